@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+	"time"
+)
+
+// settleableBet 玩法下注明细记录 各玩法的下注明细表均包含这些字段
+type settleableBet interface {
+	*model.QuickThereBetRecord | *model.GuessPointBetRecord | *model.EmojiGameBetRecord | *model.HighestRollParticipant
+}
+
+// betSettlement 一笔玩法下注的结算结果 由各玩法的派彩函数计算
+type betSettlement struct {
+	betResultType enums.BetResultType
+	amount        decimal.Decimal // 返还给用户的积分 输时为0
+	betText       string          // 结算通知中的下注内容 如 下注10积分猜【大】
+}
+
+// settleBets 结算一期的玩法下注 payout 计算单笔下注的结算结果
+// 单笔结算异常时继续结算其余下注 返回首个异常供重试
+func settleBets[T settleableBet](bot *tgbotapi.BotAPI, betRecords []T, payout func(betRecord T) betSettlement) error {
+	var settleErr error
+	for _, betRecord := range betRecords {
+		if err := settleBet(bot, betRecord, payout(betRecord)); err != nil && settleErr == nil {
+			settleErr = err
+		}
+	}
+	return settleErr
+}
+
+// settleBet 按结算结果更新用户余额及下注记录 并私聊通知用户
+func settleBet[T settleableBet](bot *tgbotapi.BotAPI, betRecord T, settlement betSettlement) error {
+	fields := betFields(betRecord)
+
+	// 查找该用户信息
+	chatGroupUser, err := store.ChatGroupUsers().QueryById(fields.chatGroupUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupUserId": fields.chatGroupUserId,
+		}).Error("未查询到该用户信息")
+		return nil
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupUserId": fields.chatGroupUserId,
+			"err":             err,
+		}).Error("查询该用户信息异常")
+		return err
+	}
+
+	// 查找该用户所属群
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupUser.ChatGroupId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": chatGroupUser.ChatGroupId,
+		}).Error("未查询到群信息")
+		return nil
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": chatGroupUser.ChatGroupId,
+			"err":         err,
+		}).Error("查询群信息异常")
+		return err
+	}
+
+	// 获取用户对应的互斥锁
+	userLockKey := fmt.Sprintf(ChatGroupUserLockKey, chatGroup.TgChatGroupId, chatGroupUser.TgUserId)
+	userLock := getUserLock(userLockKey)
+	userLock.Lock()
+	defer userLock.Unlock()
+
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	// 以结算状态为条件更新下注记录 多个结算进程重复领取同一期时只有一个能更新成功
+	betResultAmount := settlement.amount
+	if settlement.betResultType == enums.Loss {
+		betResultAmount = -fields.betAmount
+	}
+	settled, err := model.SettleBetById(tx.DB(), betRecord, fields.id, enums.Unsettled.Value, &model.BetSettlement{
+		SettleStatus:    enums.Settled.Value,
+		BetResultType:   settlement.betResultType.Value,
+		BetResultAmount: betResultAmount,
+		UpdateTime:      time.Now().Format("2006-01-02 15:04:05"),
+	})
+	if err != nil {
+		logrus.WithField("err", err).Error("更新下注记录异常")
+		return err
+	} else if !settled {
+		logrus.WithField("Id", fields.id).Warn("下注记录已结算 跳过")
+		return nil
+	}
+
+	// 按增量更新余额 避免覆盖其他进程的余额变动
+	if settlement.amount > 0 {
+		err = chatGroupUser.AddBalanceById(tx.DB(), settlement.amount)
+		if err != nil {
+			logrus.WithField("err", err).Error("更新用户余额异常")
+			return err
+		}
+	}
+	chatGroupUser, err = tx.ChatGroupUsers().QueryById(chatGroupUser.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupUserId": fields.chatGroupUserId,
+			"err":             err,
+		}).Error("查询该用户信息异常")
+		return err
+	}
+
+	if settlement.amount > 0 {
+		reasonType := enums.LedgerBetWin
+		if settlement.betResultType == enums.Refund {
+			reasonType = enums.LedgerBetRefund
+		}
+		err = recordBalanceChange(tx.DB(), chatGroupUser, settlement.amount, reasonType, fields.id)
+		if err != nil {
+			logrus.WithField("err", err).Error("记录积分流水异常")
+			return err
+		}
+	}
+
+	// 提交事务
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// 消息提醒
+	sendMsg := tgbotapi.NewMessage(chatGroupUser.TgUserId,
+		fmt.Sprintf("您在【%s】第%s期%s,结果为【%s】,积分余额%.2f。",
+			chatGroup.TgChatGroupTitle,
+			fields.issueNumber,
+			settlement.betText,
+			settlement.betResultType.Name,
+			chatGroupUser.Balance))
+	_, err = sendMessage(bot, &sendMsg)
+	blockedOrKicked(err, chatGroupUser.TgUserId)
+	return nil
+}
+
+// refundBets 作废该期 将未结算的玩法下注记录标记为退还 返回需退还给用户的下注 betTypeName 为下注类型名称
+func refundBets[T settleableBet](tx *gorm.DB, betRecords []T, betTypeName func(betRecord T) string) ([]*RefundedBet, error) {
+	var refundedBets []*RefundedBet
+	for _, betRecord := range betRecords {
+		fields := betFields(betRecord)
+		if fields.settleStatus != enums.Unsettled.Value {
+			continue
+		}
+		// 已被结算进程结算的下注不再退还
+		refunded, err := model.SettleBetById(tx, betRecord, fields.id, enums.Unsettled.Value, &model.BetSettlement{
+			SettleStatus:    enums.Settled.Value,
+			BetResultType:   enums.Refund.Value,
+			BetResultAmount: fields.betAmount,
+			UpdateTime:      time.Now().Format("2006-01-02 15:04:05"),
+		})
+		if err != nil {
+			return nil, err
+		} else if !refunded {
+			continue
+		}
+
+		refundedBets = append(refundedBets, &RefundedBet{
+			BetRecordId:     fields.id,
+			ChatGroupUserId: fields.chatGroupUserId,
+			BetTypeName:     betTypeName(betRecord),
+			BetAmount:       fields.betAmount,
+		})
+	}
+	return refundedBets, nil
+}
+
+// betRecordFields 结算用到的下注明细字段
+type betRecordFields struct {
+	id              string
+	chatGroupUserId string
+	issueNumber     string
+	betAmount       decimal.Decimal // 下注积分 比大小为参与费用
+	settleStatus    int
+}
+
+func betFields[T settleableBet](betRecord T) betRecordFields {
+	switch record := any(betRecord).(type) {
+	case *model.QuickThereBetRecord:
+		return betRecordFields{record.Id, record.ChatGroupUserId, record.IssueNumber, record.BetAmount, record.SettleStatus}
+	case *model.GuessPointBetRecord:
+		return betRecordFields{record.Id, record.ChatGroupUserId, record.IssueNumber, record.BetAmount, record.SettleStatus}
+	case *model.EmojiGameBetRecord:
+		return betRecordFields{record.Id, record.ChatGroupUserId, record.IssueNumber, record.BetAmount, record.SettleStatus}
+	case *model.HighestRollParticipant:
+		return betRecordFields{record.Id, record.ChatGroupUserId, record.IssueNumber, record.EntryFee, record.SettleStatus}
+	}
+	return betRecordFields{}
+}
+
+// betTypeText 结算通知中的下注内容 如 下注10积分猜【大】
+func betTypeText(betAmount decimal.Decimal, betType string) string {
+	lotteryType, _ := enums.GetGameLotteryType(betType)
+	return fmt.Sprintf("下注%v积分猜【%s】", betAmount, lotteryType.Name)
+}
@@ -6,52 +6,60 @@ import (
 	"github.com/go-redis/redis/v8"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 	"os"
 	"telegram-dice-bot/internal/database"
 	"telegram-dice-bot/internal/enums"
-	"telegram-dice-bot/internal/model"
-	"time"
+	"telegram-dice-bot/internal/kv"
+	"telegram-dice-bot/internal/repository"
 )
 
 const (
 	TelegramAPIToken = "TELEGRAM_API_TOKEN"
 	WhiteList        = "WHITE_LIST"
+	// StateDBPath 未配置 Redis 时运行状态保存的 BoltDB 文件 为空时保存在内存中
+	StateDBPath = "STATE_DB_PATH"
 )
 
 var (
-	db      *gorm.DB
+	store   repository.Store
 	redisDB *redis.Client
+	kvStore kv.Store
 )
 
 func StartBot() {
 	initDB()
 
+	initBalanceLedgerTask()
+
 	bot := initTelegramBot()
 
+	// 先恢复上次中断遗留的结算 再开启开奖任务
+	recoverUnsettledBets(bot)
+
+	initSettlementWorker(bot)
+
 	initGameTask(bot)
 
-	updateConfig := tgbotapi.NewUpdate(0)
-	updateConfig.Timeout = 60
-	updates := bot.GetUpdatesChan(updateConfig)
+	initDuelTask(bot)
 
-	for update := range updates {
+	updateDispatcher := newDispatcher(bot)
+	updates := receiveUpdates(bot)
+	shutdownCh := notifyShutdown()
 
-		if update.Message != nil {
-			go handleMessage(bot, update.Message)
-		} else if update.CallbackQuery != nil {
-			go handleCallbackQuery(bot, update.CallbackQuery)
+	for {
+		select {
+		case update := <-updates:
+			updateDispatcher.Dispatch(update)
+		case <-shutdownCh:
+			shutdown(bot, updates, updateDispatcher)
+			return
 		}
 	}
 }
 
 func initGameTask(bot *tgbotapi.BotAPI) {
 	// 查出所有已开启的对话
-	chatGroup := &model.ChatGroup{
-		GameplayStatus: enums.GameplayStatusON.Value,
-	}
-
-	chatGroups, err := chatGroup.ListByGameplayStatus(db)
+	chatGroups, err := store.ChatGroups().ListByGameplayStatus(enums.GameplayStatusON.Value)
 	if len(chatGroups) == 0 {
 		logrus.Println("暂无可开启的群配置")
 		return
@@ -62,21 +70,42 @@ func initGameTask(bot *tgbotapi.BotAPI) {
 	for _, group := range chatGroups {
 		// 查询当前对话在缓存中是否有未执行的任务
 		redisKey := fmt.Sprintf(RedisCurrentIssueNumberKey, group.Id)
-		issueNumberResult := redisDB.Get(redisDB.Context(), redisKey)
-		if errors.Is(issueNumberResult.Err(), redis.Nil) || issueNumberResult == nil {
+		result, err := kvStore.Get(redisKey)
+		if errors.Is(err, kv.ErrNil) {
 			// 没有未开奖的任务，开始新的期号
 			logrus.Printf("键 %s 不存在", redisKey)
-			issueNumber := time.Now().Format("20060102150405")
+			issueNumber := newIssueNumber(group)
+			if _, err := openIssue(group, issueNumber); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"chatGroupId": group.Id,
+					"issueNumber": issueNumber,
+					"err":         err,
+				}).Error("保存开期记录异常")
+				continue
+			}
+			// 存储当前期号 重启后按期号恢复剩余倒计时
+			err = kvStore.Set(redisKey, issueNumber, 0)
+			if err != nil {
+				logrus.WithField("err", err).Error("存储新期号和对话ID异常")
+				continue
+			}
 
 			go gameTaskStart(bot, group, issueNumber)
 			continue
-		} else if issueNumberResult.Err() != nil {
-			logrus.Println("获取值时发生错误:", issueNumberResult.Err())
+		} else if err != nil {
+			logrus.Println("获取值时发生错误:", err)
 			continue
 		} else {
-			// 有未开奖的任务
-			result, _ := issueNumberResult.Result()
+			// 有未开奖的任务 旧版本开启的期号没有开期记录时补上 否则无法下注
 			logrus.Printf("有未开奖的任务期号:%s", result)
+			if _, err := openIssue(group, result); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"chatGroupId": group.Id,
+					"issueNumber": result,
+					"err":         err,
+				}).Error("保存开期记录异常")
+				continue
+			}
 			go gameTaskStart(bot, group, result)
 			continue
 		}
@@ -84,57 +113,51 @@ func initGameTask(bot *tgbotapi.BotAPI) {
 }
 
 func initDB() {
-	var err error
-	db, err = database.InitDB(os.Getenv(database.DBConnectionString))
-	if err != nil {
-		logrus.Fatal("连接数据库失败:", err)
-	}
-
-	err = db.AutoMigrate(&model.ChatGroup{})
-	if err != nil {
-		logrus.Fatal("自动迁移表结构失败:", err)
+	// DB_DSN 未设置时兼容旧的 MYSQL_DSN
+	dsn := os.Getenv(database.DBDSN)
+	if dsn == "" {
+		dsn = os.Getenv(database.DBConnectionString)
 	}
 
-	err = db.AutoMigrate(&model.ChatGroupAdmin{})
-	if err != nil {
-		logrus.Fatal("自动迁移表结构失败:", err)
-	}
-
-	err = db.AutoMigrate(&model.QuickThereConfig{})
+	var err error
+	store, err = repository.Open(dsn)
 	if err != nil {
-		logrus.Fatal("自动迁移表结构失败:", err)
+		logrus.Fatal("连接数据库失败:", err)
 	}
 
-	err = db.AutoMigrate(&model.QuickThereLotteryRecord{})
+	err = store.Migrate()
 	if err != nil {
 		logrus.Fatal("自动迁移表结构失败:", err)
 	}
 
-	err = db.AutoMigrate(&model.ChatGroupUser{})
+	redisDB, err = database.InitRedisDB(os.Getenv(database.RedisDBConnectionString))
 	if err != nil {
-		logrus.Fatal("自动迁移表结构失败:", err)
+		logrus.Fatal("连接Redis数据库失败:", err)
 	}
 
-	err = db.AutoMigrate(&model.QuickThereBetRecord{})
-	if err != nil {
-		logrus.Fatal("自动迁移表结构失败:", err)
-	}
+	initKVStore()
+}
 
-	err = db.AutoMigrate(&model.LotteryRecord{})
-	if err != nil {
-		logrus.Fatal("自动迁移表结构失败:", err)
+// initKVStore 配置了 Redis 时运行状态保存在 Redis 中 否则使用内置存储 仅支持单实例部署
+func initKVStore() {
+	if redisDB != nil {
+		kvStore = kv.NewRedisStore(redisDB)
+		return
 	}
 
-	err = db.AutoMigrate(&model.BetRecord{})
-	if err != nil {
-		logrus.Fatal("自动迁移表结构失败:", err)
+	path := os.Getenv(StateDBPath)
+	if path == "" {
+		kvStore = kv.NewMemoryStore()
+		logrus.Warn("未配置 Redis 运行状态保存在内存中 重启后当前期号和对话状态将丢失")
+		return
 	}
 
-	redisDB, err = database.InitRedisDB(os.Getenv(database.RedisDBConnectionString))
+	var err error
+	kvStore, err = kv.OpenBoltStore(path)
 	if err != nil {
-		logrus.Fatal("连接Redis数据库失败:", err)
+		logrus.Fatal("打开状态存储文件失败:", err)
 	}
-
+	logrus.WithField("path", path).Info("未配置 Redis 运行状态保存在 BoltDB 中")
 }
 
 func initTelegramBot() *tgbotapi.BotAPI {
@@ -53,12 +53,63 @@ func handleCallbackQuery(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQ
 		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickThereTripletOdds.Value) {
 			// 群配置-更新快三-豹子赔率
 			updateQuickThereTripletOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickThereComboOdds.Value) {
+			// 群配置-更新快三-组合赔率
+			updateQuickThereComboOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickThereSumOdds.Value) {
+			// 群配置-更新快三-和值赔率
+			updateQuickThereSumOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickThereSpecificTripletOdds.Value) {
+			// 群配置-更新快三-指定豹子赔率
+			updateQuickThereSpecificTripletOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickTherePairOdds.Value) {
+			// 群配置-更新快三-对子赔率
+			updateQuickTherePairOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickThereSpecificPairOdds.Value) {
+			// 群配置-更新快三-指定对子赔率
+			updateQuickThereSpecificPairOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickThereNumberOdds.Value) {
+			// 群配置-更新快三-单号赔率
+			updateQuickThereNumberOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickThereTripleKill.Value) {
+			// 群配置-更新快三-豹子通杀
+			updateQuickThereTripleKillCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickThereOddsMode.Value) {
+			// 群配置-更新快三-赔付模式
+			updateQuickThereOddsModeCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateQuickTherePoolRakeRate.Value) {
+			// 群配置-更新快三-奖池抽成比例
+			updateQuickTherePoolRakeRateCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateDiceSource.Value) {
+			// 群配置-更新骰子来源
+			updateDiceSourceCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateGuessPointPointOdds.Value) {
+			// 群配置-更新猜点数-点数赔率
+			updateGuessPointPointOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateGuessPointSimpleOdds.Value) {
+			// 群配置-更新猜点数-简易赔率
+			updateGuessPointSimpleOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateEmojiGameOdds.Value) {
+			// 群配置-更新表情骰子玩法-赔率
+			updateEmojiGameOddsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateHighestRollEntryFee.Value) {
+			// 群配置-更新比大小-参与费用
+			updateHighestRollEntryFeeCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateDuelFeeRate.Value) {
+			// 群配置-更新对决抽成比例
+			updateDuelFeeRateCallBack(bot, callbackQuery)
 		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateGameplayStatus.Value) {
 			// 群配置-更新游戏状态
 			updateGameplayStatusCallBack(bot, callbackQuery)
 		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateGameDrawCycle.Value) {
 			// 群配置-更新游戏开奖周期
 			updateGameDrawCycleCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateBetCloseSeconds.Value) {
+			// 群配置-更新开奖前封盘时间
+			updateBetCloseSecondsCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackUpdateDrawSchedule.Value) {
+			// 群配置-更新开奖计划
+			updateDrawScheduleCallBack(bot, callbackQuery)
 		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackQueryChatGroupUser.Value) {
 			// 查询用户信息
 			queryChatGroupUser(bot, callbackQuery)
@@ -73,6 +124,12 @@ func handleCallbackQuery(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQ
 		if callbackQuery.Data == enums.CallbackLotteryHistory.Value {
 			// 群内联键盘 查看开奖历史
 			lotteryHistoryCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackAcceptDuel.Value) {
+			// 群内联键盘 接受对决
+			acceptDuelCallBack(bot, callbackQuery)
+		} else if strings.HasPrefix(callbackQuery.Data, enums.CallbackDeclineDuel.Value) {
+			// 群内联键盘 拒绝对决
+			declineDuelCallBack(bot, callbackQuery)
 		}
 	}
 }
@@ -101,7 +158,7 @@ func exitAdminGroupCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery)
 	chatGroupId := callBackData["chatGroupId"]
 
 	// 查询该群信息
-	chatGroup, err := model.QueryChatGroupById(db, chatGroupId)
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithField("chatGroupId", chatGroupId).Error("群未初始化过配置")
@@ -112,13 +169,16 @@ func exitAdminGroupCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery)
 	}
 
 	// 删除该用户
-	chatGroupUserQuery := &model.ChatGroupAdmin{
-		AdminTgUserId: fromUser.ID,
-		ChatGroupId:   chatGroup.Id,
+	err = store.ChatGroupAdmins().DeleteByChatGroupIdAndAdminTgUserId(chatGroup.Id, fromUser.ID)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroup.Id,
+			"tgUserId":    fromUser.ID,
+			"err":         err,
+		}).Error("删除群管理员异常")
+		return
 	}
 
-	chatGroupUserQuery.DeleteByChatGroupIdAndAdminTgUserId(db)
-
 	// 更新上条消息
 	sendMsg, err := buildAdminGroupMsg(query)
 	if err != nil {
@@ -216,7 +276,7 @@ func exitGroupCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
 	chatGroupId := callBackData["chatGroupId"]
 
 	// 查询该群信息
-	chatGroup, err := model.QueryChatGroupById(db, chatGroupId)
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
@@ -237,7 +297,7 @@ func exitGroupCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
 		ChatGroupId: chatGroup.Id,
 	}
 
-	chatGroupUser, err := chatGroupUserQuery.QueryByTgUserIdAndChatGroupId(db)
+	chatGroupUser, err := store.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
 			"TgUserId":    fromUser.ID,
@@ -255,9 +315,9 @@ func exitGroupCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
 
 	// 更新该用户状态为离开
 	chatGroupUser.IsLeft = 1
-	result := db.Save(&chatGroupUser)
+	err = chatGroupUser.UpdateIsLeftById(store.DB())
 
-	if result.Error != nil {
+	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"err": err,
 		}).Error("保存用户信息异常")
@@ -310,7 +370,7 @@ func chatGroupInfoCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery)
 	chatGroupId := callBackData["chatGroupId"]
 
 	// 查询该群信息
-	chatGroup, err := model.QueryChatGroupById(db, chatGroupId)
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
@@ -331,7 +391,7 @@ func chatGroupInfoCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery)
 		ChatGroupId: chatGroup.Id,
 	}
 
-	chatGroupUser, err := chatGroupUserQuery.QueryByTgUserIdAndChatGroupId(db)
+	chatGroupUser, err := store.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
 			"TgUserId":    fromUser.ID,
@@ -382,6 +442,79 @@ func chatGroupInfoCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery)
 
 }
 
+func updateQuickThereSumOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateQuickThereSumOdds.Value)+len(enums.CallbackUpdateQuickThereSumOdds.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), chatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("查询群的快三配置异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("当前【经典快三】和值倍率:\n%s\n\n"+
+		"请按照以下格式输入要设置的和值倍率(多个用空格分隔):\n"+
+		"[和值]=[倍率] 例子: 3=240 10=9", formatQuickThereSumOdds(quickThereSumOdds(quickThereConfig))))
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitQuickThereSumOdds.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitQuickThereSumOdds.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
 func updateQuickThereTripletOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
 	chatId := query.Message.Chat.ID
 	fromUser := query.From
@@ -506,95 +639,74 @@ func updateQuickThereSimpleOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.Ca
 	}
 }
 
-func lotteryHistoryCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
-	tgChatGroupId := query.Message.Chat.ID
+func updateQuickThereComboOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
 
-	// 查询该群历史开奖信息
-	chatGroup, err := model.QueryChatGroupByTgChatId(db, tgChatGroupId)
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateQuickThereComboOdds.Value)+len(enums.CallbackUpdateQuickThereComboOdds.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"tgChatGroupId": tgChatGroupId,
-			"err":           err,
-		}).Error("群配置查询异常")
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
 		return
 	}
+	callBackDataKey := queryStringToMap["callbackKey"]
 
-	sendMsg := tgbotapi.NewMessage(tgChatGroupId, "")
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
 
-	lotteryRecord := &model.LotteryRecord{ChatGroupId: chatGroup.Id}
-	lotteryRecords, err := lotteryRecord.ListByChatGroupId(db)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"tgChatGroupId": tgChatGroupId,
-			"err":           err,
-		}).Error("开奖记录查询异常")
+		logrus.Error("内联键盘回调参数redis查询异常")
 		return
 	}
-	if len(lotteryRecords) == 0 {
-		sendMsg.Text = "暂无开奖记录"
-	} else {
-		sendMsg.Text = "近10期开奖记录:\n"
-		for _, record := range lotteryRecords {
-			// 开奖类型查询开奖信息
-			switch record.GameplayType {
-			case enums.QuickThere.Value:
-				quickThereLotteryRecord := &model.QuickThereLotteryRecord{
-					Id: record.Id,
-				}
-				quickThereLotteryRecord, err := quickThereLotteryRecord.QueryById(db)
-				if err != nil {
-					logrus.WithFields(logrus.Fields{
-						"IssueNumber": record.IssueNumber,
-					}).Error("快三开奖记录查询异常")
-					return
-				}
-
-				bigSmall, _ := enums.GetGameLotteryType(quickThereLotteryRecord.BigSmall)
-				singleDouble, _ := enums.GetGameLotteryType(quickThereLotteryRecord.SingleDouble)
-
-				triplet := ""
-				if quickThereLotteryRecord.Triplet == 1 {
-					triplet = "【豹子】"
-				}
-
-				sendMsg.Text += fmt.Sprintf("%s期 %s %d+%d+%d=%d %s %s %s\n",
-					quickThereLotteryRecord.IssueNumber,
-					"快三",
-					quickThereLotteryRecord.ValueA,
-					quickThereLotteryRecord.ValueB,
-					quickThereLotteryRecord.ValueC,
-					quickThereLotteryRecord.ValueA+quickThereLotteryRecord.ValueB+quickThereLotteryRecord.ValueC,
-					bigSmall.Name,
-					singleDouble.Name,
-					triplet,
-				)
-			}
-		}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
 	}
-	sentMsg, err := sendMessage(bot, &sendMsg)
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的【经典快三】组合倍率(大单/大双/小单/小双):")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitQuickThereComboOdds.Value,
+		ChatGroupId: chatGroupId,
+	})
 
 	if err != nil {
-		blockedOrKicked(err, tgChatGroupId)
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitQuickThereComboOdds.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
 		return
 	}
 
-	go func(messageID int) {
-		time.Sleep(1 * time.Minute)
-		deleteMsg := tgbotapi.NewDeleteMessage(tgChatGroupId, messageID)
-		_, err := bot.Request(deleteMsg)
-		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"err": err,
-			}).Error("删除消息异常")
-		}
-	}(sentMsg.MessageID)
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
 }
 
-func updateChatGroupUserBalance(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+func updateQuickThereSpecificTripletOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
 	chatId := query.Message.Chat.ID
 	fromUser := query.From
 
-	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateChatGroupUserBalance.Value)+len(enums.CallbackUpdateChatGroupUserBalance.Value):]
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateQuickThereSpecificTripletOdds.Value)+len(enums.CallbackUpdateQuickThereSpecificTripletOdds.Value):]
 
 	queryStringToMap, err := utils.QueryStringToMap(queryString)
 	if err != nil {
@@ -625,21 +737,18 @@ func updateChatGroupUserBalance(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQu
 		return
 	}
 
-	sendMsg := tgbotapi.NewMessage(chatId, "请按照以下格式修改用户积分:\n"+
-		"增加用户积分:[用户Id]+[积分] 例子: 10086+100\n"+
-		"减少用户积分:[用户Id]-[积分] 例子: 10086-100\n"+
-		"设置用户积分:[用户Id]=[积分] 例子: 10086=1000")
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的【经典快三】指定豹子倍率(豹子1-豹子6):")
 
 	// 设置当前机器人状态
 	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
-		ChatStatus:  enums.WaitUpdateUserBalance.Value,
+		ChatStatus:  enums.WaitQuickThereSpecificTripletOdds.Value,
 		ChatGroupId: chatGroupId,
 	})
 
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"fromUserId":  fromUser.ID,
-			"ChatStatus":  enums.WaitUpdateUserBalance.Value,
+			"ChatStatus":  enums.WaitQuickThereSpecificTripletOdds.Value,
 			"ChatGroupId": chatGroupId,
 			"err":         err,
 		}).Error("BotChatStatus 设置异常")
@@ -654,12 +763,12 @@ func updateChatGroupUserBalance(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQu
 	}
 }
 
-func queryChatGroupUser(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
-
+func updateQuickTherePairOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
 	chatId := query.Message.Chat.ID
 	fromUser := query.From
 
-	queryString := query.Data[strings.Index(query.Data, enums.CallbackQueryChatGroupUser.Value)+len(enums.CallbackQueryChatGroupUser.Value):]
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateQuickTherePairOdds.Value)+len(enums.CallbackUpdateQuickTherePairOdds.Value):]
 
 	queryStringToMap, err := utils.QueryStringToMap(queryString)
 	if err != nil {
@@ -690,18 +799,18 @@ func queryChatGroupUser(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
 		return
 	}
 
-	sendMsg := tgbotapi.NewMessage(chatId, "请输入当前群聊中的用户名称，如:@username")
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的【经典快三】对子倍率:")
 
 	// 设置当前机器人状态
-	err = PrivateChatCacheAddRedis(chatId, &common.BotPrivateChatCache{
-		ChatStatus:  enums.WaitQueryUser.Value,
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitQuickTherePairOdds.Value,
 		ChatGroupId: chatGroupId,
 	})
 
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"fromUserId":  fromUser.ID,
-			"ChatStatus":  enums.WaitQueryUser.Value,
+			"ChatStatus":  enums.WaitQuickTherePairOdds.Value,
 			"ChatGroupId": chatGroupId,
 			"err":         err,
 		}).Error("BotChatStatus 设置异常")
@@ -716,12 +825,12 @@ func queryChatGroupUser(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
 	}
 }
 
-func updateGameDrawCycleCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+func updateQuickThereSpecificPairOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
 	chatId := query.Message.Chat.ID
 	fromUser := query.From
 
 	// 查询当前群配置的游戏类型
-	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateGameDrawCycle.Value)+len(enums.CallbackUpdateGameDrawCycle.Value):]
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateQuickThereSpecificPairOdds.Value)+len(enums.CallbackUpdateQuickThereSpecificPairOdds.Value):]
 
 	queryStringToMap, err := utils.QueryStringToMap(queryString)
 	if err != nil {
@@ -752,18 +861,18 @@ func updateGameDrawCycleCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQ
 		return
 	}
 
-	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的开奖周期(1-60的整数)(单位:分钟)")
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的【经典快三】指定对子倍率(对子1-对子6):")
 
 	// 设置当前机器人状态
 	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
-		ChatStatus:  enums.WaitGameDrawCycle.Value,
+		ChatStatus:  enums.WaitQuickThereSpecificPairOdds.Value,
 		ChatGroupId: chatGroupId,
 	})
 
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"fromUserId":  fromUser.ID,
-			"ChatStatus":  enums.WaitGameDrawCycle.Value,
+			"ChatStatus":  enums.WaitQuickThereSpecificPairOdds.Value,
 			"ChatGroupId": chatGroupId,
 			"err":         err,
 		}).Error("BotChatStatus 设置异常")
@@ -778,13 +887,12 @@ func updateGameDrawCycleCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQ
 	}
 }
 
-func updateGameplayStatusCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
-	chatID := query.Message.Chat.ID
-	messageID := query.Message.MessageID
+func updateQuickThereNumberOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
 	fromUser := query.From
 
-	// 查询使用的chatGroupId为内联键盘中的Data
-	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateGameplayStatus.Value)+len(enums.CallbackUpdateGameplayStatus.Value):]
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateQuickThereNumberOdds.Value)+len(enums.CallbackUpdateQuickThereNumberOdds.Value):]
 
 	queryStringToMap, err := utils.QueryStringToMap(queryString)
 	if err != nil {
@@ -815,77 +923,50 @@ func updateGameplayStatusCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.Callback
 		return
 	}
 
-	chatGroup, err := model.QueryChatGroupById(db, chatGroupId)
-
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		logrus.WithFields(logrus.Fields{
-			"chatGroupId": chatGroupId,
-		}).Error("未查询到群配置信息 [未初始化]")
-		return
-	} else if err != nil {
+	quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), chatGroupId)
+	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"chatGroupId": chatGroupId,
 			"err":         err,
-		}).Error("群配置信息查询异常")
+		}).Error("查询群的快三配置异常")
 		return
 	}
 
-	// 更新群配置-游戏状态
-	chatGroupUpdate := &model.ChatGroup{
-		Id: chatGroupId,
-	}
-	if chatGroup.GameplayStatus == enums.GameplayStatusON.Value {
-		chatGroupUpdate.GameplayStatus = enums.GameplayStatusOFF.Value
-		chatGroup.GameplayStatus = enums.GameplayStatusOFF.Value
-		gameStop(chatGroup)
-		// 发送提示消息
-		sendMsg := tgbotapi.NewMessage(chatID, "关闭成功!")
-		_, err = sendMessage(bot, &sendMsg)
-		blockedOrKicked(err, chatID)
-	} else {
-		chatGroupUpdate.GameplayStatus = enums.GameplayStatusON.Value
-		chatGroup.GameplayStatus = enums.GameplayStatusON.Value
-		// 开启
-		gameStart(bot, chatGroup)
-		// 发送提示消息
-		sendMsg := tgbotapi.NewMessage(chatID, "开启成功!")
-		_, err = sendMessage(bot, &sendMsg)
-		blockedOrKicked(err, chatID)
-	}
-	err = chatGroupUpdate.UpdateChatGroupStatusById(db)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"err": err,
-		}).Error("更新群配置-游戏状态异常")
-		return
-	}
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("当前【经典快三】单号倍率(按所选点数出现次数赔付):\n%s\n\n"+
+		"请按照以下格式输入要设置的单号倍率(多个用空格分隔):\n"+
+		"[出现次数]=[倍率] 例子: 1=2 2=3 3=4", formatQuickThereNumberOdds(quickThereNumberOdds(quickThereConfig))))
 
-	inlineKeyboardMarkup, err := buildChatGroupInlineKeyboardMarkup(query, chatGroup)
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitQuickThereNumberOdds.Value,
+		ChatGroupId: chatGroupId,
+	})
 
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"err": err,
-		}).Error("组装群组配置内联键盘异常")
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitQuickThereNumberOdds.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
 		return
 	}
 
-	sendMsg := tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("点击修改【%s】相关配置:", chatGroup.TgChatGroupTitle))
-
-	sendMsg.ReplyMarkup = inlineKeyboardMarkup
 	_, err = sendMessage(bot, &sendMsg)
+
 	if err != nil {
-		blockedOrKicked(err, chatID)
+		blockedOrKicked(err, chatId)
 		return
 	}
 }
 
-func updateGameplayTypeCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
-	chatId := query.Message.Chat.ID
+func updateQuickThereTripleKillCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
 	fromUser := query.From
-	messageId := query.Message.MessageID
 
-	// 查询当前群配置的游戏类型
-	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateGameplayType.Value)+len(enums.CallbackUpdateGameplayType.Value):]
+	// 查询使用的chatGroupId为内联键盘中的Data
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateQuickThereTripleKill.Value)+len(enums.CallbackUpdateQuickThereTripleKill.Value):]
 
 	queryStringToMap, err := utils.QueryStringToMap(queryString)
 	if err != nil {
@@ -905,7 +986,6 @@ func updateGameplayTypeCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQu
 	}
 
 	chatGroupId := callBackData["chatGroupId"]
-	gameplayType := callBackData["gameplayType"]
 
 	// 校验当前对话人是否为该群管理员
 	err = checkGroupAdmin(chatGroupId, fromUser.ID)
@@ -917,18 +997,1308 @@ func updateGameplayTypeCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQu
 		return
 	}
 
-	// 更改配置
-	err = model.UpdateChatGroupGameplayTypeById(db, &model.ChatGroup{
-		Id:           chatGroupId,
-		GameplayType: gameplayType,
-	})
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("群配置信息查询异常")
+		return
+	}
 
+	quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), chatGroupId)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"chatGroupId":  chatGroupId,
-			"GameplayType": gameplayType,
-			"err":          err,
-		}).Error("更新群配置异常")
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("查询群的快三配置异常")
+		return
+	}
+
+	// 更新快三配置-豹子通杀
+	quickThereConfigUpdate := &model.QuickThereConfig{
+		ChatGroupId: chatGroupId,
+	}
+	if quickThereConfig.TripleKill == enums.TripleKillON.Value {
+		quickThereConfigUpdate.TripleKill = enums.TripleKillOFF.Value
+	} else {
+		quickThereConfigUpdate.TripleKill = enums.TripleKillON.Value
+	}
+	err = quickThereConfigUpdate.UpdateTripleKillByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"TripleKill":  quickThereConfigUpdate.TripleKill,
+			"err":         err,
+		}).Error("更新快三配置-豹子通杀异常")
+		return
+	}
+
+	tripleKillStatus, _ := enums.GetTripleKillStatus(quickThereConfigUpdate.TripleKill)
+	sendMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("【经典快三】豹子通杀%s!", tripleKillStatus.Name))
+	_, err = sendMessage(bot, &sendMsg)
+	blockedOrKicked(err, chatID)
+
+	inlineKeyboardMarkup, err := buildChatGroupInlineKeyboardMarkup(query, chatGroup)
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("组装群组配置内联键盘异常")
+		return
+	}
+
+	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("点击修改【%s】相关配置:", chatGroup.TgChatGroupTitle))
+
+	editMsg.ReplyMarkup = inlineKeyboardMarkup
+	_, err = sendMessage(bot, &editMsg)
+	if err != nil {
+		blockedOrKicked(err, chatID)
+		return
+	}
+}
+
+func updateQuickThereOddsModeCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+	fromUser := query.From
+
+	// 查询使用的chatGroupId为内联键盘中的Data
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateQuickThereOddsMode.Value)+len(enums.CallbackUpdateQuickThereOddsMode.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+
+	quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), chatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("查询群的快三配置异常")
+		return
+	}
+
+	// 游戏进行中切换赔付模式会导致当期下注按新模式结算
+	if chatGroup.GameplayStatus == enums.GameplayStatusON.Value {
+		sendMsg := tgbotapi.NewMessage(chatID, "请先关闭游戏后再切换赔付模式!")
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatID)
+		return
+	}
+
+	// 更新快三配置-赔付模式
+	quickThereConfigUpdate := &model.QuickThereConfig{
+		ChatGroupId: chatGroupId,
+	}
+	if quickThereConfig.OddsMode == enums.OddsModePool.Value {
+		quickThereConfigUpdate.OddsMode = enums.OddsModeFixed.Value
+	} else {
+		quickThereConfigUpdate.OddsMode = enums.OddsModePool.Value
+	}
+	err = quickThereConfigUpdate.UpdateOddsModeByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"OddsMode":    quickThereConfigUpdate.OddsMode,
+			"err":         err,
+		}).Error("更新快三配置-赔付模式异常")
+		return
+	}
+
+	oddsMode, _ := enums.GetOddsMode(quickThereConfigUpdate.OddsMode)
+	sendMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("【经典快三】赔付模式已切换为%s,下一期起生效!", oddsMode.Name))
+	_, err = sendMessage(bot, &sendMsg)
+	blockedOrKicked(err, chatID)
+
+	inlineKeyboardMarkup, err := buildChatGroupInlineKeyboardMarkup(query, chatGroup)
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("组装群组配置内联键盘异常")
+		return
+	}
+
+	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("点击修改【%s】相关配置:", chatGroup.TgChatGroupTitle))
+
+	editMsg.ReplyMarkup = inlineKeyboardMarkup
+	_, err = sendMessage(bot, &editMsg)
+	if err != nil {
+		blockedOrKicked(err, chatID)
+		return
+	}
+}
+
+func updateQuickTherePoolRakeRateCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateQuickTherePoolRakeRate.Value)+len(enums.CallbackUpdateQuickTherePoolRakeRate.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的【经典快三】奖池抽成比例(0-99)(单位:%)")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitQuickTherePoolRakeRate.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitQuickTherePoolRakeRate.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func updateDiceSourceCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+	fromUser := query.From
+
+	// 查询使用的chatGroupId为内联键盘中的Data
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateDiceSource.Value)+len(enums.CallbackUpdateDiceSource.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+
+	// 游戏进行中切换骰子来源会导致已公布的种子承诺失效
+	if chatGroup.GameplayStatus == enums.GameplayStatusON.Value {
+		sendMsg := tgbotapi.NewMessage(chatID, "请先关闭游戏后再切换骰子来源!")
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatID)
+		return
+	}
+
+	// 更新群配置-骰子来源 按 Telegram骰子->系统随机->可验证随机 轮换
+	chatGroupUpdate := &model.ChatGroup{
+		Id: chatGroupId,
+	}
+	switch chatGroup.DiceSource {
+	case enums.DiceSourceTelegram.Value:
+		chatGroupUpdate.DiceSource = enums.DiceSourceCrypto.Value
+	case enums.DiceSourceCrypto.Value:
+		// 可验证随机仅经典快三支持
+		if chatGroup.GameplayType == enums.QuickThere.Value {
+			chatGroupUpdate.DiceSource = enums.DiceSourceProvablyFair.Value
+		} else {
+			chatGroupUpdate.DiceSource = enums.DiceSourceTelegram.Value
+		}
+	default:
+		chatGroupUpdate.DiceSource = enums.DiceSourceTelegram.Value
+	}
+	err = store.ChatGroups().UpdateColumnsById(chatGroupUpdate, "dice_source")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"DiceSource":  chatGroupUpdate.DiceSource,
+			"err":         err,
+		}).Error("更新群配置-骰子来源异常")
+		return
+	}
+	chatGroup.DiceSource = chatGroupUpdate.DiceSource
+
+	diceSource, _ := enums.GetDiceSource(chatGroupUpdate.DiceSource)
+	sendMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("骰子来源已切换为%s,本期开奖起生效!", diceSource.Name))
+	_, err = sendMessage(bot, &sendMsg)
+	blockedOrKicked(err, chatID)
+
+	inlineKeyboardMarkup, err := buildChatGroupInlineKeyboardMarkup(query, chatGroup)
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("组装群组配置内联键盘异常")
+		return
+	}
+
+	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("点击修改【%s】相关配置:", chatGroup.TgChatGroupTitle))
+
+	editMsg.ReplyMarkup = inlineKeyboardMarkup
+	_, err = sendMessage(bot, &editMsg)
+	if err != nil {
+		blockedOrKicked(err, chatID)
+		return
+	}
+}
+
+func updateGuessPointPointOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateGuessPointPointOdds.Value)+len(enums.CallbackUpdateGuessPointPointOdds.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的【猜点数】点数倍率(点1-点6):")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitGuessPointPointOdds.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitGuessPointPointOdds.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func updateGuessPointSimpleOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateGuessPointSimpleOdds.Value)+len(enums.CallbackUpdateGuessPointSimpleOdds.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的【猜点数】简易倍率(大/小/单/双):")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitGuessPointSimpleOdds.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitGuessPointSimpleOdds.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func updateHighestRollEntryFeeCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateHighestRollEntryFee.Value)+len(enums.CallbackUpdateHighestRollEntryFee.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的【比大小】每期参与费用:")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitHighestRollEntryFee.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitHighestRollEntryFee.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func updateEmojiGameOddsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateEmojiGameOdds.Value)+len(enums.CallbackUpdateEmojiGameOdds.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+
+	gameplay, b := getGameplay(chatGroup.GameplayType)
+	emojiGame, isEmojiGame := gameplay.(*emojiGameplay)
+	if !b || !isEmojiGame {
+		logrus.WithField("GameplayType", chatGroup.GameplayType).Error("群配置玩法非表情骰子玩法")
+		return
+	}
+
+	emojiGameConfig, err := model.QueryEmojiGameConfigByChatGroupIdAndGameplayType(store.DB(), chatGroupId, chatGroup.GameplayType)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId":  chatGroupId,
+			"GameplayType": chatGroup.GameplayType,
+			"err":          err,
+		}).Error("查询群的玩法配置异常")
+		return
+	}
+
+	exampleBetType := emojiGame.rule.betTypes[0]
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("当前【%s】倍率:\n%s\n\n"+
+		"请按照以下格式输入要设置的倍率(多个用空格分隔):\n"+
+		"[竞猜类型]=[倍率] 例子: %s=%v",
+		emojiGame.gameplayType.Name,
+		emojiGame.formatOdds(emojiGame.odds(emojiGameConfig)),
+		exampleBetType.Name, emojiGame.rule.defaultOdds[exampleBetType.Value]))
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitEmojiGameOdds.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitEmojiGameOdds.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func lotteryHistoryCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	tgChatGroupId := query.Message.Chat.ID
+
+	// 查询该群历史开奖信息
+	chatGroup, err := store.ChatGroups().QueryByTgChatId(tgChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"tgChatGroupId": tgChatGroupId,
+			"err":           err,
+		}).Error("群配置查询异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(tgChatGroupId, "")
+
+	lotteryRecord := &model.LotteryRecord{ChatGroupId: chatGroup.Id}
+	lotteryRecords, err := store.LotteryRecords().ListByChatGroupId(lotteryRecord.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"tgChatGroupId": tgChatGroupId,
+			"err":           err,
+		}).Error("开奖记录查询异常")
+		return
+	}
+	if len(lotteryRecords) == 0 {
+		sendMsg.Text = "暂无开奖记录"
+	} else {
+		sendMsg.Text = "近10期开奖记录:\n"
+		for _, record := range lotteryRecords {
+			// 作废的期号没有玩法开奖记录
+			if record.Status == enums.LotteryCanceled.Value {
+				sendMsg.Text += fmt.Sprintf("%s期 %s\n", record.IssueNumber, enums.LotteryCanceled.Name)
+				continue
+			}
+			// 开奖类型查询开奖信息
+			gameplay, ok := getGameplay(record.GameplayType)
+			if !ok {
+				continue
+			}
+			lotteryHistory, err := gameplay.FormatLotteryHistory(store.DB(), record)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"IssueNumber": record.IssueNumber,
+					"err":         err,
+				}).Error("玩法开奖记录查询异常")
+				return
+			}
+			sendMsg.Text += lotteryHistory
+		}
+	}
+	sentMsg, err := sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, tgChatGroupId)
+		return
+	}
+
+	go func(messageID int) {
+		time.Sleep(1 * time.Minute)
+		deleteMsg := tgbotapi.NewDeleteMessage(tgChatGroupId, messageID)
+		_, err := bot.Request(deleteMsg)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"err": err,
+			}).Error("删除消息异常")
+		}
+	}(sentMsg.MessageID)
+}
+
+func updateChatGroupUserBalance(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateChatGroupUserBalance.Value)+len(enums.CallbackUpdateChatGroupUserBalance.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请按照以下格式修改用户积分:\n"+
+		"增加用户积分:[用户Id]+[积分] 例子: 10086+100\n"+
+		"减少用户积分:[用户Id]-[积分] 例子: 10086-100\n"+
+		"设置用户积分:[用户Id]=[积分] 例子: 10086=1000")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitUpdateUserBalance.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitUpdateUserBalance.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func queryChatGroupUser(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackQueryChatGroupUser.Value)+len(enums.CallbackQueryChatGroupUser.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入当前群聊中的用户名称，如:@username")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(chatId, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitQueryUser.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitQueryUser.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func updateGameDrawCycleCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateGameDrawCycle.Value)+len(enums.CallbackUpdateGameDrawCycle.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的开奖周期(1-60的整数)(单位:分钟)")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitGameDrawCycle.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitGameDrawCycle.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func updateDuelFeeRateCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateDuelFeeRate.Value)+len(enums.CallbackUpdateDuelFeeRate.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的对决抽成比例(0-99)(单位:%)")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitDuelFeeRate.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitDuelFeeRate.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func updateBetCloseSecondsCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateBetCloseSeconds.Value)+len(enums.CallbackUpdateBetCloseSeconds.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, "请输入️要设置的开奖前封盘时间(单位:秒),0为开奖前不封盘")
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitBetCloseSeconds.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitBetCloseSeconds.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func updateDrawScheduleCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateDrawSchedule.Value)+len(enums.CallbackUpdateDrawSchedule.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("群配置查询异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("当前开奖计划:\n%s\n\n"+
+		"请输入要设置的开奖计划,每行一项,不需要的项可省略:\n"+
+		"cron=*/5 9-22 * * * (分 时 日 月 周,省略时按开奖周期开奖)\n"+
+		"quiet=23:00-09:00,12:00-13:00 (静默时段,时段内不开奖)\n"+
+		"tz=Asia/Shanghai (时区,省略时使用服务器时区)\n"+
+		"输入 清除 恢复为按开奖周期全天开奖", formatDrawSchedule(chatGroup)))
+
+	// 设置当前机器人状态
+	err = PrivateChatCacheAddRedis(fromUser.ID, &common.BotPrivateChatCache{
+		ChatStatus:  enums.WaitDrawSchedule.Value,
+		ChatGroupId: chatGroupId,
+	})
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"fromUserId":  fromUser.ID,
+			"ChatStatus":  enums.WaitDrawSchedule.Value,
+			"ChatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("BotChatStatus 设置异常")
+		return
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+}
+
+func updateGameplayStatusCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+	fromUser := query.From
+
+	// 查询使用的chatGroupId为内联键盘中的Data
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateGameplayStatus.Value)+len(enums.CallbackUpdateGameplayStatus.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+		}).Error("未查询到群配置信息 [未初始化]")
+		return
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+
+	// 更新群配置-游戏状态
+	chatGroupUpdate := &model.ChatGroup{
+		Id: chatGroupId,
+	}
+	if chatGroup.GameplayStatus == enums.GameplayStatusON.Value {
+		chatGroupUpdate.GameplayStatus = enums.GameplayStatusOFF.Value
+		chatGroup.GameplayStatus = enums.GameplayStatusOFF.Value
+		gameStop(chatGroup)
+		// 发送提示消息
+		sendMsg := tgbotapi.NewMessage(chatID, "关闭成功!")
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatID)
+	} else {
+		chatGroupUpdate.GameplayStatus = enums.GameplayStatusON.Value
+		chatGroup.GameplayStatus = enums.GameplayStatusON.Value
+		// 开启
+		gameStart(bot, chatGroup)
+		// 发送提示消息
+		sendMsg := tgbotapi.NewMessage(chatID, "开启成功!")
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatID)
+	}
+	err = store.ChatGroups().UpdateColumnsById(chatGroupUpdate, "gameplay_status")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("更新群配置-游戏状态异常")
+		return
+	}
+
+	inlineKeyboardMarkup, err := buildChatGroupInlineKeyboardMarkup(query, chatGroup)
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("组装群组配置内联键盘异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("点击修改【%s】相关配置:", chatGroup.TgChatGroupTitle))
+
+	sendMsg.ReplyMarkup = inlineKeyboardMarkup
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatID)
+		return
+	}
+}
+
+func updateGameplayTypeCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	fromUser := query.From
+	messageId := query.Message.MessageID
+
+	// 查询当前群配置的游戏类型
+	queryString := query.Data[strings.Index(query.Data, enums.CallbackUpdateGameplayType.Value)+len(enums.CallbackUpdateGameplayType.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+	callBackDataKey := queryStringToMap["callbackKey"]
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(callBackDataKey)
+
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return
+	}
+
+	chatGroupId := callBackData["chatGroupId"]
+	gameplayType := callBackData["gameplayType"]
+
+	// 校验当前对话人是否为该群管理员
+	err = checkGroupAdmin(chatGroupId, fromUser.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"fromUserID":  fromUser.ID,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroupId,
+			"err":         err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+
+	// 游戏进行中切换玩法会导致当期下注无法结算
+	if chatGroup.GameplayStatus == enums.GameplayStatusON.Value && chatGroup.GameplayType != gameplayType {
+		sendMsg := tgbotapi.NewMessage(chatId, "请先关闭游戏后再切换玩法!")
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	}
+
+	gameplay, b := getGameplay(gameplayType)
+	if !b {
+		logrus.WithField("GameplayType", gameplayType).Error("群配置玩法未注册")
+		return
+	}
+
+	// 关闭游戏时当前期仍保留期号及下注 切换前按原玩法作废并退还
+	if chatGroup.GameplayType != gameplayType {
+		err = cancelCurrentIssue(bot, chatGroup, "切换玩法")
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"chatGroupId": chatGroupId,
+				"err":         err,
+			}).Error("切换玩法作废当前期异常")
+			sendMsg := tgbotapi.NewMessage(chatId, "当前期作废失败,请稍后重试!")
+			_, err = sendMessage(bot, &sendMsg)
+			blockedOrKicked(err, chatId)
+			return
+		}
+	}
+
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	// 初始化该玩法配置(加入群时尚未有该玩法的群)
+	err = gameplay.InitConfig(tx.DB(), chatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId":  chatGroupId,
+			"GameplayType": gameplayType,
+			"err":          err,
+		}).Error("初始化玩法配置异常")
+		tx.Rollback()
+		return
+	}
+
+	// 更改配置
+	err = tx.ChatGroups().UpdateColumnsById(&model.ChatGroup{
+		Id:           chatGroupId,
+		GameplayType: gameplayType,
+	}, "gameplay_type")
+
+	if err != nil {
+		tx.Rollback()
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId":  chatGroupId,
+			"GameplayType": gameplayType,
+			"err":          err,
+		}).Error("更新群配置异常")
+		return
+	}
+
+	// 可验证随机仅经典快三支持 切换到其他玩法时改为系统随机
+	if chatGroup.DiceSource == enums.DiceSourceProvablyFair.Value && gameplayType != enums.QuickThere.Value {
+		chatGroupUpdate := &model.ChatGroup{
+			Id:         chatGroupId,
+			DiceSource: enums.DiceSourceCrypto.Value,
+		}
+		err = tx.ChatGroups().UpdateColumnsById(chatGroupUpdate, "dice_source")
+		if err != nil {
+			tx.Rollback()
+			logrus.WithFields(logrus.Fields{
+				"chatGroupId": chatGroupId,
+				"DiceSource":  chatGroupUpdate.DiceSource,
+				"err":         err,
+			}).Error("更新群配置-骰子来源异常")
+			return
+		}
+	}
+
+	// 提交事务
+	if err := tx.Commit(); err != nil {
+		// 提交事务时出现异常，回滚事务
+		tx.Rollback()
 		return
 	}
 
@@ -1054,7 +2424,7 @@ func chatGroupConfigCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery
 		return
 	}
 
-	chatGroup, err := model.QueryChatGroupById(db, chatGroupId)
+	chatGroup, err := store.ChatGroups().QueryById(chatGroupId)
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
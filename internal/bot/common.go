@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/go-redis/redis/v8"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"strings"
 	"telegram-dice-bot/internal/common"
 	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/kv"
 	"telegram-dice-bot/internal/model"
 	"telegram-dice-bot/internal/utils"
 	"time"
@@ -43,7 +43,7 @@ func blockedOrKicked(err error, chatId int64) {
 				ChatGroupStatus: enums.GroupKicked.Value,
 				GameplayStatus:  0,
 			}
-			_, err := chatGroupUpdate.UpdateGameplayStatusAndChatGroupStatusByTgChatId(db)
+			err := store.ChatGroups().UpdateGameplayStatusAndChatGroupStatusByTgChatId(chatGroupUpdate)
 			if err != nil {
 				logrus.WithField("err", err).Error("群配置修改失败")
 				return
@@ -56,7 +56,7 @@ func blockedOrKicked(err error, chatId int64) {
 				ChatGroupStatus: enums.GroupDeleted.Value,
 				GameplayStatus:  0,
 			}
-			_, err := chatGroupUpdate.UpdateGameplayStatusAndChatGroupStatusByTgChatId(db)
+			err := store.ChatGroups().UpdateGameplayStatusAndChatGroupStatusByTgChatId(chatGroupUpdate)
 			if err != nil {
 				logrus.WithField("err", err).Error("群配置修改失败")
 				return
@@ -87,23 +87,12 @@ func buildDefaultInlineKeyboardMarkup(bot *tgbotapi.BotAPI) *tgbotapi.InlineKeyb
 	return &newInlineKeyboardMarkup
 }
 
-func buildGameplayConfigInlineKeyboardButton(chatGroup *model.ChatGroup, callbackDataQueryString string) ([]tgbotapi.InlineKeyboardButton, error) {
-
-	var inlineKeyboardButton []tgbotapi.InlineKeyboardButton
-	if chatGroup.GameplayType == enums.QuickThere.Value {
-		// 查询该配置
-		quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(db, chatGroup.Id)
-
-		if err != nil {
-			return nil, err
-		}
-		inlineKeyboardButton = tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️简易倍率: %v 倍", quickThereConfig.SimpleOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereSimpleOdds.Value, callbackDataQueryString)),
-			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️豹子倍率: %v 倍", quickThereConfig.TripletOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereTripletOdds.Value, callbackDataQueryString)),
-		)
+func buildGameplayConfigInlineKeyboardRows(chatGroup *model.ChatGroup, callbackDataQueryString string) ([][]tgbotapi.InlineKeyboardButton, error) {
+	gameplay, b := getGameplay(chatGroup.GameplayType)
+	if !b {
+		return nil, nil
 	}
-
-	return inlineKeyboardButton, nil
+	return gameplay.ConfigInlineKeyboardRows(chatGroup, callbackDataQueryString)
 }
 
 func buildJoinedGroupMsg(query *tgbotapi.CallbackQuery) (*tgbotapi.EditMessageTextConfig, error) {
@@ -121,7 +110,7 @@ func buildJoinedGroupMsg(query *tgbotapi.CallbackQuery) (*tgbotapi.EditMessageTe
 		IsLeft:   0,
 	}
 
-	chatGroupUsers, err := chatGroupUserQuery.ListByTgUserIdAndIsLeft(db)
+	chatGroupUsers, err := store.ChatGroupUsers().ListByTgUserIdAndIsLeft(chatGroupUserQuery.TgUserId, chatGroupUserQuery.IsLeft)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"TgUserId": fromUser.ID,
@@ -140,7 +129,7 @@ func buildJoinedGroupMsg(query *tgbotapi.CallbackQuery) (*tgbotapi.EditMessageTe
 			chatGroupIds = append(chatGroupIds, user.ChatGroupId)
 		}
 
-		chatGroups, err := model.ListChatGroupByIds(db, chatGroupIds)
+		chatGroups, err := store.ChatGroups().ListByIds(chatGroupIds)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"chatIds": chatGroupIds,
@@ -204,7 +193,7 @@ func buildAdminGroupMsg(query *tgbotapi.CallbackQuery) (*tgbotapi.EditMessageTex
 	)
 
 	// 查询当前消息来源人关联的群聊
-	chatGroupAdmins, err := model.ListChatGroupAdminByAdminTgUserId(db, fromUser.ID)
+	chatGroupAdmins, err := store.ChatGroupAdmins().ListByAdminTgUserId(fromUser.ID)
 	if len(chatGroupAdmins) == 0 && err == nil {
 		sendMsg = tgbotapi.NewEditMessageText(chatId, messageId, "您暂无管理的群!")
 	} else if err != nil {
@@ -216,7 +205,7 @@ func buildAdminGroupMsg(query *tgbotapi.CallbackQuery) (*tgbotapi.EditMessageTex
 		sendMsg = tgbotapi.NewEditMessageText(chatId, messageId, fmt.Sprintf("您有%v个管理的群:", len(chatGroupAdmins)))
 		for _, chatGroupAdmin := range chatGroupAdmins {
 			// 查找该群的信息
-			ChatGroup, err := model.QueryChatGroupById(db, chatGroupAdmin.ChatGroupId)
+			ChatGroup, err := store.ChatGroups().QueryById(chatGroupAdmin.ChatGroupId)
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				logrus.WithFields(logrus.Fields{
 					"ChatGroupId": chatGroupAdmin.ChatGroupId,
@@ -267,7 +256,7 @@ func buildAdminGroupMsg(query *tgbotapi.CallbackQuery) (*tgbotapi.EditMessageTex
 }
 
 func checkGroupAdmin(chatGroupId string, tgUserId int64) error {
-	_, err := model.QueryChatGroupAdminByChatGroupIdAndTgUserId(db, chatGroupId, tgUserId)
+	_, err := store.ChatGroupAdmins().QueryByChatGroupIdAndTgUserId(chatGroupId, tgUserId)
 	if err != nil {
 		return err
 	}
@@ -276,7 +265,7 @@ func checkGroupAdmin(chatGroupId string, tgUserId int64) error {
 
 func buildGameplayTypeInlineKeyboardButton(chatGroupId string) ([][]tgbotapi.InlineKeyboardButton, error) {
 
-	ChatGroup, err := model.QueryChatGroupById(db, chatGroupId)
+	ChatGroup, err := store.ChatGroups().QueryById(chatGroupId)
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
@@ -293,7 +282,8 @@ func buildGameplayTypeInlineKeyboardButton(chatGroupId string) ([][]tgbotapi.Inl
 
 	var inlineKeyboardRows [][]tgbotapi.InlineKeyboardButton
 
-	for key, value := range enums.GameplayTypeMap {
+	for _, value := range enums.GameplayTypeList {
+		key := value.Value
 
 		callBackDataKey, err := ButtonCallBackDataAddRedis(map[string]string{
 			"chatGroupId":  chatGroupId,
@@ -364,7 +354,7 @@ func ButtonCallBackDataAddRedis(queryMap map[string]string) (string, error) {
 	redisKey := fmt.Sprintf(RedisButtonCallBackDataKey, id)
 
 	// 存入redis
-	err = redisDB.Set(redisDB.Context(), redisKey, string(jsonBytes), 1*time.Hour).Err()
+	err = kvStore.Set(redisKey, string(jsonBytes), 1*time.Hour)
 
 	return id, nil
 }
@@ -372,21 +362,20 @@ func ButtonCallBackDataAddRedis(queryMap map[string]string) (string, error) {
 func ButtonCallBackDataQueryFromRedis(key string) (map[string]string, error) {
 
 	redisKey := fmt.Sprintf(RedisButtonCallBackDataKey, key)
-	result := redisDB.Get(redisDB.Context(), redisKey)
-	if errors.Is(result.Err(), redis.Nil) || result == nil {
+	mapString, err := kvStore.Get(redisKey)
+	if errors.Is(err, kv.ErrNil) {
 		logrus.WithFields(logrus.Fields{
 			"redisKey": redisKey,
-		}).Error("redis键不存在")
-		return nil, result.Err()
-	} else if result.Err() != nil {
+		}).Error("键盘回调信息不存在")
+		return nil, err
+	} else if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"redisKey": redisKey,
-			"err":      result.Err(),
-		}).Error("redis查询键盘回调信息异常")
-		return nil, result.Err()
+			"err":      err,
+		}).Error("查询键盘回调信息异常")
+		return nil, err
 	} else {
 		var m map[string]string
-		mapString, _ := result.Result()
 		err := json.Unmarshal([]byte(mapString), &m)
 		if err != nil {
 			return nil, err
@@ -405,7 +394,7 @@ func PrivateChatCacheAddRedis(tgUserID int64, botPrivateChatCache *common.BotPri
 	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserID)
 
 	// 存入redis
-	return redisDB.Set(redisDB.Context(), redisKey, string(jsonBytes), 24*time.Hour).Err()
+	return kvStore.Set(redisKey, string(jsonBytes), 24*time.Hour)
 
 }
 
@@ -425,6 +414,10 @@ func buildChatGroupInlineKeyboardMarkup(query *tgbotapi.CallbackQuery, chatGroup
 		}).Error("群配置游戏状态映射查询异常")
 		return nil, errors.New("群配置游戏状态查询异常")
 	}
+	diceSource, b := enums.GetDiceSource(chatGroup.DiceSource)
+	if !b {
+		diceSource = enums.DiceSourceTelegram
+	}
 
 	// 重新生成内联键盘回调key
 	callbackDataKey, err := ButtonCallBackDataAddRedis(map[string]string{
@@ -443,7 +436,7 @@ func buildChatGroupInlineKeyboardMarkup(query *tgbotapi.CallbackQuery, chatGroup
 		"callbackKey": callbackDataKey,
 	})
 
-	inlineKeyboardButtons, err := buildGameplayConfigInlineKeyboardButton(chatGroup, callbackDataQueryString)
+	gameplayConfigInlineKeyboardRows, err := buildGameplayConfigInlineKeyboardRows(chatGroup, callbackDataQueryString)
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
@@ -458,7 +451,8 @@ func buildChatGroupInlineKeyboardMarkup(query *tgbotapi.CallbackQuery, chatGroup
 		return nil, err
 	}
 
-	newInlineKeyboardMarkup := tgbotapi.NewInlineKeyboardMarkup(
+	var inlineKeyboardRows [][]tgbotapi.InlineKeyboardButton
+	inlineKeyboardRows = append(inlineKeyboardRows,
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🛠️当前玩法:【%s】", gameplayType.Name), fmt.Sprintf("%s%s", enums.CallbackGameplayType.Value, callbackDataQueryString)),
 		),
@@ -466,7 +460,17 @@ func buildChatGroupInlineKeyboardMarkup(query *tgbotapi.CallbackQuery, chatGroup
 			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🕹️开启状态: %s", gameplayStatus.Name), fmt.Sprintf("%s%s", enums.CallbackUpdateGameplayStatus.Value, callbackDataQueryString)),
 			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⏲️开奖周期: %v 分钟", chatGroup.GameDrawCycle), fmt.Sprintf("%s%s", enums.CallbackUpdateGameDrawCycle.Value, callbackDataQueryString)),
 		),
-		inlineKeyboardButtons,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚔️对决抽成: %v%%", chatGroup.DuelFeeRate), fmt.Sprintf("%s%s", enums.CallbackUpdateDuelFeeRate.Value, callbackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🎲骰子来源: %s", diceSource.Name), fmt.Sprintf("%s%s", enums.CallbackUpdateDiceSource.Value, callbackDataQueryString)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🔒开奖前封盘: %v 秒", chatGroup.BetCloseSeconds), fmt.Sprintf("%s%s", enums.CallbackUpdateBetCloseSeconds.Value, callbackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData("📅开奖计划", fmt.Sprintf("%s%s", enums.CallbackUpdateDrawSchedule.Value, callbackDataQueryString)),
+		),
+	)
+	inlineKeyboardRows = append(inlineKeyboardRows, gameplayConfigInlineKeyboardRows...)
+	inlineKeyboardRows = append(inlineKeyboardRows,
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("🔍查询用户信息", fmt.Sprintf("%s%s", enums.CallbackQueryChatGroupUser.Value, callbackDataQueryString)),
 			tgbotapi.NewInlineKeyboardButtonData("🖊️修改用户积分", fmt.Sprintf("%s%s", enums.CallbackUpdateChatGroupUserBalance.Value, callbackDataQueryString)),
@@ -476,5 +480,9 @@ func buildChatGroupInlineKeyboardMarkup(query *tgbotapi.CallbackQuery, chatGroup
 			tgbotapi.NewInlineKeyboardButtonData("🚮我已退群", fmt.Sprintf("%s%s", enums.CallbackAdminExitGroup.Value, callbackDataQueryString)),
 		),
 	)
+
+	newInlineKeyboardMarkup := tgbotapi.NewInlineKeyboardMarkup(
+		inlineKeyboardRows...,
+	)
 	return &newInlineKeyboardMarkup, nil
 }
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"math/big"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+	"time"
+)
+
+// DiceSource 开奖骰子来源
+type DiceSource interface {
+	// Roll 投掷 numDice 次指定表情(🎲🎯🏀⚽🎳🎰)的骰子 点数范围与 Telegram 骰子一致
+	Roll(emoji string, numDice int) ([]int, error)
+}
+
+// newGroupDiceSource 按群配置获取骰子来源
+// 可验证随机需要保存种子 由支持的玩法(经典快三)自行使用 newSeededDiceSource 其余玩法按系统随机开奖
+func newGroupDiceSource(bot *tgbotapi.BotAPI, group *model.ChatGroup) DiceSource {
+	switch group.DiceSource {
+	case enums.DiceSourceCrypto.Value, enums.DiceSourceProvablyFair.Value:
+		return &cryptoDiceSource{}
+	default:
+		return &telegramDiceSource{bot: bot, chatID: group.TgChatGroupId}
+	}
+}
+
+// diceFaces 骰子表情的点数上限
+func diceFaces(emoji string) int {
+	switch emoji {
+	case "⚽", "🏀":
+		return 5
+	case "🎰":
+		return 64
+	default:
+		return 6
+	}
+}
+
+// telegramDiceSource 在群内发送 Telegram 骰子 点数由 Telegram 决定
+type telegramDiceSource struct {
+	bot    *tgbotapi.BotAPI
+	chatID int64
+}
+
+func (s *telegramDiceSource) Roll(emoji string, numDice int) ([]int, error) {
+	diceValues, err := rollDiceWithEmoji(s.bot, s.chatID, emoji, numDice)
+	if err != nil {
+		return nil, err
+	}
+	// 等待骰子动画结束
+	time.Sleep(3 * time.Second)
+	return diceValues, nil
+}
+
+// rollDiceWithEmoji 使用指定的Telegram骰子表情(🎲🎯🏀⚽🎳🎰)多次投掷。
+func rollDiceWithEmoji(bot *tgbotapi.BotAPI, chatID int64, emoji string, numDice int) ([]int, error) {
+	diceValues := make([]int, numDice)
+	diceConfig := tgbotapi.NewDiceWithEmoji(chatID, emoji)
+
+	for i := 0; i < numDice; i++ {
+		diceMsg, err := bot.Send(diceConfig)
+		if err != nil {
+			logrus.WithField("err", err).Error("发送骰子消息异常")
+			return nil, err
+		}
+		diceValues[i] = diceMsg.Dice.Value
+	}
+
+	return diceValues, nil
+}
+
+// cryptoDiceSource 使用 crypto/rand 生成点数 不发送骰子消息
+type cryptoDiceSource struct{}
+
+func (s *cryptoDiceSource) Roll(emoji string, numDice int) ([]int, error) {
+	faces := big.NewInt(int64(diceFaces(emoji)))
+	diceValues := make([]int, numDice)
+	for i := range diceValues {
+		n, err := rand.Int(rand.Reader, faces)
+		if err != nil {
+			return nil, err
+		}
+		diceValues[i] = int(n.Int64()) + 1
+	}
+	return diceValues, nil
+}
+
+// seededDiceSource 由种子确定性推导点数 相同种子和 nonce 得到相同的点数序列 用于可验证随机和离线模拟
+// 依次取 HMAC-SHA256(种子, nonce) 的字节 b, b 小于点数上限的最大整数倍时点数为 b%点数上限+1 否则丢弃;
+// 字节不够时以 HMAC-SHA256(种子, 上一轮结果) 继续
+type seededDiceSource struct {
+	seed    []byte
+	message []byte
+	digest  []byte
+	pos     int
+}
+
+func newSeededDiceSource(seed string, nonce string) *seededDiceSource {
+	return &seededDiceSource{
+		seed:    []byte(seed),
+		message: []byte(nonce),
+	}
+}
+
+func (s *seededDiceSource) Roll(emoji string, numDice int) ([]int, error) {
+	faces := diceFaces(emoji)
+	limit := 256 - 256%faces
+	diceValues := make([]int, 0, numDice)
+	for len(diceValues) < numDice {
+		if s.pos >= len(s.digest) {
+			mac := hmac.New(sha256.New, s.seed)
+			mac.Write(s.message)
+			s.digest = mac.Sum(nil)
+			s.message = s.digest
+			s.pos = 0
+		}
+		b := int(s.digest[s.pos])
+		s.pos++
+		if b < limit {
+			diceValues = append(diceValues, b%faces+1)
+		}
+	}
+	return diceValues, nil
+}
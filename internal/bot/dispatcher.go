@@ -0,0 +1,157 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// UpdateWorkers 处理更新的worker数量 同一对话的更新始终由同一个worker按顺序处理
+	UpdateWorkers = "UPDATE_WORKERS"
+	// UpdateQueueSize 每个worker的待处理队列长度 队列满时暂停接收更新
+	UpdateQueueSize = "UPDATE_QUEUE_SIZE"
+
+	defaultUpdateWorkers   = 16
+	defaultUpdateQueueSize = 100
+	// 有积压时输出队列深度的间隔
+	queueDepthLogInterval = time.Minute
+)
+
+// dispatcher 按对话ID分片的更新分发器 限制同时处理更新的协程数
+type dispatcher struct {
+	bot    *tgbotapi.BotAPI
+	queues []chan tgbotapi.Update
+	wg     sync.WaitGroup
+}
+
+// newDispatcher 按 UPDATE_WORKERS UPDATE_QUEUE_SIZE 创建分发器并启动worker
+func newDispatcher(bot *tgbotapi.BotAPI) *dispatcher {
+	workers := envPositiveInt(UpdateWorkers, defaultUpdateWorkers)
+	queueSize := envPositiveInt(UpdateQueueSize, defaultUpdateQueueSize)
+
+	d := &dispatcher{
+		bot:    bot,
+		queues: make([]chan tgbotapi.Update, workers),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, queueSize)
+		d.wg.Add(1)
+		go d.worker(d.queues[i])
+	}
+	go d.logQueueDepth()
+
+	logrus.WithFields(logrus.Fields{
+		"workers":   workers,
+		"queueSize": queueSize,
+	}).Info("更新分发器已启动")
+	return d
+}
+
+func envPositiveInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		logrus.Fatalf("%s 配置错误: %s", key, value)
+	}
+	return n
+}
+
+// Dispatch 将更新放入对应对话的队列 队列满时阻塞 由长轮询或 webhook 向上游施加背压
+func (d *dispatcher) Dispatch(update tgbotapi.Update) {
+	chatID, ok := updateChatID(update)
+	if !ok {
+		return
+	}
+
+	queue := d.queues[shardIndex(chatID, len(d.queues))]
+	select {
+	case queue <- update:
+	default:
+		logrus.WithFields(logrus.Fields{
+			"chatId":     chatID,
+			"queueDepth": d.QueueDepth(),
+		}).Warn("更新队列已满 等待处理")
+		queue <- update
+	}
+}
+
+// QueueDepth 所有队列中待处理的更新数
+func (d *dispatcher) QueueDepth() int {
+	depth := 0
+	for _, queue := range d.queues {
+		depth += len(queue)
+	}
+	return depth
+}
+
+// Close 停止接收更新 等待已入队的更新处理完成
+func (d *dispatcher) Close() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
+
+func (d *dispatcher) worker(queue <-chan tgbotapi.Update) {
+	defer d.wg.Done()
+	for update := range queue {
+		d.handle(update)
+	}
+}
+
+// handle 处理单个更新 handler 异常时不影响该worker后续的更新
+func (d *dispatcher) handle(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.WithFields(logrus.Fields{
+				"updateId": update.UpdateID,
+				"panic":    r,
+			}).Error("处理更新异常")
+		}
+	}()
+
+	if update.Message != nil {
+		handleMessage(d.bot, update.Message)
+	} else if update.CallbackQuery != nil {
+		handleCallbackQuery(d.bot, update.CallbackQuery)
+	}
+}
+
+func (d *dispatcher) logQueueDepth() {
+	ticker := time.NewTicker(queueDepthLogInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if depth := d.QueueDepth(); depth > 0 {
+			logrus.WithField("queueDepth", depth).Info("更新队列积压")
+		}
+	}
+}
+
+// updateChatID 更新所属的对话ID 不处理的更新类型返回 false
+func updateChatID(update tgbotapi.Update) (int64, bool) {
+	if update.Message != nil {
+		return update.Message.Chat.ID, true
+	}
+	if update.CallbackQuery != nil {
+		if update.CallbackQuery.Message != nil {
+			return update.CallbackQuery.Message.Chat.ID, true
+		}
+		return update.CallbackQuery.From.ID, true
+	}
+	return 0, false
+}
+
+func shardIndex(chatID int64, shards int) int {
+	index := chatID % int64(shards)
+	if index < 0 {
+		index = -index
+	}
+	return int(index)
+}
@@ -0,0 +1,883 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+	"telegram-dice-bot/internal/utils"
+	"time"
+)
+
+const (
+	// DuelExpireDuration 对决邀请未应战的过期时间
+	DuelExpireDuration = 5 * time.Minute
+	// duelRollTimeout 应战后超过该时长仍未结束的对决视为掷骰或结算时中断
+	duelRollTimeout = 2 * time.Minute
+)
+
+var (
+	// duelTimers 待触发的对决过期任务 退出时停止
+	duelTimers        = make(map[string]*time.Timer)
+	duelTimersStopped bool
+	duelTimersMutex   sync.Mutex
+	// duelTimerWG 执行中的对决过期退还
+	duelTimerWG sync.WaitGroup
+)
+
+func handleDuelCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	fromUser := message.From
+	messageId := message.MessageID
+	tgChatId := message.Chat.ID
+
+	// 解析对决命令，示例命令格式：/duel @user 100
+	args := strings.Fields(message.CommandArguments())
+	if len(args) != 2 {
+		sendMsg := tgbotapi.NewMessage(tgChatId, "命令格式错误,示例: /duel @用户名 100")
+		sendMsg.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, tgChatId)
+		return
+	}
+
+	betAmount, err := decimal.Parse(args[1])
+	if errors.Is(err, decimal.ErrTooManyDecimalPlaces) {
+		sendMsg := tgbotapi.NewMessage(tgChatId, "对决积分最多支持两位小数!")
+		sendMsg.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, tgChatId)
+		return
+	} else if err != nil || betAmount <= 0 {
+		sendMsg := tgbotapi.NewMessage(tgChatId, "对决积分需大于0!")
+		sendMsg.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, tgChatId)
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryByTgChatId(tgChatId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"tgChatId": tgChatId,
+			"err":      err,
+		}).Error("群配置查询异常")
+		return
+	}
+
+	// 查询应战方 优先使用无用户名时的text_mention
+	opponentQuery := &model.ChatGroupUser{
+		ChatGroupId: chatGroup.Id,
+	}
+	var opponent *model.ChatGroupUser
+	for _, entity := range message.Entities {
+		if entity.Type == "text_mention" && entity.User != nil {
+			opponentQuery.TgUserId = entity.User.ID
+			opponent, err = store.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(opponentQuery.TgUserId, opponentQuery.ChatGroupId)
+			break
+		}
+	}
+	if opponent == nil && err == nil {
+		opponentQuery.Username = strings.TrimPrefix(args[0], "@")
+		opponent, err = store.ChatGroupUsers().QueryByUsernameAndChatGroupId(opponentQuery.Username, opponentQuery.ChatGroupId)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sendMsg := tgbotapi.NewMessage(tgChatId, "对方还未注册,无法发起对决!")
+		sendMsg.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, tgChatId)
+		return
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": chatGroup.Id,
+			"opponent":    args[0],
+			"err":         err,
+		}).Error("查询应战方信息异常")
+		return
+	}
+
+	if opponent.TgUserId == fromUser.ID {
+		sendMsg := tgbotapi.NewMessage(tgChatId, "不能和自己对决!")
+		sendMsg.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, tgChatId)
+		return
+	}
+
+	duelRecord, ok := storeDuelRecord(bot, chatGroup, message, opponent, betAmount)
+	if !ok {
+		return
+	}
+
+	// 发送对决邀请
+	callBackDataKey, err := ButtonCallBackDataAddRedis(map[string]string{
+		"duelRecordId": duelRecord.Id,
+	})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": duelRecord.Id,
+			"err":          err,
+		}).Error("内联键盘回调参数存入redis异常")
+		return
+	}
+	callBackDataQueryString := utils.MapToQueryString(map[string]string{
+		"callbackKey": callBackDataKey,
+	})
+
+	sendMsg := tgbotapi.NewMessage(tgChatId, fmt.Sprintf("【@%s】向【@%s】发起对决,双方各押%v积分,点数大者赢走奖池(抽成%v%%)。\n%d分钟内未应战自动取消并退还积分。",
+		fromUser.UserName,
+		opponent.Username,
+		betAmount,
+		duelRecord.FeeRate,
+		int(DuelExpireDuration.Minutes())))
+	sendMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚔️应战", fmt.Sprintf("%s%s", enums.CallbackAcceptDuel.Value, callBackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData("🏳️拒绝", fmt.Sprintf("%s%s", enums.CallbackDeclineDuel.Value, callBackDataQueryString)),
+		),
+	)
+	sentMsg, err := sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, tgChatId)
+		return
+	}
+
+	duelRecord.TgMessageId = sentMsg.MessageID
+	err = duelRecord.UpdateTgMessageIdById(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": duelRecord.Id,
+			"err":          err,
+		}).Error("更新对决邀请消息ID异常")
+	}
+
+	scheduleDuelExpire(bot, duelRecord)
+}
+
+// storeDuelRecord 扣除发起方积分并保存对决记录
+func storeDuelRecord(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup, message *tgbotapi.Message, opponent *model.ChatGroupUser, betAmount decimal.Decimal) (*model.DuelRecord, bool) {
+	user := message.From
+	messageId := message.MessageID
+	chatId := message.Chat.ID
+
+	// 获取用户对应的互斥锁
+	userLockKey := fmt.Sprintf(ChatGroupUserLockKey, chatId, user.ID)
+	userLock := getUserLock(userLockKey)
+	userLock.Lock()
+	defer userLock.Unlock()
+
+	// 查询该群用户信息
+	chatGroupUserQuery := &model.ChatGroupUser{
+		TgUserId:    user.ID,
+		ChatGroupId: chatGroup.Id,
+	}
+
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	challenger, err := tx.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		tx.Rollback()
+		sendMsg := tgbotapi.NewMessage(chatId, "您还未注册，使用 /register 进行注册。")
+		sendMsg.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return nil, false
+	} else if err != nil {
+		tx.Rollback()
+		logrus.WithFields(logrus.Fields{
+			"TgUserId":    user.ID,
+			"ChatGroupId": chatGroup.Id,
+			"err":         err,
+		}).Error("查询用户信息异常")
+		return nil, false
+	}
+
+	if challenger.Balance < betAmount {
+		tx.Rollback()
+		sendMsg := tgbotapi.NewMessage(chatId, "您的余额不足!")
+		sendMsg.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return nil, false
+	}
+
+	// 按增量扣除发起方积分 余额已被其他进程扣减到不足时不扣除
+	deducted, err := challenger.DeductBalanceById(tx.DB(), betAmount)
+	if err != nil {
+		logrus.WithField("err", err).Error("扣除用户余额异常")
+		tx.Rollback()
+		return nil, false
+	}
+	if !deducted {
+		tx.Rollback()
+		sendMsg := tgbotapi.NewMessage(chatId, "您的余额不足!")
+		sendMsg.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return nil, false
+	}
+	challenger.Username = user.UserName
+	err = challenger.UpdateUsernameById(tx.DB())
+	if err == nil {
+		challenger, err = tx.ChatGroupUsers().QueryById(challenger.Id)
+	}
+	if err != nil {
+		logrus.WithField("err", err).Error("更新用户信息异常")
+		tx.Rollback()
+		return nil, false
+	}
+
+	now := time.Now()
+	currentTime := now.Format("2006-01-02 15:04:05")
+	duelRecord := &model.DuelRecord{
+		ChatGroupId:  chatGroup.Id,
+		ChallengerId: challenger.Id,
+		OpponentId:   opponent.Id,
+		BetAmount:    betAmount,
+		FeeRate:      chatGroup.DuelFeeRate,
+		Status:       enums.DuelPending.Value,
+		ExpireTime:   now.Add(DuelExpireDuration).Format("2006-01-02 15:04:05"),
+		UpdateTime:   currentTime,
+		CreateTime:   currentTime,
+	}
+	err = duelRecord.Create(tx.DB())
+	if err != nil {
+		logrus.WithField("err", err).Error("保存对决记录异常")
+		tx.Rollback()
+		return nil, false
+	}
+	err = recordBalanceChange(tx.DB(), challenger, -betAmount, enums.LedgerDuelStake, duelRecord.Id)
+	if err != nil {
+		logrus.WithField("err", err).Error("记录积分流水异常")
+		tx.Rollback()
+		return nil, false
+	}
+
+	// 提交事务
+	if err := tx.Commit(); err != nil {
+		logrus.WithField("err", err).Error("保存对决记录提交事务异常")
+		tx.Rollback()
+		return nil, false
+	}
+	return duelRecord, true
+}
+
+func acceptDuelCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	tgChatId := query.Message.Chat.ID
+	fromUser := query.From
+
+	duelRecord, ok := queryDuelRecordFromCallback(query, enums.CallbackAcceptDuel)
+	if !ok {
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(duelRecord.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": duelRecord.ChatGroupId,
+			"err":         err,
+		}).Error("群配置查询异常")
+		return
+	}
+
+	// 只有被邀请方可以应战
+	opponentQuery := &model.ChatGroupUser{Id: duelRecord.OpponentId}
+	opponent, err := store.ChatGroupUsers().QueryById(opponentQuery.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"OpponentId": duelRecord.OpponentId,
+			"err":        err,
+		}).Error("查询应战方信息异常")
+		return
+	}
+	if opponent.TgUserId != fromUser.ID {
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": duelRecord.Id,
+			"fromUserId":   fromUser.ID,
+		}).Warn("非被邀请方点击应战")
+		return
+	}
+
+	// 已过期的邀请直接退还
+	expireTime, err := time.ParseInLocation("2006-01-02 15:04:05", duelRecord.ExpireTime, time.Local)
+	if err == nil && time.Now().After(expireTime) {
+		refundDuel(bot, duelRecord.Id, enums.DuelExpired)
+		return
+	}
+
+	if !escrowOpponentStake(bot, chatGroup, duelRecord, opponent) {
+		return
+	}
+
+	// 移除邀请消息的按钮
+	editMsg := tgbotapi.NewEditMessageText(tgChatId, query.Message.MessageID,
+		fmt.Sprintf("%s\n\n【@%s】已应战!", query.Message.Text, opponent.Username))
+	_, err = sendMessage(bot, &editMsg)
+	blockedOrKicked(err, tgChatId)
+
+	rollDuel(bot, chatGroup, duelRecord)
+}
+
+// escrowOpponentStake 扣除应战方积分并将对决状态改为对决中
+func escrowOpponentStake(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup, duelRecord *model.DuelRecord, opponent *model.ChatGroupUser) bool {
+	// 获取用户对应的互斥锁
+	userLockKey := fmt.Sprintf(ChatGroupUserLockKey, chatGroup.TgChatGroupId, opponent.TgUserId)
+	userLock := getUserLock(userLockKey)
+	userLock.Lock()
+	defer userLock.Unlock()
+
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	opponent, err := tx.ChatGroupUsers().QueryById(opponent.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"OpponentId": duelRecord.OpponentId,
+			"err":        err,
+		}).Error("查询应战方信息异常")
+		tx.Rollback()
+		return false
+	}
+
+	if opponent.Balance < duelRecord.BetAmount {
+		tx.Rollback()
+		sendMsg := tgbotapi.NewMessage(chatGroup.TgChatGroupId, fmt.Sprintf("【@%s】余额不足,无法应战!", opponent.Username))
+		sendMsg.ReplyToMessageID = duelRecord.TgMessageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatGroup.TgChatGroupId)
+		return false
+	}
+
+	duelRecordUpdate := &model.DuelRecord{
+		Id:         duelRecord.Id,
+		Status:     enums.DuelAccepted.Value,
+		UpdateTime: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	updated, err := duelRecordUpdate.UpdateStatusByIdAndStatus(tx.DB(), enums.DuelPending.Value)
+	if err != nil || !updated {
+		// 已被拒绝或已过期
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": duelRecord.Id,
+			"err":          err,
+		}).Warn("对决已不是待应战状态")
+		tx.Rollback()
+		return false
+	}
+
+	// 按增量扣除应战方积分 余额已被其他进程扣减到不足时不扣除
+	deducted, err := opponent.DeductBalanceById(tx.DB(), duelRecord.BetAmount)
+	if err != nil {
+		logrus.WithField("err", err).Error("扣除用户余额异常")
+		tx.Rollback()
+		return false
+	}
+	if !deducted {
+		tx.Rollback()
+		sendMsg := tgbotapi.NewMessage(chatGroup.TgChatGroupId, fmt.Sprintf("【@%s】余额不足,无法应战!", opponent.Username))
+		sendMsg.ReplyToMessageID = duelRecord.TgMessageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatGroup.TgChatGroupId)
+		return false
+	}
+	opponent, err = tx.ChatGroupUsers().QueryById(opponent.Id)
+	if err != nil {
+		logrus.WithField("err", err).Error("查询应战方信息异常")
+		tx.Rollback()
+		return false
+	}
+	err = recordBalanceChange(tx.DB(), opponent, -duelRecord.BetAmount, enums.LedgerDuelStake, duelRecord.Id)
+	if err != nil {
+		logrus.WithField("err", err).Error("记录积分流水异常")
+		tx.Rollback()
+		return false
+	}
+
+	// 提交事务
+	if err := tx.Commit(); err != nil {
+		logrus.WithField("err", err).Error("应战提交事务异常")
+		tx.Rollback()
+		return false
+	}
+	return true
+}
+
+// rollDuel 双方各掷一颗骰子 点数相同则重掷 胜者赢走奖池(扣除抽成)
+func rollDuel(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup, duelRecord *model.DuelRecord) {
+	diceSource := newGroupDiceSource(bot, chatGroup)
+	var challengerValue, opponentValue int
+	for challengerValue == opponentValue {
+		diceValues, err := diceSource.Roll("🎲", 2)
+		if err != nil {
+			blockedOrKicked(err, chatGroup.TgChatGroupId)
+			// 骰子发送失败 退还双方积分
+			refundAcceptedDuel(bot, chatGroup, duelRecord)
+			return
+		}
+		challengerValue, opponentValue = diceValues[0], diceValues[1]
+	}
+
+	winnerId := duelRecord.ChallengerId
+	if opponentValue > challengerValue {
+		winnerId = duelRecord.OpponentId
+	}
+
+	winnerQuery := &model.ChatGroupUser{Id: winnerId}
+	winner, err := store.ChatGroupUsers().QueryById(winnerQuery.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"winnerId": winnerId,
+			"err":      err,
+		}).Error("查询对决胜者信息异常")
+		refundAcceptedDuel(bot, chatGroup, duelRecord)
+		return
+	}
+
+	pot := duelRecord.BetAmount * 2
+	feeAmount := pot.Percent(duelRecord.FeeRate)
+	winAmount := pot - feeAmount
+
+	duelRecord.Status = enums.DuelFinished.Value
+	duelRecord.ChallengerValue = &challengerValue
+	duelRecord.OpponentValue = &opponentValue
+	duelRecord.WinnerId = winnerId
+	duelRecord.FeeAmount = feeAmount
+	duelRecord.UpdateTime = time.Now().Format("2006-01-02 15:04:05")
+
+	winner, finished, err := settleDuelWinner(chatGroup, duelRecord, winner, winAmount)
+	if err != nil {
+		// 结算未提交 对决仍为对决中 退还双方积分
+		refundAcceptedDuel(bot, chatGroup, duelRecord)
+		return
+	} else if !finished {
+		// 已被其他进程退还
+		return
+	}
+
+	challenger, opponent := queryDuelUsers(duelRecord)
+	sendMsg := tgbotapi.NewMessage(chatGroup.TgChatGroupId, fmt.Sprintf("对决结果:\n【@%s】🎲%d vs 【@%s】🎲%d\n【@%s】获胜,赢得%.2f积分(抽成%.2f)。",
+		challenger.Username, challengerValue,
+		opponent.Username, opponentValue,
+		winner.Username, winAmount, feeAmount))
+	sendMsg.ReplyToMessageID = duelRecord.TgMessageId
+	_, err = sendMessage(bot, &sendMsg)
+	blockedOrKicked(err, chatGroup.TgChatGroupId)
+}
+
+// settleDuelWinner 胜者赢走奖池并记录对决结果 对决已不是对决中时返回 false
+func settleDuelWinner(chatGroup *model.ChatGroup, duelRecord *model.DuelRecord, winner *model.ChatGroupUser, winAmount decimal.Decimal) (*model.ChatGroupUser, bool, error) {
+	// 获取用户对应的互斥锁
+	userLockKey := fmt.Sprintf(ChatGroupUserLockKey, chatGroup.TgChatGroupId, winner.TgUserId)
+	userLock := getUserLock(userLockKey)
+	userLock.Lock()
+	defer userLock.Unlock()
+
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	// 以对决中状态为条件更新 与异常退还及重启恢复互斥
+	finished, err := duelRecord.FinishByIdAndStatus(tx.DB(), enums.DuelAccepted.Value)
+	if err != nil {
+		logrus.WithField("err", err).Error("更新对决记录异常")
+		return nil, false, err
+	} else if !finished {
+		logrus.WithField("duelRecordId", duelRecord.Id).Warn("对决已不是对决中状态")
+		return nil, false, nil
+	}
+
+	err = winner.AddBalanceById(tx.DB(), winAmount)
+	if err != nil {
+		logrus.WithField("err", err).Error("更新用户余额异常")
+		return nil, false, err
+	}
+	winner, err = tx.ChatGroupUsers().QueryById(winner.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"winnerId": duelRecord.WinnerId,
+			"err":      err,
+		}).Error("查询对决胜者信息异常")
+		return nil, false, err
+	}
+	err = recordBalanceChange(tx.DB(), winner, winAmount, enums.LedgerDuelWin, duelRecord.Id)
+	if err != nil {
+		logrus.WithField("err", err).Error("记录积分流水异常")
+		return nil, false, err
+	}
+
+	// 提交事务
+	if err := tx.Commit(); err != nil {
+		logrus.WithField("err", err).Error("对决结算提交事务异常")
+		return nil, false, err
+	}
+	return winner, true, nil
+}
+
+// refundAcceptedDuel 对决中出现异常时退还双方积分
+func refundAcceptedDuel(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup, duelRecord *model.DuelRecord) {
+	tx := store.Begin()
+	defer tx.Rollback()
+	for _, chatGroupUserId := range []string{duelRecord.ChallengerId, duelRecord.OpponentId} {
+		result := tx.DB().Model(&model.ChatGroupUser{}).Where("id = ?", chatGroupUserId).Update("balance", gorm.Expr("balance + ?", duelRecord.BetAmount))
+		if result.Error != nil {
+			logrus.WithFields(logrus.Fields{
+				"duelRecordId":    duelRecord.Id,
+				"chatGroupUserId": chatGroupUserId,
+				"err":             result.Error,
+			}).Error("退还对决积分异常")
+			tx.Rollback()
+			return
+		}
+
+		chatGroupUser := &model.ChatGroupUser{Id: chatGroupUserId}
+		chatGroupUser, err := tx.ChatGroupUsers().QueryById(chatGroupUser.Id)
+		if err == nil {
+			err = recordBalanceChange(tx.DB(), chatGroupUser, duelRecord.BetAmount, enums.LedgerDuelRefund, duelRecord.Id)
+		}
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"duelRecordId":    duelRecord.Id,
+				"chatGroupUserId": chatGroupUserId,
+				"err":             err,
+			}).Error("记录积分流水异常")
+			tx.Rollback()
+			return
+		}
+	}
+	duelRecordUpdate := &model.DuelRecord{
+		Id:         duelRecord.Id,
+		Status:     enums.DuelCanceled.Value,
+		UpdateTime: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	updated, err := duelRecordUpdate.UpdateStatusByIdAndStatus(tx.DB(), enums.DuelAccepted.Value)
+	if err != nil || !updated {
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": duelRecord.Id,
+			"err":          err,
+		}).Error("更新对决状态异常")
+		tx.Rollback()
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		logrus.WithField("err", err).Error("退还对决积分提交事务异常")
+		tx.Rollback()
+		return
+	}
+	sendMsg := tgbotapi.NewMessage(chatGroup.TgChatGroupId, "对决异常取消,已退还双方积分。")
+	sendMsg.ReplyToMessageID = duelRecord.TgMessageId
+	_, err = sendMessage(bot, &sendMsg)
+	blockedOrKicked(err, chatGroup.TgChatGroupId)
+}
+
+func declineDuelCallBack(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	fromUser := query.From
+
+	duelRecord, ok := queryDuelRecordFromCallback(query, enums.CallbackDeclineDuel)
+	if !ok {
+		return
+	}
+
+	// 只有被邀请方可以拒绝
+	opponentQuery := &model.ChatGroupUser{Id: duelRecord.OpponentId}
+	opponent, err := store.ChatGroupUsers().QueryById(opponentQuery.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"OpponentId": duelRecord.OpponentId,
+			"err":        err,
+		}).Error("查询应战方信息异常")
+		return
+	}
+	if opponent.TgUserId != fromUser.ID {
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": duelRecord.Id,
+			"fromUserId":   fromUser.ID,
+		}).Warn("非被邀请方点击拒绝")
+		return
+	}
+
+	refundDuel(bot, duelRecord.Id, enums.DuelDeclined)
+}
+
+// queryDuelRecordFromCallback 通过内联键盘回调参数查询对决记录
+func queryDuelRecordFromCallback(query *tgbotapi.CallbackQuery, callbackPrefix enums.CallbackPrefix) (*model.DuelRecord, bool) {
+	queryString := query.Data[strings.Index(query.Data, callbackPrefix.Value)+len(callbackPrefix.Value):]
+
+	queryStringToMap, err := utils.QueryStringToMap(queryString)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"queryData": query.Data,
+			"err":       err,
+		}).Error("对决回调参数解析异常")
+		return nil, false
+	}
+
+	callBackData, err := ButtonCallBackDataQueryFromRedis(queryStringToMap["callbackKey"])
+	if err != nil {
+		logrus.Error("内联键盘回调参数redis查询异常")
+		return nil, false
+	}
+
+	duelRecordQuery := &model.DuelRecord{Id: callBackData["duelRecordId"]}
+	duelRecord, err := duelRecordQuery.QueryById(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": callBackData["duelRecordId"],
+			"err":          err,
+		}).Error("查询对决记录异常")
+		return nil, false
+	}
+	return duelRecord, true
+}
+
+// refundDuel 待应战的对决被拒绝或过期时 退还发起方积分
+func refundDuel(bot *tgbotapi.BotAPI, duelRecordId string, status enums.DuelStatus) {
+	duelRecordQuery := &model.DuelRecord{Id: duelRecordId}
+	duelRecord, err := duelRecordQuery.QueryById(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": duelRecordId,
+			"err":          err,
+		}).Error("查询对决记录异常")
+		return
+	}
+	if duelRecord.Status != enums.DuelPending.Value {
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(duelRecord.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": duelRecord.ChatGroupId,
+			"err":         err,
+		}).Error("群配置查询异常")
+		return
+	}
+
+	challengerQuery := &model.ChatGroupUser{Id: duelRecord.ChallengerId}
+	challenger, err := store.ChatGroupUsers().QueryById(challengerQuery.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChallengerId": duelRecord.ChallengerId,
+			"err":          err,
+		}).Error("查询发起方信息异常")
+		return
+	}
+
+	// 获取用户对应的互斥锁
+	userLockKey := fmt.Sprintf(ChatGroupUserLockKey, chatGroup.TgChatGroupId, challenger.TgUserId)
+	userLock := getUserLock(userLockKey)
+	userLock.Lock()
+	defer userLock.Unlock()
+
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	duelRecordUpdate := &model.DuelRecord{
+		Id:         duelRecord.Id,
+		Status:     status.Value,
+		UpdateTime: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	updated, err := duelRecordUpdate.UpdateStatusByIdAndStatus(tx.DB(), enums.DuelPending.Value)
+	if err != nil || !updated {
+		// 已被应战或已处理
+		tx.Rollback()
+		return
+	}
+
+	err = challenger.AddBalanceById(tx.DB(), duelRecord.BetAmount)
+	if err != nil {
+		logrus.WithField("err", err).Error("退还对决积分异常")
+		tx.Rollback()
+		return
+	}
+	challenger, err = tx.ChatGroupUsers().QueryById(challenger.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChallengerId": duelRecord.ChallengerId,
+			"err":          err,
+		}).Error("查询发起方信息异常")
+		tx.Rollback()
+		return
+	}
+	err = recordBalanceChange(tx.DB(), challenger, duelRecord.BetAmount, enums.LedgerDuelRefund, duelRecord.Id)
+	if err != nil {
+		logrus.WithField("err", err).Error("记录积分流水异常")
+		tx.Rollback()
+		return
+	}
+
+	// 提交事务
+	if err := tx.Commit(); err != nil {
+		logrus.WithField("err", err).Error("退还对决积分提交事务异常")
+		tx.Rollback()
+		return
+	}
+
+	// 移除邀请消息的按钮
+	if duelRecord.TgMessageId != 0 {
+		editMsg := tgbotapi.NewEditMessageText(chatGroup.TgChatGroupId, duelRecord.TgMessageId,
+			fmt.Sprintf("【@%s】发起的%v积分对决%s,已退还积分。", challenger.Username, duelRecord.BetAmount, status.Name))
+		_, err = sendMessage(bot, &editMsg)
+		blockedOrKicked(err, chatGroup.TgChatGroupId)
+	}
+}
+
+// scheduleDuelExpire 到期后自动取消未应战的对决
+func scheduleDuelExpire(bot *tgbotapi.BotAPI, duelRecord *model.DuelRecord) {
+	expireTime, err := time.ParseInLocation("2006-01-02 15:04:05", duelRecord.ExpireTime, time.Local)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": duelRecord.Id,
+			"ExpireTime":   duelRecord.ExpireTime,
+			"err":          err,
+		}).Error("对决过期时间解析异常")
+		return
+	}
+	duelRecordId := duelRecord.Id
+	scheduleDuelTimer(duelRecordId, time.Until(expireTime), func() {
+		refundDuel(bot, duelRecordId, enums.DuelExpired)
+	})
+}
+
+// scheduleAcceptedDuelRefund 对决中的对决超过掷骰时长仍未结束时退还双方积分 其他实例正在掷骰的对决不会被提前退还
+func scheduleAcceptedDuelRefund(bot *tgbotapi.BotAPI, duelRecord *model.DuelRecord) {
+	updateTime, err := time.ParseInLocation("2006-01-02 15:04:05", duelRecord.UpdateTime, time.Local)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"duelRecordId": duelRecord.Id,
+			"UpdateTime":   duelRecord.UpdateTime,
+			"err":          err,
+		}).Error("对决更新时间解析异常")
+		return
+	}
+	duelRecordId := duelRecord.Id
+	scheduleDuelTimer(duelRecordId, time.Until(updateTime.Add(duelRollTimeout)), func() {
+		duelRecordQuery := &model.DuelRecord{Id: duelRecordId}
+		duelRecord, err := duelRecordQuery.QueryById(store.DB())
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"duelRecordId": duelRecordId,
+				"err":          err,
+			}).Error("查询对决记录异常")
+			return
+		}
+		if duelRecord.Status != enums.DuelAccepted.Value {
+			return
+		}
+		chatGroup, err := store.ChatGroups().QueryById(duelRecord.ChatGroupId)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"duelRecordId": duelRecord.Id,
+				"ChatGroupId":  duelRecord.ChatGroupId,
+				"err":          err,
+			}).Error("查询群信息异常")
+			return
+		}
+		refundAcceptedDuel(bot, chatGroup, duelRecord)
+	})
+}
+
+// scheduleDuelTimer 延迟执行对决任务 退出时未触发的任务不再执行 已触发的任务执行完成后才退出
+func scheduleDuelTimer(duelRecordId string, delay time.Duration, task func()) {
+	duelTimersMutex.Lock()
+	defer duelTimersMutex.Unlock()
+	if duelTimersStopped {
+		return
+	}
+	duelTimers[duelRecordId] = time.AfterFunc(delay, func() {
+		duelTimersMutex.Lock()
+		if duelTimersStopped {
+			duelTimersMutex.Unlock()
+			return
+		}
+		delete(duelTimers, duelRecordId)
+		duelTimerWG.Add(1)
+		duelTimersMutex.Unlock()
+
+		defer duelTimerWG.Done()
+		task()
+	})
+}
+
+// stopDuelTimers 停止未触发的对决过期任务并等待执行中的退还完成 未触发的对决在下次启动时恢复
+func stopDuelTimers() {
+	duelTimersMutex.Lock()
+	duelTimersStopped = true
+	for duelRecordId, timer := range duelTimers {
+		timer.Stop()
+		delete(duelTimers, duelRecordId)
+	}
+	duelTimersMutex.Unlock()
+
+	duelTimerWG.Wait()
+}
+
+// initDuelTask 重启后恢复待应战对决的过期任务 并退还中断的对决
+func initDuelTask(bot *tgbotapi.BotAPI) {
+	duelRecordQuery := &model.DuelRecord{Status: enums.DuelPending.Value}
+	duelRecords, err := duelRecordQuery.ListByStatus(store.DB())
+	if err != nil {
+		logrus.WithField("err", err).Error("查询待应战对决异常")
+		return
+	}
+	for _, duelRecord := range duelRecords {
+		scheduleDuelExpire(bot, duelRecord)
+	}
+
+	// 对决中的对决超过掷骰时长仍未结束时视为中断 退还双方积分 未超时的可能正由其他实例掷骰 到时仍未结束再退还
+	duelRecordQuery = &model.DuelRecord{Status: enums.DuelAccepted.Value}
+	duelRecords, err = duelRecordQuery.ListByStatus(store.DB())
+	if err != nil {
+		logrus.WithField("err", err).Error("查询对决中的对决异常")
+		return
+	}
+	for _, duelRecord := range duelRecords {
+		scheduleAcceptedDuelRefund(bot, duelRecord)
+	}
+}
+
+// queryDuelUsers 查询对决双方信息
+func queryDuelUsers(duelRecord *model.DuelRecord) (*model.ChatGroupUser, *model.ChatGroupUser) {
+	challenger := &model.ChatGroupUser{Id: duelRecord.ChallengerId}
+	challenger, err := store.ChatGroupUsers().QueryById(challenger.Id)
+	if err != nil {
+		challenger = &model.ChatGroupUser{}
+	}
+	opponent := &model.ChatGroupUser{Id: duelRecord.OpponentId}
+	opponent, err = store.ChatGroupUsers().QueryById(opponent.Id)
+	if err != nil {
+		opponent = &model.ChatGroupUser{}
+	}
+	return challenger, opponent
+}
+
+// formatDuelHistory 对决历史中的一条记录
+func formatDuelHistory(duelRecord *model.DuelRecord, chatGroupUserId string) string {
+	challenger, opponent := queryDuelUsers(duelRecord)
+
+	rival := opponent
+	if duelRecord.OpponentId == chatGroupUserId {
+		rival = challenger
+	}
+
+	status, _ := enums.GetDuelStatus(duelRecord.Status)
+	result := status.Name
+	if duelRecord.Status == enums.DuelFinished.Value {
+		if duelRecord.WinnerId == chatGroupUserId {
+			result = fmt.Sprintf("赢 +%.2f", duelRecord.BetAmount*2-duelRecord.FeeAmount-duelRecord.BetAmount)
+		} else {
+			result = fmt.Sprintf("输 -%.2f", duelRecord.BetAmount)
+		}
+	}
+
+	return fmt.Sprintf("%s 对决 @%s %v %s\n",
+		duelRecord.CreateTime,
+		rival.Username,
+		duelRecord.BetAmount,
+		result,
+	)
+}
@@ -0,0 +1,450 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strings"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+	"time"
+)
+
+// emojiGameRule 表情骰子玩法规则
+type emojiGameRule struct {
+	emoji       string                     // Telegram骰子表情
+	betTypes    []enums.GameLotteryType    // 支持的下注类型
+	defaultOdds map[string]decimal.Decimal // 默认倍率 key为下注类型Value
+	winBetTypes func(value int) []string   // 根据骰子值计算中奖的下注类型
+	formatValue func(value int) string     // 骰子值的结果描述 为空时展示中奖的下注类型
+}
+
+// emojiGameplay 基于Telegram表情骰子(⚽🏀🎯🎳🎰)的玩法
+type emojiGameplay struct {
+	gameplayType enums.GameplayType
+	rule         emojiGameRule
+}
+
+// 老虎机转轮图案 Telegram按 value-1 的4进制由低到高依次为左/中/右转轮
+var slotMachineSymbols = []string{"BAR", "🍇", "🍋", "7️⃣"}
+
+func init() {
+	// ⚽ 1-2未进 3-5进球
+	registerGameplay(enums.Football, &emojiGameplay{
+		gameplayType: enums.Football,
+		rule: emojiGameRule{
+			emoji:       "⚽",
+			betTypes:    []enums.GameLotteryType{enums.Goal, enums.Miss},
+			defaultOdds: map[string]decimal.Decimal{enums.Goal.Value: decimal.MustParse("1.5"), enums.Miss.Value: decimal.MustParse("2.2")},
+			winBetTypes: func(value int) []string {
+				if value >= 3 {
+					return []string{enums.Goal.Value}
+				}
+				return []string{enums.Miss.Value}
+			},
+		},
+	})
+	// 🏀 1-3未进 4-5进球
+	registerGameplay(enums.Basketball, &emojiGameplay{
+		gameplayType: enums.Basketball,
+		rule: emojiGameRule{
+			emoji:       "🏀",
+			betTypes:    []enums.GameLotteryType{enums.Goal, enums.Miss},
+			defaultOdds: map[string]decimal.Decimal{enums.Goal.Value: decimal.MustParse("2.2"), enums.Miss.Value: decimal.MustParse("1.5")},
+			winBetTypes: func(value int) []string {
+				if value >= 4 {
+					return []string{enums.Goal.Value}
+				}
+				return []string{enums.Miss.Value}
+			},
+		},
+	})
+	// 🎯 1脱靶 2-5中环 6靶心
+	registerGameplay(enums.Darts, &emojiGameplay{
+		gameplayType: enums.Darts,
+		rule: emojiGameRule{
+			emoji:       "🎯",
+			betTypes:    []enums.GameLotteryType{enums.Bullseye, enums.OnTarget, enums.OffTarget},
+			defaultOdds: map[string]decimal.Decimal{enums.Bullseye.Value: decimal.MustParse("5"), enums.OnTarget.Value: decimal.MustParse("1.4"), enums.OffTarget.Value: decimal.MustParse("5")},
+			winBetTypes: func(value int) []string {
+				switch value {
+				case 6:
+					return []string{enums.Bullseye.Value}
+				case 1:
+					return []string{enums.OffTarget.Value}
+				default:
+					return []string{enums.OnTarget.Value}
+				}
+			},
+		},
+	})
+	// 🎳 1洗沟 6全中
+	registerGameplay(enums.Bowling, &emojiGameplay{
+		gameplayType: enums.Bowling,
+		rule: emojiGameRule{
+			emoji:       "🎳",
+			betTypes:    []enums.GameLotteryType{enums.Strike, enums.Gutter},
+			defaultOdds: map[string]decimal.Decimal{enums.Strike.Value: decimal.MustParse("5"), enums.Gutter.Value: decimal.MustParse("5")},
+			winBetTypes: func(value int) []string {
+				switch value {
+				case 6:
+					return []string{enums.Strike.Value}
+				case 1:
+					return []string{enums.Gutter.Value}
+				default:
+					return nil
+				}
+			},
+			formatValue: func(value int) string {
+				// 骰子值对应击倒的瓶数
+				pins := map[int]int{1: 0, 2: 1, 3: 3, 4: 4, 5: 5, 6: 10}
+				switch value {
+				case 6:
+					return fmt.Sprintf("击倒%d瓶 %s", pins[value], enums.Strike.Name)
+				case 1:
+					return fmt.Sprintf("击倒%d瓶 %s", pins[value], enums.Gutter.Name)
+				default:
+					return fmt.Sprintf("击倒%d瓶", pins[value])
+				}
+			},
+		},
+	})
+	// 🎰 1-64 解码为三个转轮
+	registerGameplay(enums.SlotMachine, &emojiGameplay{
+		gameplayType: enums.SlotMachine,
+		rule: emojiGameRule{
+			emoji:       "🎰",
+			betTypes:    []enums.GameLotteryType{enums.Slot777, enums.SlotTriple, enums.SlotDouble},
+			defaultOdds: map[string]decimal.Decimal{enums.Slot777.Value: decimal.MustParse("50"), enums.SlotTriple.Value: decimal.MustParse("14"), enums.SlotDouble.Value: decimal.MustParse("1.6")},
+			winBetTypes: func(value int) []string {
+				reels := decodeSlotMachineReels(value)
+				if reels[0] == reels[1] && reels[1] == reels[2] {
+					if reels[0] == len(slotMachineSymbols)-1 {
+						return []string{enums.Slot777.Value, enums.SlotTriple.Value}
+					}
+					return []string{enums.SlotTriple.Value}
+				}
+				if reels[0] == reels[1] || reels[1] == reels[2] || reels[0] == reels[2] {
+					return []string{enums.SlotDouble.Value}
+				}
+				return nil
+			},
+			formatValue: func(value int) string {
+				reels := decodeSlotMachineReels(value)
+				return fmt.Sprintf("%s %s %s", slotMachineSymbols[reels[0]], slotMachineSymbols[reels[1]], slotMachineSymbols[reels[2]])
+			},
+		},
+	})
+}
+
+// decodeSlotMachineReels 将🎰的骰子值(1-64)解码为左/中/右三个转轮的图案下标
+func decodeSlotMachineReels(value int) [3]int {
+	value--
+	return [3]int{value % 4, value / 4 % 4, value / 16 % 4}
+}
+
+// formatValue 骰子值的结果描述
+func (g *emojiGameplay) formatValue(value int) string {
+	if g.rule.formatValue != nil {
+		return g.rule.formatValue(value)
+	}
+	var names []string
+	for _, winBetType := range g.rule.winBetTypes(value) {
+		betType, _ := enums.GetGameLotteryType(winBetType)
+		names = append(names, betType.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func (g *emojiGameplay) InitConfig(tx *gorm.DB, chatGroupId string) error {
+	_, err := model.QueryEmojiGameConfigByChatGroupIdAndGameplayType(tx, chatGroupId, g.gameplayType.Value)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	odds, err := json.Marshal(g.rule.defaultOdds)
+	if err != nil {
+		return err
+	}
+	emojiGameConfig := &model.EmojiGameConfig{
+		ChatGroupId:  chatGroupId,
+		GameplayType: g.gameplayType.Value,
+		Odds:         string(odds),
+		CreateTime:   time.Now().Format("2006-01-02 15:04:05"),
+	}
+	return emojiGameConfig.Create(tx)
+}
+
+func (g *emojiGameplay) ParseBet(group *model.ChatGroup, text string) (*Bet, error) {
+	// 解析下注命令，示例命令格式：#进球 20
+	parts := strings.Fields(text)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "#") {
+		return nil, nil
+	}
+
+	// 获取下注类型和下注积分
+	betType, b := g.betTypeForName(parts[0][1:])
+	if !b {
+		return nil, nil
+	}
+
+	betAmount, err := parseBetAmount(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bet{
+		BetType:   betType.Value,
+		BetAmount: betAmount,
+	}, nil
+}
+
+// betTypeForName 通过下注类型名称获取该玩法支持的下注类型
+func (g *emojiGameplay) betTypeForName(betTypeName string) (enums.GameLotteryType, bool) {
+	for _, betType := range g.rule.betTypes {
+		if betType.Name == betTypeName {
+			return betType, true
+		}
+	}
+	return enums.GameLotteryType{}, false
+}
+
+func (g *emojiGameplay) StoreBet(tx *gorm.DB, betRecord *model.BetRecord, bet *Bet) error {
+	// 保存表情骰子玩法下注记录
+	emojiGameBetRecord := &model.EmojiGameBetRecord{
+		Id:              betRecord.Id,
+		ChatGroupUserId: betRecord.ChatGroupUserId,
+		ChatGroupId:     betRecord.ChatGroupId,
+		GameplayType:    g.gameplayType.Value,
+		IssueNumber:     betRecord.IssueNumber,
+		BetType:         bet.BetType,
+		BetAmount:       bet.BetAmount,
+		SettleStatus:    enums.Unsettled.Value,
+		UpdateTime:      betRecord.UpdateTime,
+		CreateTime:      betRecord.CreateTime,
+	}
+	return emojiGameBetRecord.Create(tx)
+}
+
+func (g *emojiGameplay) Draw(bot *tgbotapi.BotAPI, group *model.ChatGroup, lotteryRecord *model.LotteryRecord) (Lottery, error) {
+	diceValues, err := newGroupDiceSource(bot, group).Roll(g.rule.emoji, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.EmojiGameLotteryRecord{
+		Id:           lotteryRecord.Id,
+		ChatGroupId:  lotteryRecord.ChatGroupId,
+		IssueNumber:  lotteryRecord.IssueNumber,
+		GameplayType: g.gameplayType.Value,
+		Value:        diceValues[0],
+		CreateTime:   lotteryRecord.CreateTime,
+	}, nil
+}
+
+func (g *emojiGameplay) Settle(bot *tgbotapi.BotAPI, group *model.ChatGroup, lottery Lottery) error {
+	lotteryRecord := lottery.(*model.EmojiGameLotteryRecord)
+
+	// 获取所有参与竞猜的用户下注记录
+	emojiGameBetRecord := &model.EmojiGameBetRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: lotteryRecord.IssueNumber,
+	}
+	emojiGameBetRecords, err := emojiGameBetRecord.ListByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": group.Id,
+			"IssueNumber": lotteryRecord.IssueNumber,
+			"err":         err,
+		}).Error("获取用户下注记录异常")
+		return err
+	}
+	// 查询此群的该玩法配置
+	emojiGameConfig, err := model.QueryEmojiGameConfigByChatGroupIdAndGameplayType(store.DB(), group.Id, g.gameplayType.Value)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId":  group.Id,
+			"GameplayType": g.gameplayType.Value,
+			"err":          err,
+		}).Error("查询群的玩法配置异常")
+		return err
+	}
+	odds := g.odds(emojiGameConfig)
+	winBetTypes := g.rule.winBetTypes(lotteryRecord.Value)
+
+	return settleBets(bot, emojiGameBetRecords, func(betRecord *model.EmojiGameBetRecord) betSettlement {
+		betText := betTypeText(betRecord.BetAmount, betRecord.BetType)
+		for _, winBetType := range winBetTypes {
+			if betRecord.BetType == winBetType {
+				return betSettlement{betResultType: enums.Win, amount: betRecord.BetAmount.Mul(odds[betRecord.BetType]), betText: betText}
+			}
+		}
+		return betSettlement{betResultType: enums.Loss, betText: betText}
+	})
+}
+
+func (g *emojiGameplay) ListUnsettledIssues(db *gorm.DB) ([]*UnsettledIssue, error) {
+	emojiGameBetRecord := &model.EmojiGameBetRecord{
+		GameplayType: g.gameplayType.Value,
+		SettleStatus: enums.Unsettled.Value,
+	}
+	emojiGameBetRecords, err := emojiGameBetRecord.ListIssuesBySettleStatusAndGameplayType(db)
+	if err != nil {
+		return nil, err
+	}
+
+	unsettledIssues := make([]*UnsettledIssue, 0, len(emojiGameBetRecords))
+	for _, betRecord := range emojiGameBetRecords {
+		unsettledIssues = append(unsettledIssues, &UnsettledIssue{
+			ChatGroupId: betRecord.ChatGroupId,
+			IssueNumber: betRecord.IssueNumber,
+		})
+	}
+	return unsettledIssues, nil
+}
+
+func (g *emojiGameplay) QueryLottery(db *gorm.DB, record *model.LotteryRecord) (Lottery, error) {
+	emojiGameLotteryRecord := &model.EmojiGameLotteryRecord{
+		Id: record.Id,
+	}
+	return emojiGameLotteryRecord.QueryById(db)
+}
+
+func (g *emojiGameplay) RefundBets(tx *gorm.DB, group *model.ChatGroup, issueNumber string) ([]*RefundedBet, error) {
+	emojiGameBetRecord := &model.EmojiGameBetRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+	}
+	emojiGameBetRecords, err := emojiGameBetRecord.ListByChatGroupIdAndIssueNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return refundBets(tx, emojiGameBetRecords, func(betRecord *model.EmojiGameBetRecord) string {
+		lotteryType, _ := enums.GetGameLotteryType(betRecord.BetType)
+		return lotteryType.Name
+	})
+}
+
+func (g *emojiGameplay) FormatResult(lottery Lottery) (string, error) {
+	lotteryRecord := lottery.(*model.EmojiGameLotteryRecord)
+	return fmt.Sprintf(""+
+		"%s结果: %s\n"+
+		"期号: %s ",
+		g.rule.emoji,
+		g.formatValue(lotteryRecord.Value),
+		lotteryRecord.IssueNumber,
+	), nil
+}
+
+func (g *emojiGameplay) FormatLotteryHistory(db *gorm.DB, record *model.LotteryRecord) (string, error) {
+	emojiGameLotteryRecord := &model.EmojiGameLotteryRecord{
+		Id: record.Id,
+	}
+	emojiGameLotteryRecord, err := emojiGameLotteryRecord.QueryById(db)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s期 %s %s\n",
+		emojiGameLotteryRecord.IssueNumber,
+		g.gameplayType.Name,
+		g.formatValue(emojiGameLotteryRecord.Value),
+	), nil
+}
+
+func (g *emojiGameplay) FormatBetHistory(db *gorm.DB, record *model.BetRecord) (string, error) {
+	emojiGameBetRecord := &model.EmojiGameBetRecord{
+		Id: record.Id,
+	}
+	emojiGameBetRecord, err := emojiGameBetRecord.QueryById(db)
+	if err != nil {
+		return "", err
+	}
+
+	betType, _ := enums.GetGameLotteryType(emojiGameBetRecord.BetType)
+
+	betResultTypeName := "「未开奖」"
+
+	if emojiGameBetRecord.BetResultType != nil {
+		betType, _ := enums.GetBetResultType(*emojiGameBetRecord.BetResultType)
+		betResultTypeName = betType.Name
+	}
+
+	return fmt.Sprintf("%s期 %s %s %v %s %v \n",
+		record.IssueNumber,
+		g.gameplayType.Name,
+		betType.Name,
+		emojiGameBetRecord.BetAmount,
+		betResultTypeName,
+		formatBetResultAmount(emojiGameBetRecord.BetResultAmount),
+	), nil
+}
+
+func (g *emojiGameplay) HelpText(group *model.ChatGroup) (string, error) {
+	emojiGameConfig, err := model.QueryEmojiGameConfigByChatGroupIdAndGameplayType(store.DB(), group.Id, g.gameplayType.Value)
+	if err != nil {
+		return "", err
+	}
+
+	var betTypeNames []string
+	for _, betType := range g.rule.betTypes {
+		betTypeNames = append(betTypeNames, betType.Name)
+	}
+
+	return fmt.Sprintf("当前倍率:\n%s\n\n"+
+		"支持竞猜类型: %s\n"+
+		"竞猜示例(竞猜类型-%s,下注积分-20):\n #%s 20",
+		g.formatOdds(g.odds(emojiGameConfig)),
+		strings.Join(betTypeNames, "、"),
+		g.rule.betTypes[0].Name, g.rule.betTypes[0].Name), nil
+}
+
+func (g *emojiGameplay) ConfigInlineKeyboardRows(group *model.ChatGroup, callbackDataQueryString string) ([][]tgbotapi.InlineKeyboardButton, error) {
+	return [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️%s倍率", g.gameplayType.Name), fmt.Sprintf("%s%s", enums.CallbackUpdateEmojiGameOdds.Value, callbackDataQueryString)),
+		),
+	}, nil
+}
+
+// odds 解析群的该玩法倍率 未配置的下注类型使用默认倍率
+func (g *emojiGameplay) odds(emojiGameConfig *model.EmojiGameConfig) map[string]decimal.Decimal {
+	odds := make(map[string]decimal.Decimal)
+	for key, value := range g.rule.defaultOdds {
+		odds[key] = value
+	}
+	if emojiGameConfig.Odds == "" {
+		return odds
+	}
+
+	var configOdds map[string]decimal.Decimal
+	err := json.Unmarshal([]byte(emojiGameConfig.Odds), &configOdds)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId":  emojiGameConfig.ChatGroupId,
+			"GameplayType": emojiGameConfig.GameplayType,
+			"Odds":         emojiGameConfig.Odds,
+			"err":          err,
+		}).Error("玩法倍率解析异常")
+		return odds
+	}
+	for key, value := range configOdds {
+		odds[key] = value
+	}
+	return odds
+}
+
+// formatOdds 倍率展示 例: 进球: 1.5倍丨未进: 2.2倍
+func (g *emojiGameplay) formatOdds(odds map[string]decimal.Decimal) string {
+	var oddsTexts []string
+	for _, betType := range g.rule.betTypes {
+		oddsTexts = append(oddsTexts, fmt.Sprintf("%s: %v倍", betType.Name, odds[betType.Value]))
+	}
+	return strings.Join(oddsTexts, "丨")
+}
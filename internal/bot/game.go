@@ -3,54 +3,73 @@ package bot
 import (
 	"errors"
 	"fmt"
-	"github.com/go-redis/redis/v8"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
 	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/kv"
 	"telegram-dice-bot/internal/model"
+	"telegram-dice-bot/internal/utils"
 	"time"
 )
 
 const (
 	RedisCurrentIssueNumberKey = "CURRENT_ISSUE_NUMBER:CHAT_GROUP_ID:%s"
+	// RedisClosedIssueNumberKey 已封盘的期号 与当前期号相同时停止下注
+	RedisClosedIssueNumberKey = "CLOSED_ISSUE_NUMBER:CHAT_GROUP_ID:%s"
+	// RedisIssueLockKey 开奖锁 开奖及作废期号前获取 多个实例共用状态存储时同一期只由一个实例处理
+	RedisIssueLockKey = "ISSUE_LOCK:CHAT_GROUP_ID:%s:ISSUE_NUMBER:%s"
+
+	// 开奖锁的过期时间 持有锁的实例中断时到期自动释放
+	issueLockExpiration = 10 * time.Minute
 )
 
 var (
-	stopTaskFlags = make(map[string]chan struct{})
+	stopTaskFlags      = make(map[string]chan struct{})
+	stopTaskFlagsMutex sync.Mutex
 )
 
 func gameStart(bot *tgbotapi.BotAPI, group *model.ChatGroup) {
 
-	issueNumber := time.Now().Format("20060102150405")
+	issueNumber := newIssueNumber(group)
 
 	// 查找上个未开奖的期号
 	redisKey := fmt.Sprintf(RedisCurrentIssueNumberKey, group.Id)
-	issueNumberResult := redisDB.Get(redisDB.Context(), redisKey)
-	if errors.Is(issueNumberResult.Err(), redis.Nil) || issueNumberResult == nil {
-		lotteryDrawTipMsgConfig := tgbotapi.NewMessage(group.TgChatGroupId, fmt.Sprintf("第%s期 %d分钟后开奖", issueNumber, group.GameDrawCycle))
-		_, err := sendMessage(bot, &lotteryDrawTipMsgConfig)
-		if err != nil {
-			blockedOrKicked(err, group.TgChatGroupId)
-			return
-		}
+	result, err := kvStore.Get(redisKey)
+	resumed := err == nil
+	if resumed {
+		issueNumber = result
+	} else if !errors.Is(err, kv.ErrNil) {
+		logrus.WithField("err", err).Error("获取期号异常")
+		return
+	}
+
+	// 先保存开期记录 保存失败时不开启游戏
+	_, err = openIssue(group, issueNumber)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Error("保存开期记录异常")
+		return
+	}
+
+	lotteryDrawTipMsgConfig := tgbotapi.NewMessage(group.TgChatGroupId, formatLotteryDrawTip(group, issueNumber))
+	_, err = sendMessage(bot, &lotteryDrawTipMsgConfig)
+	if err != nil {
+		blockedOrKicked(err, group.TgChatGroupId)
+		return
+	}
+	if !resumed {
 		// 存储当前期号和对话ID
-		err = redisDB.Set(redisDB.Context(), redisKey, issueNumber, 0).Err()
+		err = kvStore.Set(redisKey, issueNumber, 0)
 		if err != nil {
 			logrus.WithField("err", err).Error("存储新期号和对话ID异常")
 			return
 		}
-	} else if issueNumberResult.Err() != nil {
-		logrus.WithField("err", issueNumberResult.Err()).Error("redis获取期号异常")
-		return
-	} else {
-		result, _ := issueNumberResult.Result()
-		issueNumber = result
-		lotteryDrawTipMsgConfig := tgbotapi.NewMessage(group.TgChatGroupId, fmt.Sprintf("第%s期 %d分钟后开奖", issueNumber, group.GameDrawCycle))
-		_, err := sendMessage(bot, &lotteryDrawTipMsgConfig)
-		if err != nil {
-			blockedOrKicked(err, group.TgChatGroupId)
-			return
-		}
 	}
 
 	gameTaskStart(bot, group, issueNumber)
@@ -66,42 +85,577 @@ func gameTaskStart(bot *tgbotapi.BotAPI, group *model.ChatGroup, issueNumber str
 	chatLock.Lock()
 	defer chatLock.Unlock()
 
-	stopTaskFlags[group.Id] = make(chan struct{})
-	go func(stopCh <-chan struct{}) {
-
-		ticker := time.NewTicker(time.Duration(group.GameDrawCycle) * time.Minute)
-		defer ticker.Stop()
+	stopCh := make(chan struct{})
+	stopTaskFlagsMutex.Lock()
+	stopTaskFlags[group.Id] = stopCh
+	stopTaskFlagsMutex.Unlock()
+	gameTaskWG.Add(1)
+	go func(group *model.ChatGroup, stopCh <-chan struct{}) {
+		defer gameTaskWG.Done()
 
 		for {
-			select {
-			case <-ticker.C:
-				if group.GameplayType == enums.QuickThere.Value {
-					nextIssueNumber, err := quickThereTask(bot, group, issueNumber)
-					if err != nil {
-						return
-					}
-					issueNumber = nextIssueNumber
-				} else {
-					return
-				}
-			case <-stopCh:
+			if !waitDraw(bot, group, issueNumber, stopCh) {
 				logrus.WithField("chatGroupId", group.Id).Info("已关闭任务")
 				return
 			}
+			// 每期开奖前重新读取群配置 任务运行期间修改的骰子来源等配置从本期开奖起生效
+			latestGroup, err := store.ChatGroups().QueryById(group.Id)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"chatGroupId": group.Id,
+					"err":         err,
+				}).Warn("重新读取群配置异常 按原配置开奖")
+			} else {
+				group = latestGroup
+			}
+			nextIssueNumber, err := gameplayTask(bot, group, issueNumber)
+			if err != nil {
+				return
+			}
+			issueNumber = nextIssueNumber
 		}
 
-	}(stopTaskFlags[group.Id])
+	}(group, stopCh)
 }
 func gameTaskStop(group *model.ChatGroup) {
-	chatLock := getChatLock(group.Id)
+	gameTaskStopById(group.Id)
+}
+
+func gameTaskStopById(chatGroupId string) {
+	chatLock := getChatLock(chatGroupId)
 	chatLock.Lock()
 	defer chatLock.Unlock()
 
-	if stopFlag, ok := stopTaskFlags[group.Id]; ok {
-		logrus.WithField("groupId", group.Id).Info("停止聊天ID的任务")
+	stopTaskFlagsMutex.Lock()
+	stopFlag, ok := stopTaskFlags[chatGroupId]
+	delete(stopTaskFlags, chatGroupId)
+	stopTaskFlagsMutex.Unlock()
+
+	if ok {
+		logrus.WithField("groupId", chatGroupId).Info("停止聊天ID的任务")
 		close(stopFlag)
-		delete(stopTaskFlags, group.Id)
 	} else {
-		logrus.WithField("groupId", group.Id).Warn("没有要停止的聊天ID的任务")
+		logrus.WithField("groupId", chatGroupId).Warn("没有要停止的聊天ID的任务")
+	}
+}
+
+func gameplayTask(bot *tgbotapi.BotAPI, group *model.ChatGroup, issueNumber string) (nextIssueNumber string, err error) {
+	gameplay, b := getGameplay(group.GameplayType)
+	if !b {
+		logrus.WithField("GameplayType", group.GameplayType).Error("群配置玩法未注册")
+		return "", errors.New("群配置玩法未注册")
+	}
+
+	// 执行任务前对群组校验 如果只剩1个人那必然是自己
+	chatMembersLen, err := bot.GetChatMembersCount(tgbotapi.ChatMemberCountConfig{
+		ChatConfig: tgbotapi.ChatConfig{
+			ChatID: group.TgChatGroupId,
+		},
+	})
+	if chatMembersLen == 1 {
+		logrus.WithField("GroupId", group.Id).Warn("群内只剩机器人")
+		// 更新群状态
+		group.GameplayStatus = 0
+		store.ChatGroups().Save(group)
+		return "", errors.New("群内只剩机器人")
+	}
+
+	// 开奖前封盘 开奖期间的下注提示已封盘而不是暂无开奖活动
+	err = closeIssue(group, issueNumber)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Error("封盘异常")
+		return "", err
+	}
+
+	// 开奖期间持有开奖锁 其他实例启动恢复时不会将该期作为未开奖期号作废
+	locked, err := lockIssue(group.Id, issueNumber)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Error("获取开奖锁异常")
+		return "", err
+	} else if !locked {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+		}).Warn("该期正在由其他实例处理")
+		return "", errors.New("该期正在由其他实例处理")
+	}
+	defer unlockIssue(group.Id, issueNumber)
+
+	redisKey := fmt.Sprintf(RedisCurrentIssueNumberKey, group.Id)
+	// 删除当前期号和对话ID
+	err = kvStore.Del(redisKey)
+	if err != nil {
+		logrus.WithField("redisKey", redisKey).Error("删除当前期号和对话ID异常")
+		return "", err
+	}
+
+	id, err := utils.NextID()
+	if err != nil {
+		logrus.Error("SnowFlakeId create error")
+		return "", err
+	}
+
+	// 开奖主表
+	record := &model.LotteryRecord{
+		Id:           id,
+		ChatGroupId:  group.Id,
+		IssueNumber:  issueNumber,
+		GameplayType: group.GameplayType,
+		CreateTime:   time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	lottery, err := gameplay.Draw(bot, group, record)
+	if err != nil {
+		blockedOrKicked(err, group.TgChatGroupId)
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Error("开奖失败 作废该期")
+		return voidIssue(bot, group, gameplay, record)
+	}
+
+	message, err := gameplay.FormatResult(lottery)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Warn("开奖结果消息格式化异常")
+	}
+
+	err = storeLottery(record, lottery)
+	if err != nil {
+		// 开奖记录未提交 结算无从查询该期 作废并退还下注
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Error("开奖记录保存失败 作废该期")
+		return voidIssue(bot, group, gameplay, record)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("开奖历史", enums.CallbackLotteryHistory.Value),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(group.TgChatGroupId, message)
+	msg.ReplyMarkup = keyboard
+	_, err = sendMessage(bot, &msg)
+	if err != nil {
+		blockedOrKicked(err, group.TgChatGroupId)
+		// 开奖记录已提交 仍需结算该期
+		settleIssue(bot, group, gameplay, record, lottery)
+		return "", err
+	}
+
+	nextIssueNumber, err = openNextIssue(bot, group)
+	settleIssue(bot, group, gameplay, record, lottery)
+	if err != nil {
+		return "", err
+	}
+
+	return nextIssueNumber, nil
+}
+
+// storeLottery 在同一事务中保存开奖主表及玩法开奖表
+func storeLottery(record *model.LotteryRecord, lottery Lottery) error {
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	// 插入开奖主表
+	err := tx.LotteryRecords().Create(record)
+	if err != nil {
+		logrus.WithField("err", err).Error("开奖记录插入异常")
+		return err
+	}
+
+	// 插入玩法开奖表
+	err = lottery.Create(tx.DB())
+	if err != nil {
+		logrus.WithField("err", err).Error("开奖记录插入异常")
+		return err
+	}
+
+	// 提交事务
+	err = tx.Commit()
+	if err != nil {
+		logrus.WithField("err", err).Error("开奖记录提交事务异常")
+		return err
+	}
+	return nil
+}
+
+// settleIssue 发布已提交开奖记录的结算任务 由结算worker遍历下注记录计算竞猜结果 发布失败时直接结算
+func settleIssue(bot *tgbotapi.BotAPI, group *model.ChatGroup, gameplay Gameplay, record *model.LotteryRecord, lottery Lottery) {
+	err := publishSettlement(record)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"lotteryRecordId": record.Id,
+			"err":             err,
+		}).Error("发布结算任务异常 直接结算")
+		settlementWG.Add(1)
+		go func() {
+			defer settlementWG.Done()
+			gameplay.Settle(bot, group, lottery)
+		}()
+	}
+}
+
+// closeIssue 封盘 该期停止下注 开期记录的封盘状态与下注在同一事务内检查 缓存仅用于提示
+func closeIssue(group *model.ChatGroup, issueNumber string) error {
+	issueRecord := &model.IssueRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+	}
+	err := issueRecord.CloseByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil {
+		return err
+	}
+	redisKey := fmt.Sprintf(RedisClosedIssueNumberKey, group.Id)
+	return kvStore.Set(redisKey, issueNumber, 0)
+}
+
+// lockIssue 获取该期的开奖锁 返回是否获取成功
+func lockIssue(chatGroupId string, issueNumber string) (bool, error) {
+	redisKey := fmt.Sprintf(RedisIssueLockKey, chatGroupId, issueNumber)
+	return kvStore.SetNX(redisKey, "1", issueLockExpiration)
+}
+
+// unlockIssue 释放该期的开奖锁
+func unlockIssue(chatGroupId string, issueNumber string) {
+	redisKey := fmt.Sprintf(RedisIssueLockKey, chatGroupId, issueNumber)
+	err := kvStore.Del(redisKey)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"redisKey": redisKey,
+			"err":      err,
+		}).Error("释放开奖锁异常")
+	}
+}
+
+// isIssueClosed 该期是否已封盘
+func isIssueClosed(chatGroupId string, issueNumber string) (bool, error) {
+	redisKey := fmt.Sprintf(RedisClosedIssueNumberKey, chatGroupId)
+	closedIssueNumber, err := kvStore.Get(redisKey)
+	if errors.Is(err, kv.ErrNil) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return closedIssueNumber == issueNumber, nil
+}
+
+// closeBetting 开奖前封盘并通知群 奖池模式下附带封盘时的奖池积分
+func closeBetting(bot *tgbotapi.BotAPI, group *model.ChatGroup, issueNumber string, drawTime time.Time) {
+	err := closeIssue(group, issueNumber)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Error("封盘异常")
+		return
+	}
+
+	closeMsg := tgbotapi.NewMessage(group.TgChatGroupId, fmt.Sprintf("第%s期已封盘,%s开奖,停止下注!", issueNumber, drawTime.Format("15:04:05"))+formatPoolAmount(group, issueNumber))
+	_, err = sendMessage(bot, &closeMsg)
+	blockedOrKicked(err, group.TgChatGroupId)
+}
+
+// openIssue 获取该期的开期记录 不存在时保存开期时的玩法配置 该期按开期时的配置结算
+func openIssue(group *model.ChatGroup, issueNumber string) (*model.IssueRecord, error) {
+	issueRecordQuery := &model.IssueRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
 	}
+	issueRecord, err := issueRecordQuery.QueryByChatGroupIdAndIssueNumber(store.DB())
+	if err == nil {
+		return issueRecord, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	issueRecord = &model.IssueRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+		CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
+	}
+	// 期号按开期时的群时区解析 之后修改时区不影响该期的开奖时间
+	if drawTime, err := parseIssueNumber(group, issueNumber); err == nil {
+		issueRecord.DrawTime = drawTime.Format(time.RFC3339)
+	}
+	if group.GameplayType == enums.QuickThere.Value {
+		quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), group.Id)
+		if err != nil {
+			return nil, err
+		}
+		issueRecord.OddsMode = quickThereConfig.OddsMode
+		issueRecord.PoolRakeRate = quickThereConfig.PoolRakeRate
+	}
+	err = issueRecord.Create(store.DB())
+	if err != nil {
+		// 并发开期时以先写入的开期记录为准
+		issueRecord, queryErr := issueRecordQuery.QueryByChatGroupIdAndIssueNumber(store.DB())
+		if queryErr != nil {
+			return nil, err
+		}
+		return issueRecord, nil
+	}
+	return issueRecord, nil
+}
+
+// formatPoolAmount 奖池模式下该期当前奖池积分 未启用奖池模式时返回空
+func formatPoolAmount(group *model.ChatGroup, issueNumber string) string {
+	gameplay, b := getGameplay(group.GameplayType)
+	if !b {
+		return ""
+	}
+	if poolGameplay, ok := gameplay.(PoolGameplay); ok {
+		if poolAmount, isPool := poolGameplay.PoolAmount(group, issueNumber); isPool {
+			return fmt.Sprintf("\n当前奖池: %.2f积分", poolAmount)
+		}
+	}
+	return ""
+}
+
+// formatLotteryDrawTip 开奖倒计时消息 可验证随机时附带种子承诺 奖池模式下附带当前奖池积分 调用前需已开期
+func formatLotteryDrawTip(group *model.ChatGroup, issueNumber string) string {
+	tip := fmt.Sprintf("第%s期 %s开奖", issueNumber, issueDrawTime(group, issueNumber).Format("15:04:05"))
+	if _, betClose := betCloseDuration(group); betClose {
+		tip += fmt.Sprintf(",开奖前%d秒封盘", group.BetCloseSeconds)
+	}
+	if group.DiceSource == enums.DiceSourceProvablyFair.Value && group.GameplayType == enums.QuickThere.Value {
+		// 可验证随机 开期时公布本期种子承诺
+		serverSeed, err := issueServerSeed(group, issueNumber)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"chatGroupId": group.Id,
+				"issueNumber": issueNumber,
+				"err":         err,
+			}).Error("生成服务端种子异常")
+		} else if serverSeed != "" {
+			tip += fmt.Sprintf("\n本期种子承诺(SHA-256): %s", seedCommitment(serverSeed))
+		}
+	}
+	return tip + formatPoolAmount(group, issueNumber)
+}
+
+// openNextIssue 开启下一期 发送开奖倒计时并记录当前期号
+func openNextIssue(bot *tgbotapi.BotAPI, group *model.ChatGroup) (string, error) {
+	nextIssueNumber := newIssueNumber(group)
+
+	// 先保存开期记录 保存失败时不开启该期
+	_, err := openIssue(group, nextIssueNumber)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": nextIssueNumber,
+			"err":         err,
+		}).Error("保存开期记录异常")
+		return "", err
+	}
+
+	lotteryDrawTipMsgConfig := tgbotapi.NewMessage(group.TgChatGroupId, formatLotteryDrawTip(group, nextIssueNumber))
+	_, err = sendMessage(bot, &lotteryDrawTipMsgConfig)
+	if err != nil {
+		blockedOrKicked(err, group.TgChatGroupId)
+		return "", err
+	}
+
+	// 设置新的期号和对话ID
+	redisKey := fmt.Sprintf(RedisCurrentIssueNumberKey, group.Id)
+	err = kvStore.Set(redisKey, nextIssueNumber, 0)
+	if err != nil {
+		logrus.WithField("err", err).Warn("存储新期号和对话ID异常")
+	}
+	return nextIssueNumber, nil
+}
+
+// voidIssue 开奖失败时作废该期 退还所有未结算下注并通知下注用户 然后开启新的一期
+func voidIssue(bot *tgbotapi.BotAPI, group *model.ChatGroup, gameplay Gameplay, record *model.LotteryRecord) (string, error) {
+	err := cancelIssue(bot, group, gameplay, record, "开奖失败")
+	if err != nil {
+		return "", err
+	}
+
+	// 机器人被踢出或群被删除时游戏已关闭 不再开启新的一期
+	latestGroup, err := store.ChatGroups().QueryById(group.Id)
+	if err != nil {
+		return "", err
+	} else if latestGroup.GameplayStatus != enums.GameplayStatusON.Value {
+		return "", errors.New("群游戏已关闭")
+	}
+
+	return openNextIssue(bot, group)
+}
+
+// cancelCurrentIssue 作废未开奖的当前期并清除当前期号 游戏关闭后切换玩法时调用
+// 避免新玩法沿用原玩法的期号 导致原玩法的下注无法结算
+func cancelCurrentIssue(bot *tgbotapi.BotAPI, group *model.ChatGroup, reason string) error {
+	redisKey := fmt.Sprintf(RedisCurrentIssueNumberKey, group.Id)
+	issueNumber, err := kvStore.Get(redisKey)
+	if errors.Is(err, kv.ErrNil) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	gameplay, b := getGameplay(group.GameplayType)
+	if !b {
+		return errors.New("群配置玩法未注册")
+	}
+
+	locked, err := lockIssue(group.Id, issueNumber)
+	if err != nil {
+		return err
+	} else if !locked {
+		return errors.New("该期正在开奖")
+	}
+	defer unlockIssue(group.Id, issueNumber)
+
+	// 已开奖的期号由结算流程处理 只清除期号
+	_, err = store.LotteryRecords().QueryByChatGroupIdAndIssueNumber(group.Id, issueNumber)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		record := &model.LotteryRecord{
+			Id:           id,
+			ChatGroupId:  group.Id,
+			IssueNumber:  issueNumber,
+			GameplayType: group.GameplayType,
+			CreateTime:   time.Now().Format("2006-01-02 15:04:05"),
+		}
+		err = cancelIssue(bot, group, gameplay, record, reason)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	err = kvStore.Del(redisKey)
+	if err != nil {
+		return err
+	}
+	return kvStore.Del(fmt.Sprintf(RedisClosedIssueNumberKey, group.Id))
+}
+
+// cancelIssue 作废该期 退还所有未结算下注并通知群及下注用户 reason 为作废原因
+func cancelIssue(bot *tgbotapi.BotAPI, group *model.ChatGroup, gameplay Gameplay, record *model.LotteryRecord, reason string) error {
+	refundedBets, chatGroupUsers, err := refundIssueBets(group, gameplay, record)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": record.IssueNumber,
+			"err":         err,
+		}).Error("作废期号退还下注异常")
+		return err
+	}
+
+	voidMsg := tgbotapi.NewMessage(group.TgChatGroupId, fmt.Sprintf("第%s期%s,本期已作废,所有下注已退还!", record.IssueNumber, reason))
+	_, err = sendMessage(bot, &voidMsg)
+	blockedOrKicked(err, group.TgChatGroupId)
+
+	for _, refundedBet := range refundedBets {
+		chatGroupUser := chatGroupUsers[refundedBet.ChatGroupUserId]
+		sendMsg := tgbotapi.NewMessage(chatGroupUser.TgUserId,
+			fmt.Sprintf("您在【%s】第%s期下注%v积分猜【%s】,该期%s已作废,已退还%v积分,积分余额%.2f。",
+				group.TgChatGroupTitle,
+				record.IssueNumber,
+				refundedBet.BetAmount,
+				refundedBet.BetTypeName,
+				reason,
+				refundedBet.BetAmount,
+				chatGroupUser.Balance))
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatGroupUser.TgUserId)
+	}
+	return nil
+}
+
+// refundIssueBets 在一个事务中保存作废的开奖主表记录并退还该期所有未结算下注
+// 返回退还的下注及退还后的用户信息(key 为 ChatGroupUser.Id)
+func refundIssueBets(group *model.ChatGroup, gameplay Gameplay, record *model.LotteryRecord) ([]*RefundedBet, map[string]*model.ChatGroupUser, error) {
+	// 先按用户ID顺序持有该期所有下注用户的锁再开启事务 与下注、结算的加锁顺序一致
+	chatGroupUserIds, err := store.BetRecords().ListChatGroupUserIdsByChatGroupIdAndIssueNumber(group.Id, record.IssueNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(chatGroupUserIds)
+
+	var userLocks []*sync.Mutex
+	defer func() {
+		for _, userLock := range userLocks {
+			userLock.Unlock()
+		}
+	}()
+
+	lockedChatGroupUsers := make(map[string]*model.ChatGroupUser)
+	for _, chatGroupUserId := range chatGroupUserIds {
+		chatGroupUser, err := store.ChatGroupUsers().QueryById(chatGroupUserId)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		userLockKey := fmt.Sprintf(ChatGroupUserLockKey, group.TgChatGroupId, chatGroupUser.TgUserId)
+		userLock := getUserLock(userLockKey)
+		userLock.Lock()
+		userLocks = append(userLocks, userLock)
+		lockedChatGroupUsers[chatGroupUserId] = chatGroupUser
+	}
+
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	record.Status = enums.LotteryCanceled.Value
+	err = tx.LotteryRecords().Create(record)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refundedBets, err := gameplay.RefundBets(tx.DB(), group, record.IssueNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 每笔退还按增量更新余额并记录一条积分流水
+	chatGroupUsers := make(map[string]*model.ChatGroupUser)
+	for _, refundedBet := range refundedBets {
+		chatGroupUser, ok := lockedChatGroupUsers[refundedBet.ChatGroupUserId]
+		if !ok {
+			return nil, nil, fmt.Errorf("退还的下注用户未加锁: %s", refundedBet.ChatGroupUserId)
+		}
+		err = chatGroupUser.AddBalanceById(tx.DB(), refundedBet.BetAmount)
+		if err != nil {
+			return nil, nil, err
+		}
+		chatGroupUser, err = tx.ChatGroupUsers().QueryById(chatGroupUser.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = recordBalanceChange(tx.DB(), chatGroupUser, refundedBet.BetAmount, enums.LedgerBetRefund, refundedBet.BetRecordId)
+		if err != nil {
+			return nil, nil, err
+		}
+		chatGroupUsers[chatGroupUser.Id] = chatGroupUser
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return refundedBets, chatGroupUsers, nil
 }
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"telegram-dice-bot/internal/kv"
+	"telegram-dice-bot/internal/model"
+	"testing"
+	"time"
+)
+
+// 封盘后开期记录不再接受下注 已封盘时下注事务内的检查返回false
+func TestCloseIssueRejectsBets(t *testing.T) {
+	setupTestStore(t)
+	previousKVStore := kvStore
+	kvStore = kv.NewMemoryStore()
+	t.Cleanup(func() {
+		kvStore = previousKVStore
+	})
+
+	group := &model.ChatGroup{Id: "group"}
+	issueNumber := "202401010000"
+	issueRecord := &model.IssueRecord{
+		Id:          "issue",
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+		CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := issueRecord.Create(store.DB()); err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := issueRecord.AddBetCountByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil || !opened {
+		t.Fatalf("封盘前下注 = %v, %v, want true", opened, err)
+	}
+
+	if err := closeIssue(group, issueNumber); err != nil {
+		t.Fatal(err)
+	}
+	opened, err = issueRecord.AddBetCountByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil || opened {
+		t.Fatalf("封盘后下注 = %v, %v, want false", opened, err)
+	}
+	if closed, err := isIssueClosed(group.Id, issueNumber); err != nil || !closed {
+		t.Fatalf("isIssueClosed = %v, %v, want true", closed, err)
+	}
+
+	issueRecord, err = issueRecord.QueryByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issueRecord.BetCount != 1 {
+		t.Fatalf("BetCount = %d, want 1", issueRecord.BetCount)
+	}
+}
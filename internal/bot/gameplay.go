@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+)
+
+// Bet 群内解析出的一次下注
+type Bet struct {
+	BetType   string          // 下注类型 对应 enums.GameLotteryType.Value
+	BetAmount decimal.Decimal // 下注积分
+}
+
+// BetRejectedError 玩法拒绝本次下注 Reason 会回复给下注用户
+type BetRejectedError struct {
+	Reason string
+}
+
+func (e *BetRejectedError) Error() string {
+	return e.Reason
+}
+
+// RefundedBet 作废期号时退还的一笔下注
+type RefundedBet struct {
+	BetRecordId     string
+	ChatGroupUserId string
+	BetTypeName     string
+	BetAmount       decimal.Decimal
+}
+
+// UnsettledIssue 存在未结算下注的一期
+type UnsettledIssue struct {
+	ChatGroupId string
+	IssueNumber string
+}
+
+// Lottery 某一期的玩法开奖明细
+type Lottery interface {
+	Create(db *gorm.DB) error
+}
+
+// Gameplay 游戏玩法 新增玩法时实现该接口并在 init 中调用 registerGameplay 注册
+type Gameplay interface {
+	// InitConfig 初始化群的玩法配置 已存在时不做处理
+	InitConfig(tx *gorm.DB, chatGroupId string) error
+	// ParseBet 解析下注文本 非该玩法的下注格式时返回 nil
+	ParseBet(group *model.ChatGroup, text string) (*Bet, error)
+	// StoreBet 保存玩法下注明细 betRecord 为已保存的下注主表记录
+	StoreBet(tx *gorm.DB, betRecord *model.BetRecord, bet *Bet) error
+	// Draw 开奖 lotteryRecord 为待保存的开奖主表记录
+	Draw(bot *tgbotapi.BotAPI, group *model.ChatGroup, lotteryRecord *model.LotteryRecord) (Lottery, error)
+	// Settle 结算该期所有下注 已结算的下注会被跳过 返回异常时可重复调用
+	Settle(bot *tgbotapi.BotAPI, group *model.ChatGroup, lottery Lottery) error
+	// ListUnsettledIssues 存在未结算下注的所有期号 用于启动时恢复结算
+	ListUnsettledIssues(db *gorm.DB) ([]*UnsettledIssue, error)
+	// QueryLottery 查询已保存的玩法开奖明细 record 为已开奖的开奖主表记录
+	QueryLottery(db *gorm.DB, record *model.LotteryRecord) (Lottery, error)
+	// RefundBets 作废该期 将未结算的玩法下注记录标记为退还 返回需退还给用户的下注
+	RefundBets(tx *gorm.DB, group *model.ChatGroup, issueNumber string) ([]*RefundedBet, error)
+	// FormatResult 开奖结果消息
+	FormatResult(lottery Lottery) (string, error)
+	// FormatLotteryHistory 开奖历史中的一期记录
+	FormatLotteryHistory(db *gorm.DB, record *model.LotteryRecord) (string, error)
+	// FormatBetHistory 下注历史中的一条记录
+	FormatBetHistory(db *gorm.DB, record *model.BetRecord) (string, error)
+	// HelpText /help 中的玩法说明
+	HelpText(group *model.ChatGroup) (string, error)
+	// ConfigInlineKeyboardRows 群配置中的玩法配置按钮
+	ConfigInlineKeyboardRows(group *model.ChatGroup, callbackDataQueryString string) ([][]tgbotapi.InlineKeyboardButton, error)
+}
+
+// PoolGameplay 支持奖池模式的玩法 开奖倒计时消息中展示当前奖池
+type PoolGameplay interface {
+	// PoolAmount 当前期的奖池积分 未启用奖池模式时返回 false
+	PoolAmount(group *model.ChatGroup, issueNumber string) (decimal.Decimal, bool)
+}
+
+// 玩法注册表 key 为 enums.GameplayType.Value
+var gameplayRegistry = make(map[string]Gameplay)
+
+// registerGameplay 注册玩法
+func registerGameplay(gameplayType enums.GameplayType, gameplay Gameplay) {
+	gameplayRegistry[gameplayType.Value] = gameplay
+}
+
+// getGameplay 通过 enums.GameplayType.Value 获取玩法
+func getGameplay(gameplayType string) (Gameplay, bool) {
+	gameplay, ok := gameplayRegistry[gameplayType]
+	return gameplay, ok
+}
+
+// parseBetAmount 解析下注积分 最多两位小数
+func parseBetAmount(text string) (decimal.Decimal, error) {
+	betAmount, err := decimal.Parse(text)
+	if errors.Is(err, decimal.ErrTooManyDecimalPlaces) {
+		return 0, &BetRejectedError{Reason: "下注积分最多支持两位小数!"}
+	}
+	if err != nil || betAmount <= 0 {
+		return 0, errors.New("下注积分异常")
+	}
+	return betAmount, nil
+}
+
+// formatBetResultAmount 下注结果积分展示 如 +12.00 -5.00 未结算时为空
+func formatBetResultAmount(betResultAmount *decimal.Decimal) string {
+	if betResultAmount == nil {
+		return ""
+	}
+	return fmt.Sprintf("%+.2f", *betResultAmount)
+}
@@ -3,13 +3,12 @@ package bot
 import (
 	"errors"
 	"fmt"
-	"github.com/go-redis/redis/v8"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
-	"strconv"
-	"strings"
+	"telegram-dice-bot/internal/decimal"
 	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/kv"
 	"telegram-dice-bot/internal/model"
 	"telegram-dice-bot/internal/utils"
 	"time"
@@ -45,6 +44,10 @@ func handleGroupCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		handleMyHistoryCommand(bot, message)
 	case "help":
 		handleHelpCommand(bot, message)
+	case "duel":
+		handleDuelCommand(bot, message)
+	case "verify":
+		handleVerifyCommand(bot, message)
 	}
 }
 
@@ -53,7 +56,7 @@ func handleHelpCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	messageID := message.MessageID
 
 	// 查询当前群组的配置
-	chatGroup, err := model.QueryChatGroupByTgChatId(db, fromChatId)
+	chatGroup, err := store.ChatGroups().QueryByTgChatId(fromChatId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// 群未初始化则不处理
 		logrus.WithFields(logrus.Fields{
@@ -70,16 +73,15 @@ func handleHelpCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 
 	var gameHelp string
 
-	if chatGroup.GameplayType == enums.QuickThere.Value {
-		quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(db, chatGroup.Id)
+	if gameplay, ok := getGameplay(chatGroup.GameplayType); ok {
+		gameHelp, err = gameplay.HelpText(chatGroup)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"chatGroupId": chatGroup.Id,
 				"err":         err,
-			}).Error("群的快三配置异常")
+			}).Error("群的玩法配置异常")
 			return
 		}
-		gameHelp = fmt.Sprintf("当前倍率:\n简易%v倍丨豹子%v倍\n\n支持竞猜类型: 单、双、大、小、豹子\n竞猜示例(竞猜类型-单,下注积分-20):\n #单 20", quickThereConfig.SimpleOdds, quickThereConfig.TripletOdds)
 	}
 
 	gameplayType, b := enums.GetGameplayType(chatGroup.GameplayType)
@@ -97,12 +99,14 @@ func handleHelpCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 			"/register 用户注册\n"+
 			"/sign 用户签到\n"+
 			"/my 查询积分\n"+
-			"/myhistory 查询历史下注记录\n\n"+
+			"/myhistory 查询历史下注记录\n"+
+			"/duel @用户名 积分 发起对决\n"+
+			"/verify 期号 验证可验证随机开奖结果\n\n"+
 			"当前游戏类型【%s】\n"+
-			"开奖周期 %v 分钟\n"+
+			"%s\n"+
 			"%s",
 			gameplayType.Name,
-			chatGroup.GameDrawCycle,
+			formatDrawSchedule(chatGroup),
 			gameHelp))
 	msgConfig.ReplyToMessageID = messageID
 	sentMsg, err := sendMessage(bot, &msgConfig)
@@ -127,7 +131,7 @@ func handleMyHistoryCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	messageId := message.MessageID
 	tgChatId := message.Chat.ID
 
-	chatGroup, err := model.QueryChatGroupByTgChatId(db, tgChatId)
+	chatGroup, err := store.ChatGroups().QueryByTgChatId(tgChatId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
 			"tgChatId": tgChatId,
@@ -146,7 +150,7 @@ func handleMyHistoryCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		ChatGroupId: chatGroup.Id,
 	}
 
-	chatGroupUser, err := chatGroupUserQuery.QueryByTgUserIdAndChatGroupId(db)
+	chatGroupUser, err := store.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// 没有找到记录
 		msgConfig := tgbotapi.NewMessage(tgChatId, "您还未注册，使用 /register 进行注册。")
@@ -164,7 +168,7 @@ func handleMyHistoryCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	// 查询下注记录
 
 	betRecord := &model.BetRecord{ChatGroupUserId: chatGroupUser.Id}
-	betRecords, err := betRecord.ListByChatGroupUserId(db)
+	betRecords, err := store.BetRecords().ListByChatGroupUserId(betRecord.ChatGroupUserId)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"chatGroupUserId": chatGroupUser.Id,
@@ -172,49 +176,49 @@ func handleMyHistoryCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		}).Error("查询下注记录异常")
 		return
 	}
+	// 查询对决记录
+	duelRecord := &model.DuelRecord{}
+	duelRecords, err := duelRecord.ListByChatGroupUserId(store.DB(), chatGroupUser.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupUserId": chatGroupUser.Id,
+			"err":             err,
+		}).Error("查询对决记录异常")
+		return
+	}
+
 	sendMsg := tgbotapi.NewMessage(tgChatId, "")
 	sendMsg.ReplyToMessageID = messageId
 
-	if len(betRecords) == 0 {
+	if len(betRecords) == 0 && len(duelRecords) == 0 {
 		// 下注记录为空
 		sendMsg.Text = "您还没有下注记录哦!"
 	} else {
-		sendMsg.Text = "您的近10期下注记录如下:\n"
+		if len(betRecords) > 0 {
+			sendMsg.Text = "您的近10期下注记录如下:\n"
+		}
 
 		for _, record := range betRecords {
 			// 开奖类型查询开奖信息
-			switch record.GameplayType {
-			case enums.QuickThere.Value:
-
-				quickThereBetRecord := &model.QuickThereBetRecord{
-					Id: record.Id,
-				}
-				quickThereBetRecord, err := quickThereBetRecord.QueryById(db)
-				if err != nil {
-					logrus.WithFields(logrus.Fields{
-						"recordId": record.Id,
-						"err":      err,
-					}).Error("查询快三下注记录异常")
-					return
-				}
-
-				betType, _ := enums.GetGameLotteryType(quickThereBetRecord.BetType)
-
-				betResultTypeName := "「未开奖」"
-
-				if quickThereBetRecord.BetResultType != nil {
-					betType, _ := enums.GetBetResultType(*quickThereBetRecord.BetResultType)
-					betResultTypeName = betType.Name
-				}
+			gameplay, ok := getGameplay(record.GameplayType)
+			if !ok {
+				continue
+			}
+			betHistory, err := gameplay.FormatBetHistory(store.DB(), record)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"recordId": record.Id,
+					"err":      err,
+				}).Error("查询玩法下注记录异常")
+				return
+			}
+			sendMsg.Text += betHistory
+		}
 
-				sendMsg.Text += fmt.Sprintf("%s期 %s %s %v %s %v \n",
-					record.IssueNumber,
-					"快三",
-					betType.Name,
-					quickThereBetRecord.BetAmount,
-					betResultTypeName,
-					quickThereBetRecord.BetResultAmount,
-				)
+		if len(duelRecords) > 0 {
+			sendMsg.Text += "您的近10场对决记录如下:\n"
+			for _, record := range duelRecords {
+				sendMsg.Text += formatDuelHistory(record, chatGroupUser.Id)
 			}
 		}
 
@@ -247,12 +251,13 @@ func handleGroupNewMembers(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 			// 检查是否是机器人被邀请加入
 			if newMember.UserName == bot.Self.UserName {
 
-				tx := db.Begin()
+				tx := store.Begin()
+				defer tx.Rollback()
 				logrus.WithFields(logrus.Fields{
 					"chatTitle": chatTitle,
 				}).Info("Bot was added to a group")
 				// 查找是否原来关联过该群
-				chatGroup, err := model.QueryChatGroupByTgChatId(tx, chatId)
+				chatGroup, err := tx.ChatGroups().QueryByTgChatId(chatId)
 				if errors.Is(err, gorm.ErrRecordNotFound) {
 					logrus.WithFields(logrus.Fields{
 						"chatId": chatId,
@@ -273,9 +278,10 @@ func handleGroupNewMembers(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 						GameDrawCycle:    1,
 						GameplayStatus:   0,
 						ChatGroupStatus:  enums.GroupNormal.Value,
+						DiceSource:       enums.DiceSourceTelegram.Value,
 						CreateTime:       time.Now().Format("2006-01-02 15:04:05"),
 					}
-					err = chatGroup.Create(tx)
+					err = tx.ChatGroups().Create(chatGroup)
 					if err != nil {
 						logrus.WithFields(logrus.Fields{
 							"err": err,
@@ -284,25 +290,21 @@ func handleGroupNewMembers(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 						return
 					}
 
-					// 初始化快三配置
-					quickThereConfig := &model.QuickThereConfig{
-						ChatGroupId: chatGroupId,
-						SimpleOdds:  2,
-						TripletOdds: 10,
-						CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
-					}
-
-					err = quickThereConfig.Create(tx)
-					if err != nil {
-						logrus.WithFields(logrus.Fields{
-							"err": err,
-						}).Error("初始化快三配置异常")
-						tx.Rollback()
-						return
+					// 初始化各玩法配置
+					for gameplayType, gameplay := range gameplayRegistry {
+						err = gameplay.InitConfig(tx.DB(), chatGroupId)
+						if err != nil {
+							logrus.WithFields(logrus.Fields{
+								"gameplayType": gameplayType,
+								"err":          err,
+							}).Error("初始化玩法配置异常")
+							tx.Rollback()
+							return
+						}
 					}
 
 					// 提交事务
-					if err := tx.Commit().Error; err != nil {
+					if err := tx.Commit(); err != nil {
 						// 提交事务时出现异常，回滚事务
 						tx.Rollback()
 					}
@@ -320,8 +322,8 @@ func handleGroupNewMembers(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 				} else {
 					// 更新原有配置状态为正常
 					chatGroup.ChatGroupStatus = enums.GroupNormal.Value
-					result := db.Save(&chatGroup)
-					if result.Error != nil {
+					err = store.ChatGroups().Save(chatGroup)
+					if err != nil {
 						logrus.WithFields(logrus.Fields{
 							"err": err,
 						}).Error("更新配置异常")
@@ -335,7 +337,7 @@ func handleGroupNewMembers(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 
 			} else {
 				// 非机器人入群 则检查是否已注册
-				chatGroup, err := model.QueryChatGroupByTgChatId(db, chatId)
+				chatGroup, err := store.ChatGroups().QueryByTgChatId(chatId)
 				if errors.Is(err, gorm.ErrRecordNotFound) {
 					// 群未初始化则不处理
 					logrus.WithFields(logrus.Fields{
@@ -353,7 +355,7 @@ func handleGroupNewMembers(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 						TgUserId:    newMember.ID,
 						ChatGroupId: chatGroup.Id,
 					}
-					chatGroupUser, err := chatGroupUserQuery.QueryByTgUserIdAndChatGroupId(db)
+					chatGroupUser, err := store.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
 
 					if errors.Is(err, gorm.ErrRecordNotFound) {
 						logrus.WithFields(logrus.Fields{
@@ -371,7 +373,13 @@ func handleGroupNewMembers(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 					} else {
 						// 已注册则更新状态为未离开
 						chatGroupUser.IsLeft = 0
-						db.Save(&chatGroupUser)
+						err = chatGroupUser.UpdateIsLeftById(store.DB())
+						if err != nil {
+							logrus.WithFields(logrus.Fields{
+								"chatGroupUserId": chatGroupUser.Id,
+								"err":             err,
+							}).Error("更新用户状态异常")
+						}
 					}
 				}
 			}
@@ -385,7 +393,7 @@ func handleGroupMigrateFromChatID(bot *tgbotapi.BotAPI, message *tgbotapi.Messag
 		oldGroupID := message.MigrateFromChatID
 		newSuperGroupID := message.Chat.ID
 		// 普通群组升级为超级群组
-		chatGroup, err := model.QueryChatGroupByTgChatId(db, oldGroupID)
+		chatGroup, err := store.ChatGroups().QueryByTgChatId(oldGroupID)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"oldGroupID": oldGroupID,
@@ -394,7 +402,7 @@ func handleGroupMigrateFromChatID(bot *tgbotapi.BotAPI, message *tgbotapi.Messag
 			return
 		}
 		chatGroup.TgChatGroupId = newSuperGroupID
-		db.Save(&chatGroup)
+		store.ChatGroups().Save(chatGroup)
 		logrus.WithFields(logrus.Fields{
 			"oldGroupID":      oldGroupID,
 			"newSuperGroupID": newSuperGroupID,
@@ -407,7 +415,7 @@ func handleGroupNewChatTitle(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	if message.NewChatTitle != "" {
 		newChatTitle := message.NewChatTitle
 		tgChatGroupId := message.Chat.ID
-		chatGroup, err := model.QueryChatGroupByTgChatId(db, tgChatGroupId)
+		chatGroup, err := store.ChatGroups().QueryByTgChatId(tgChatGroupId)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"tgChatGroupId": tgChatGroupId,
@@ -416,7 +424,7 @@ func handleGroupNewChatTitle(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 			return
 		}
 		chatGroup.TgChatGroupTitle = newChatTitle
-		db.Save(&chatGroup)
+		store.ChatGroups().Save(chatGroup)
 		logrus.WithFields(logrus.Fields{
 			"chatGroupId":  chatGroup.Id,
 			"newChatTitle": newChatTitle,
@@ -430,10 +438,11 @@ func handleGroupLeftChatMember(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 		tgChatGroupId := message.Chat.ID
 		leftUser := message.LeftChatMember
 
-		tx := db.Begin()
+		tx := store.Begin()
+		defer tx.Rollback()
 
 		// 查询该群的信息
-		chatGroup, err := model.QueryChatGroupByTgChatId(tx, tgChatGroupId)
+		chatGroup, err := tx.ChatGroups().QueryByTgChatId(tgChatGroupId)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"tgChatGroupId": tgChatGroupId,
@@ -449,7 +458,7 @@ func handleGroupLeftChatMember(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 			ChatGroupId: chatGroup.Id,
 		}
 
-		chatGroupUser, err := chatGroupUserQuery.QueryByTgUserIdAndChatGroupId(tx)
+		chatGroupUser, err := tx.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logrus.WithFields(logrus.Fields{
 				"TgUserId":    chatGroupUserQuery.TgUserId,
@@ -466,8 +475,8 @@ func handleGroupLeftChatMember(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 		} else {
 			// 更新该用户状态为离开
 			chatGroupUser.IsLeft = 1
-			result := tx.Save(&chatGroupUser)
-			if result.Error != nil {
+			err = chatGroupUser.UpdateIsLeftById(tx.DB())
+			if err != nil {
 				logrus.WithFields(logrus.Fields{
 					"err": err,
 				}).Error("更新用户状态异常")
@@ -476,14 +485,14 @@ func handleGroupLeftChatMember(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 			}
 		}
 
-		chatGroupAdmin, err := model.QueryChatGroupAdminByChatGroupIdAndTgUserId(tx, chatGroup.Id, leftUser.ID)
+		chatGroupAdmin, err := tx.ChatGroupAdmins().QueryByChatGroupIdAndTgUserId(chatGroup.Id, leftUser.ID)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logrus.WithFields(logrus.Fields{
 				"tgChatGroupId": chatGroup.Id,
 				"tgUserId":      leftUser.ID,
 			}).Warn("该用户非管理员")
 			// 提交事务
-			if err := tx.Commit().Error; err != nil {
+			if err := tx.Commit(); err != nil {
 				// 提交事务时出现异常，回滚事务
 				tx.Rollback()
 			}
@@ -497,9 +506,9 @@ func handleGroupLeftChatMember(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 			tx.Rollback()
 		} else {
 			// 删除该用户的管理员权限
-			tx.Delete(&chatGroupAdmin)
+			tx.ChatGroupAdmins().DeleteByChatGroupIdAndAdminTgUserId(chatGroupAdmin.ChatGroupId, chatGroupAdmin.AdminTgUserId)
 			// 提交事务
-			if err := tx.Commit().Error; err != nil {
+			if err := tx.Commit(); err != nil {
 				// 提交事务时出现异常，回滚事务
 				tx.Rollback()
 			}
@@ -508,7 +517,7 @@ func handleGroupLeftChatMember(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 }
 
 func handleGroupText(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
-	go handleBettingText(bot, message)
+	handleBettingText(bot, message)
 }
 
 func handleBettingText(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
@@ -516,7 +525,7 @@ func handleBettingText(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	messageId := message.MessageID
 
 	// 查询该群的信息
-	chatGroup, err := model.QueryChatGroupByTgChatId(db, tgChatGroupId)
+	chatGroup, err := store.ChatGroups().QueryByTgChatId(tgChatGroupId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
 			"tgChatGroupId": tgChatGroupId,
@@ -530,49 +539,56 @@ func handleBettingText(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		return
 	}
 
-	if chatGroup.GameplayType == enums.QuickThere.Value {
-		b, err := handleQuickThereBettingText(bot, chatGroup, message)
-		if b {
-			// 回复下注成功信息
-			replyMsg := tgbotapi.NewMessage(tgChatGroupId, "下注成功!")
-			replyMsg.ReplyToMessageID = messageId
-			_, err = bot.Send(replyMsg)
-			if err != nil {
-				logrus.WithFields(logrus.Fields{
-					"err": err,
-				}).Error("发送消息异常")
-				blockedOrKicked(err, tgChatGroupId)
-			}
-		} else if err != nil {
+	gameplay, ok := getGameplay(chatGroup.GameplayType)
+	if !ok {
+		return
+	}
+
+	bet, err := gameplay.ParseBet(chatGroup, message.Text)
+	var betRejectedError *BetRejectedError
+	if errors.As(err, &betRejectedError) {
+		rejectedMsg := tgbotapi.NewMessage(tgChatGroupId, betRejectedError.Reason)
+		rejectedMsg.ReplyToMessageID = messageId
+		_, err = bot.Send(rejectedMsg)
+		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"err": err,
-			}).Error("处理下注信息异常")
+			}).Error("发送下注拒绝提示异常")
+			blockedOrKicked(err, tgChatGroupId)
 		}
+		return
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("处理下注信息异常")
+		return
+	} else if bet == nil {
+		return
+	}
+
+	b, err := handleBet(bot, chatGroup, gameplay, message, bet)
+	if b {
+		// 回复下注成功信息
+		replyMsg := tgbotapi.NewMessage(tgChatGroupId, "下注成功!")
+		replyMsg.ReplyToMessageID = messageId
+		_, err = bot.Send(replyMsg)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"err": err,
+			}).Error("发送消息异常")
+			blockedOrKicked(err, tgChatGroupId)
+		}
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("处理下注信息异常")
 	}
 }
 
-func handleQuickThereBettingText(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup, message *tgbotapi.Message) (bool, error) {
-	text := message.Text
+func handleBet(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup, gameplay Gameplay, message *tgbotapi.Message, bet *Bet) (bool, error) {
 	tgChatGroupId := message.Chat.ID
 	messageId := message.MessageID
 
-	// 解析下注命令，示例命令格式：#单 20
-	parts := strings.Fields(text)
-	if len(parts) != 2 || !strings.HasPrefix(parts[0], "#") {
-		return false, nil
-	}
-
-	// 获取下注类型和下注积分
-	betType := parts[0][1:]
-	if betType != "单" && betType != "双" && betType != "大" && betType != "小" && betType != "豹子" {
-		return false, nil
-	}
-
-	betAmount, err := strconv.ParseFloat(parts[1], 64)
-	if err != nil || betAmount <= 0 {
-		return false, errors.New("下注积分异常")
-	}
-
 	if chatGroup.GameplayStatus == enums.GameplayStatusOFF.Value {
 		registrationMsg := tgbotapi.NewMessage(tgChatGroupId, "功能未开启！")
 		registrationMsg.ReplyToMessageID = messageId
@@ -589,33 +605,44 @@ func handleQuickThereBettingText(bot *tgbotapi.BotAPI, chatGroup *model.ChatGrou
 
 	// 获取当前进行的期号
 	redisKey := fmt.Sprintf(RedisCurrentIssueNumberKey, chatGroup.Id)
-	issueNumberResult := redisDB.Get(redisDB.Context(), redisKey)
-	if errors.Is(issueNumberResult.Err(), redis.Nil) || issueNumberResult == nil {
+	issueNumber, err := kvStore.Get(redisKey)
+	if errors.Is(err, kv.ErrNil) {
+		// 开奖期间当前期号已删除 下一期尚未开始
+		closedIssueNumber, closedErr := kvStore.Get(fmt.Sprintf(RedisClosedIssueNumberKey, chatGroup.Id))
+		if closedErr == nil {
+			return false, replyBettingClosed(bot, message, closedIssueNumber)
+		}
 		logrus.WithFields(logrus.Fields{
 			"redisKey": redisKey,
-			"err":      issueNumberResult.Err(),
-		}).Warn("redis键不存在")
+			"err":      err,
+		}).Warn("当前期号不存在")
 		replyMsg := tgbotapi.NewMessage(tgChatGroupId, "当前暂无开奖活动!")
 		replyMsg.ReplyToMessageID = messageId
 		_, sendErr := bot.Send(replyMsg)
 		blockedOrKicked(sendErr, tgChatGroupId)
 		return false, nil
-	} else if issueNumberResult.Err() != nil {
+	} else if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"redisKey": redisKey,
-			"err":      issueNumberResult.Err(),
-		}).Error("redis获取当前期号异常")
+			"err":      err,
+		}).Error("获取当前期号异常")
 		return false, nil
 	}
 
-	issueNumber, _ := issueNumberResult.Result()
+	closed, err := isIssueClosed(chatGroup.Id, issueNumber)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroup.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Error("redis获取封盘期号异常")
+		return false, nil
+	} else if closed {
+		return false, replyBettingClosed(bot, message, issueNumber)
+	}
 
 	// 存储下注记录到数据库，并扣除用户余额
-	b, err := storeQuickThereBetRecord(bot, chatGroup, message, &model.QuickThereBetRecord{
-		IssueNumber: issueNumber,
-		BetType:     betType,
-		BetAmount:   betAmount,
-	})
+	b, err := storeBetRecord(bot, chatGroup, gameplay, message, issueNumber, bet)
 
 	if !b && err != nil {
 		logrus.WithFields(logrus.Fields{
@@ -626,7 +653,22 @@ func handleQuickThereBettingText(bot *tgbotapi.BotAPI, chatGroup *model.ChatGrou
 	return b, nil
 }
 
-func storeQuickThereBetRecord(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup, message *tgbotapi.Message, quickThereBetRecord *model.QuickThereBetRecord) (bool, error) {
+// replyBettingClosed 回复该期已封盘
+func replyBettingClosed(bot *tgbotapi.BotAPI, message *tgbotapi.Message, issueNumber string) error {
+	replyMsg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("第%s期已封盘,停止下注,请等待下一期!", issueNumber))
+	replyMsg.ReplyToMessageID = message.MessageID
+	_, err := bot.Send(replyMsg)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("发送封盘提示异常")
+		blockedOrKicked(err, message.Chat.ID)
+		return err
+	}
+	return nil
+}
+
+func storeBetRecord(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup, gameplay Gameplay, message *tgbotapi.Message, issueNumber string, bet *Bet) (bool, error) {
 	user := message.From
 	messageId := message.MessageID
 	chatId := message.Chat.ID
@@ -637,7 +679,26 @@ func storeQuickThereBetRecord(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup,
 	userLock.Lock()
 	defer userLock.Unlock()
 
-	tx := db.Begin()
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	// 在下注事务内检查封盘 封盘会等待已开始的下注提交 封盘后不再接受下注
+	issueRecord := &model.IssueRecord{
+		ChatGroupId: chatGroup.Id,
+		IssueNumber: issueNumber,
+	}
+	opened, err := issueRecord.AddBetCountByChatGroupIdAndIssueNumber(tx.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroup.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Error("检查封盘状态异常")
+		return false, err
+	} else if !opened {
+		tx.Rollback()
+		return false, replyBettingClosed(bot, message, issueNumber)
+	}
 
 	// 查询该群用户信息
 	chatGroupUserQuery := &model.ChatGroupUser{
@@ -645,7 +706,7 @@ func storeQuickThereBetRecord(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup,
 		ChatGroupId: chatGroup.Id,
 	}
 
-	chatGroupUser, err := chatGroupUserQuery.QueryByTgUserIdAndChatGroupId(tx)
+	chatGroupUser, err := tx.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// 用户不存在，发送注册提示
 		registrationMsg := tgbotapi.NewMessage(chatId, "您还未注册，使用 /register 进行注册。")
@@ -668,7 +729,7 @@ func storeQuickThereBetRecord(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup,
 		return false, err
 	} else {
 		// 检查用户余额是否足够
-		if chatGroupUser.Balance < quickThereBetRecord.BetAmount {
+		if chatGroupUser.Balance < bet.BetAmount {
 			// 用户不存在，发送注册提示
 			balanceInsufficientMsg := tgbotapi.NewMessage(chatId, "您的余额不足!")
 			balanceInsufficientMsg.ReplyToMessageID = messageId
@@ -684,30 +745,49 @@ func storeQuickThereBetRecord(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup,
 			}
 		}
 
-		// 扣除用户余额
-		chatGroupUser.Balance -= quickThereBetRecord.BetAmount
-		// 同步更新用户信息
-		chatGroupUser.Username = user.UserName
-
-		result := tx.Save(&chatGroupUser)
-		if result.Error != nil {
+		// 按增量扣除用户余额 余额已被其他进程扣减到不足时不扣除
+		deducted, err := chatGroupUser.DeductBalanceById(tx.DB(), bet.BetAmount)
+		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"err": err,
 			}).Error("扣除用户余额异常")
 			tx.Rollback()
-			return false, result.Error
+			return false, err
+		}
+		if !deducted {
+			tx.Rollback()
+			balanceInsufficientMsg := tgbotapi.NewMessage(chatId, "您的余额不足!")
+			balanceInsufficientMsg.ReplyToMessageID = messageId
+			_, err := bot.Send(balanceInsufficientMsg)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"err": err,
+				}).Error("您的余额不足提示异常")
+				blockedOrKicked(err, chatId)
+				return false, err
+			}
+			return false, nil
 		}
-		currentTime := time.Now().Format("2006-01-02 15:04:05")
 
-		// 映射下注类型
-		betType, b := enums.GetGameLotteryTypeForName(quickThereBetRecord.BetType)
-		if !b {
+		// 同步更新用户信息
+		chatGroupUser.Username = user.UserName
+		err = chatGroupUser.UpdateUsernameById(tx.DB())
+		if err != nil {
 			logrus.WithFields(logrus.Fields{
-				"betType": quickThereBetRecord.BetType,
-				"err":     err,
-			}).Error("下注类型映射异常")
-			return false, errors.New("该下注类型映射异常")
+				"err": err,
+			}).Error("更新用户信息异常")
+			tx.Rollback()
+			return false, err
+		}
+		chatGroupUser, err = tx.ChatGroupUsers().QueryById(chatGroupUser.Id)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"err": err,
+			}).Error("查询用户信息异常")
+			tx.Rollback()
+			return false, err
 		}
+		currentTime := time.Now().Format("2006-01-02 15:04:05")
 
 		id, err := utils.NextID()
 		if err != nil {
@@ -721,50 +801,56 @@ func storeQuickThereBetRecord(bot *tgbotapi.BotAPI, chatGroup *model.ChatGroup,
 			ChatGroupUserId: chatGroupUser.Id,
 			ChatGroupId:     chatGroup.Id,
 			GameplayType:    chatGroup.GameplayType,
-			IssueNumber:     quickThereBetRecord.IssueNumber,
+			IssueNumber:     issueNumber,
 			UpdateTime:      currentTime,
 			CreateTime:      currentTime,
 		}
 
-		err = betRecord.Create(tx)
+		err = tx.BetRecords().Create(betRecord)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"err": err,
 			}).Error("保存下注记录异常")
-			// 如果保存下注记录失败，需要返还用户余额
-			//chatGroupUser.Balance += quickThereBetRecord.BetAmount
-			//tx.Save(&user)
 			tx.Rollback()
 			return false, err
 		}
 
-		// 保存快三下注记录
-		quickThereBetRecordCreate := &model.QuickThereBetRecord{
-			Id:              id,
-			ChatGroupUserId: chatGroupUser.Id,
-			ChatGroupId:     chatGroup.Id,
-			IssueNumber:     quickThereBetRecord.IssueNumber,
-			BetType:         betType.Value,
-			BetAmount:       quickThereBetRecord.BetAmount,
-			SettleStatus:    enums.Unsettled.Value,
-			UpdateTime:      currentTime,
-			CreateTime:      currentTime,
+		// 记录下注扣除积分流水
+		err = recordBalanceChange(tx.DB(), chatGroupUser, -bet.BetAmount, enums.LedgerBet, betRecord.Id)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"err": err,
+			}).Error("记录积分流水异常")
+			tx.Rollback()
+			return false, err
 		}
 
-		err = quickThereBetRecordCreate.Create(tx)
-		if err != nil {
+		// 保存玩法下注记录
+		err = gameplay.StoreBet(tx.DB(), betRecord, bet)
+		var betRejectedError *BetRejectedError
+		if errors.As(err, &betRejectedError) {
+			tx.Rollback()
+			rejectedMsg := tgbotapi.NewMessage(chatId, betRejectedError.Reason)
+			rejectedMsg.ReplyToMessageID = messageId
+			_, err := bot.Send(rejectedMsg)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"err": err,
+				}).Error("发送下注拒绝提示异常")
+				blockedOrKicked(err, chatId)
+				return false, err
+			}
+			return false, nil
+		} else if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"err": err,
-			}).Error("保存快三下注记录异常")
-			// 如果保存下注记录失败，需要返还用户余额
-			//chatGroupUser.Balance += quickThereBetRecord.BetAmount
-			//tx.Save(&user)
+			}).Error("保存玩法下注记录异常")
 			tx.Rollback()
-			return false, result.Error
+			return false, err
 		}
 
 		// 提交事务
-		if err := tx.Commit().Error; err != nil {
+		if err := tx.Commit(); err != nil {
 			// 提交事务时出现异常，回滚事务
 			tx.Rollback()
 		}
@@ -779,7 +865,7 @@ func handleMyCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	messageId := message.MessageID
 
 	// 查询该群的信息
-	chatGroup, err := model.QueryChatGroupByTgChatId(db, tgChatGroupId)
+	chatGroup, err := store.ChatGroups().QueryByTgChatId(tgChatGroupId)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"tgChatGroupId": tgChatGroupId,
@@ -794,7 +880,7 @@ func handleMyCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		ChatGroupId: chatGroup.Id,
 	}
 
-	chatGroupUser, err := chatGroupUserQuery.QueryByTgUserIdAndChatGroupId(db)
+	chatGroupUser, err := store.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// 没有找到记录
 		msgConfig := tgbotapi.NewMessage(tgChatGroupId, "请发送 /register 注册用户！")
@@ -838,7 +924,7 @@ func handleSignCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	messageId := message.MessageID
 
 	// 查询该群的信息
-	chatGroup, err := model.QueryChatGroupByTgChatId(db, tgChatGroupId)
+	chatGroup, err := store.ChatGroups().QueryByTgChatId(tgChatGroupId)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"tgChatGroupId": tgChatGroupId,
@@ -853,7 +939,7 @@ func handleSignCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		ChatGroupId: chatGroup.Id,
 	}
 
-	chatGroupUser, err := chatGroupUserQuery.QueryByTgUserIdAndChatGroupId(db)
+	chatGroupUser, err := store.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// 没有找到记录
@@ -896,12 +982,33 @@ func handleSignCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 			}
 		}
 		chatGroupUser.SignInTime = time.Now().Format("2006-01-02 15:04:05")
-		chatGroupUser.Balance += 1000
-		result := db.Save(&chatGroupUser)
-		if result.Error != nil {
+
+		tx := store.Begin()
+		defer tx.Rollback()
+		err = chatGroupUser.UpdateSignInTimeById(tx.DB())
+		if err == nil {
+			err = chatGroupUser.AddBalanceById(tx.DB(), decimal.NewFromInt(1000))
+		}
+		if err == nil {
+			chatGroupUser, err = tx.ChatGroupUsers().QueryById(chatGroupUser.Id)
+		}
+		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"err": err,
 			}).Error("保存用户信息异常")
+			tx.Rollback()
+			return
+		}
+		err = recordBalanceChange(tx.DB(), chatGroupUser, decimal.NewFromInt(1000), enums.LedgerSignIn, "")
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"err": err,
+			}).Error("记录积分流水异常")
+			tx.Rollback()
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			tx.Rollback()
 			return
 		}
 		msgConfig := tgbotapi.NewMessage(tgChatGroupId, "签到成功！奖励1000积分！")
@@ -917,7 +1024,7 @@ func handleRegisterCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	messageId := message.MessageID
 
 	// 查询该群的信息
-	chatGroup, err := model.QueryChatGroupByTgChatId(db, tgChatGroupId)
+	chatGroup, err := store.ChatGroups().QueryByTgChatId(tgChatGroupId)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"err": err,
@@ -931,7 +1038,7 @@ func handleRegisterCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		ChatGroupId: chatGroup.Id,
 	}
 
-	_, err = chatGroupUserQuery.QueryByTgUserIdAndChatGroupId(db)
+	_, err = store.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(chatGroupUserQuery.TgUserId, chatGroupUserQuery.ChatGroupId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// 没有找到记录 则注册
 		chatGroupUser := &model.ChatGroupUser{
@@ -939,11 +1046,20 @@ func handleRegisterCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 			ChatGroupId: chatGroup.Id,
 			Username:    fromUser.UserName,
 			IsLeft:      0,
-			Balance:     1000,
+			Balance:     decimal.NewFromInt(1000),
 			CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
 		}
-		err := chatGroupUser.Create(db)
+		tx := store.Begin()
+		defer tx.Rollback()
+		err := tx.ChatGroupUsers().Create(chatGroupUser)
+		if err == nil {
+			err = recordBalanceChange(tx.DB(), chatGroupUser, chatGroupUser.Balance, enums.LedgerRegister, "")
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
 		if err != nil {
+			tx.Rollback()
 			logrus.WithFields(logrus.Fields{
 				"err": err,
 			}).Error("创建用户信息异常")
@@ -986,7 +1102,7 @@ func handleGroupReloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		return
 	}
 
-	ChatGroup, err := model.QueryChatGroupByTgChatId(db, chatId)
+	ChatGroup, err := store.ChatGroups().QueryByTgChatId(chatId)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
 			"chatId": chatId,
@@ -1002,10 +1118,19 @@ func handleGroupReloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 
 	botIsAdmin := false
 
-	tx := db.Begin()
+	tx := store.Begin()
+	defer tx.Rollback()
 
 	// 删除该群关联的管理员记录
-	model.DeleteChatGroupAdminByChatGroupId(tx, ChatGroup.Id)
+	err = tx.ChatGroupAdmins().DeleteByChatGroupId(ChatGroup.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": ChatGroup.Id,
+			"err":         err,
+		}).Error("删除管理员信息异常")
+		tx.Rollback()
+		return
+	}
 
 	// 载入新的管理员信息
 	for _, administrator := range administrators {
@@ -1016,7 +1141,7 @@ func handleGroupReloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		if user.UserName == bot.Self.UserName {
 			botIsAdmin = true
 		}
-		err := model.CreateChatGroupAdmin(tx, &model.ChatGroupAdmin{
+		err := tx.ChatGroupAdmins().Create(&model.ChatGroupAdmin{
 			ChatGroupId:   ChatGroup.Id,
 			AdminTgUserId: user.ID,
 			CreateTime:    time.Now().Format("2006-01-02 15:04:05"),
@@ -1033,7 +1158,7 @@ func handleGroupReloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	}
 
 	// 提交事务
-	if err := tx.Commit().Error; err != nil {
+	if err := tx.Commit(); err != nil {
 		// 提交事务时出现异常，回滚事务
 		tx.Rollback()
 	}
@@ -0,0 +1,332 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strings"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+	"time"
+)
+
+type guessPointGameplay struct{}
+
+// 猜点数支持的下注类型
+var guessPointBetTypes = []enums.GameLotteryType{
+	enums.Single, enums.Double, enums.Big, enums.Small,
+	enums.Point1, enums.Point2, enums.Point3, enums.Point4, enums.Point5, enums.Point6,
+}
+
+func init() {
+	registerGameplay(enums.GuessPoint, &guessPointGameplay{})
+}
+
+func (g *guessPointGameplay) InitConfig(tx *gorm.DB, chatGroupId string) error {
+	_, err := model.QueryGuessPointConfigByChatGroupId(tx, chatGroupId)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	guessPointConfig := &model.GuessPointConfig{
+		ChatGroupId: chatGroupId,
+		PointOdds:   decimal.NewFromInt(5),
+		SimpleOdds:  decimal.MustParse("1.9"),
+		CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
+	}
+	return guessPointConfig.Create(tx)
+}
+
+func (g *guessPointGameplay) ParseBet(group *model.ChatGroup, text string) (*Bet, error) {
+	// 解析下注命令，示例命令格式：#点3 20
+	parts := strings.Fields(text)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "#") {
+		return nil, nil
+	}
+
+	// 获取下注类型和下注积分
+	betTypeName := parts[0][1:]
+	if !isGuessPointBetTypeName(betTypeName) {
+		return nil, nil
+	}
+
+	betAmount, err := parseBetAmount(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	// 映射下注类型
+	betType, b := enums.GetGameLotteryTypeForName(betTypeName)
+	if !b {
+		logrus.WithField("betType", betTypeName).Error("下注类型映射异常")
+		return nil, errors.New("该下注类型映射异常")
+	}
+
+	return &Bet{
+		BetType:   betType.Value,
+		BetAmount: betAmount,
+	}, nil
+}
+
+// isGuessPointBetTypeName 是否为猜点数支持的下注类型
+func isGuessPointBetTypeName(betTypeName string) bool {
+	for _, betType := range guessPointBetTypes {
+		if betType.Name == betTypeName {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *guessPointGameplay) StoreBet(tx *gorm.DB, betRecord *model.BetRecord, bet *Bet) error {
+	// 保存猜点数下注记录
+	guessPointBetRecord := &model.GuessPointBetRecord{
+		Id:              betRecord.Id,
+		ChatGroupUserId: betRecord.ChatGroupUserId,
+		ChatGroupId:     betRecord.ChatGroupId,
+		IssueNumber:     betRecord.IssueNumber,
+		BetType:         bet.BetType,
+		BetAmount:       bet.BetAmount,
+		SettleStatus:    enums.Unsettled.Value,
+		UpdateTime:      betRecord.UpdateTime,
+		CreateTime:      betRecord.CreateTime,
+	}
+	return guessPointBetRecord.Create(tx)
+}
+
+func (g *guessPointGameplay) Draw(bot *tgbotapi.BotAPI, group *model.ChatGroup, lotteryRecord *model.LotteryRecord) (Lottery, error) {
+	diceValues, err := newGroupDiceSource(bot, group).Roll("🎲", 1)
+	if err != nil {
+		return nil, err
+	}
+	singleOrDouble, bigOrSmall := determineGuessPointResult(diceValues[0])
+
+	return &model.GuessPointLotteryRecord{
+		Id:           lotteryRecord.Id,
+		ChatGroupId:  lotteryRecord.ChatGroupId,
+		IssueNumber:  lotteryRecord.IssueNumber,
+		Value:        diceValues[0],
+		SingleDouble: singleOrDouble,
+		BigSmall:     bigOrSmall,
+		CreateTime:   lotteryRecord.CreateTime,
+	}, nil
+}
+
+func (g *guessPointGameplay) Settle(bot *tgbotapi.BotAPI, group *model.ChatGroup, lottery Lottery) error {
+	lotteryRecord := lottery.(*model.GuessPointLotteryRecord)
+
+	// 获取所有参与竞猜的用户下注记录
+	guessPointBetRecord := &model.GuessPointBetRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: lotteryRecord.IssueNumber,
+	}
+	guessPointBetRecords, err := guessPointBetRecord.ListByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": group.Id,
+			"IssueNumber": lotteryRecord.IssueNumber,
+			"err":         err,
+		}).Error("获取用户下注记录异常")
+		return err
+	}
+	// 查询此群的猜点数配置
+	guessPointConfig, err := model.QueryGuessPointConfigByChatGroupId(store.DB(), group.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": group.Id,
+			"err":         err,
+		}).Error("查询群的猜点数配置异常")
+		return err
+	}
+
+	return settleBets(bot, guessPointBetRecords, func(betRecord *model.GuessPointBetRecord) betSettlement {
+		betText := betTypeText(betRecord.BetAmount, betRecord.BetType)
+		if odds, win := guessPointBetOdds(guessPointConfig, betRecord.BetType, lotteryRecord); win {
+			return betSettlement{betResultType: enums.Win, amount: betRecord.BetAmount.Mul(odds), betText: betText}
+		}
+		return betSettlement{betResultType: enums.Loss, betText: betText}
+	})
+}
+
+func (g *guessPointGameplay) ListUnsettledIssues(db *gorm.DB) ([]*UnsettledIssue, error) {
+	guessPointBetRecord := &model.GuessPointBetRecord{
+		SettleStatus: enums.Unsettled.Value,
+	}
+	guessPointBetRecords, err := guessPointBetRecord.ListIssuesBySettleStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	unsettledIssues := make([]*UnsettledIssue, 0, len(guessPointBetRecords))
+	for _, betRecord := range guessPointBetRecords {
+		unsettledIssues = append(unsettledIssues, &UnsettledIssue{
+			ChatGroupId: betRecord.ChatGroupId,
+			IssueNumber: betRecord.IssueNumber,
+		})
+	}
+	return unsettledIssues, nil
+}
+
+func (g *guessPointGameplay) QueryLottery(db *gorm.DB, record *model.LotteryRecord) (Lottery, error) {
+	guessPointLotteryRecord := &model.GuessPointLotteryRecord{
+		Id: record.Id,
+	}
+	return guessPointLotteryRecord.QueryById(db)
+}
+
+func (g *guessPointGameplay) RefundBets(tx *gorm.DB, group *model.ChatGroup, issueNumber string) ([]*RefundedBet, error) {
+	guessPointBetRecord := &model.GuessPointBetRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+	}
+	guessPointBetRecords, err := guessPointBetRecord.ListByChatGroupIdAndIssueNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return refundBets(tx, guessPointBetRecords, func(betRecord *model.GuessPointBetRecord) string {
+		lotteryType, _ := enums.GetGameLotteryType(betRecord.BetType)
+		return lotteryType.Name
+	})
+}
+
+func (g *guessPointGameplay) FormatResult(lottery Lottery) (string, error) {
+	lotteryRecord := lottery.(*model.GuessPointLotteryRecord)
+
+	singleOrDoubleType, b := enums.GetGameLotteryType(lotteryRecord.SingleDouble)
+	if !b {
+		logrus.WithFields(logrus.Fields{
+			"singleOrDouble": lotteryRecord.SingleDouble,
+		}).Error("开奖结果映射异常")
+		return "", errors.New("开奖结果映射异常")
+	}
+	bigOrSmallType, b := enums.GetGameLotteryType(lotteryRecord.BigSmall)
+	if !b {
+		logrus.WithFields(logrus.Fields{
+			"bigOrSmall": lotteryRecord.BigSmall,
+		}).Error("开奖结果映射异常")
+		return "", errors.New("开奖结果映射异常")
+	}
+
+	return fmt.Sprintf(""+
+		"点数: %d\n"+
+		"[单/双]: %s \n"+
+		"[大/小]: %s \n"+
+		"期号: %s ",
+		lotteryRecord.Value,
+		singleOrDoubleType.Name,
+		bigOrSmallType.Name,
+		lotteryRecord.IssueNumber,
+	), nil
+}
+
+func (g *guessPointGameplay) FormatLotteryHistory(db *gorm.DB, record *model.LotteryRecord) (string, error) {
+	guessPointLotteryRecord := &model.GuessPointLotteryRecord{
+		Id: record.Id,
+	}
+	guessPointLotteryRecord, err := guessPointLotteryRecord.QueryById(db)
+	if err != nil {
+		return "", err
+	}
+
+	bigSmall, _ := enums.GetGameLotteryType(guessPointLotteryRecord.BigSmall)
+	singleDouble, _ := enums.GetGameLotteryType(guessPointLotteryRecord.SingleDouble)
+
+	return fmt.Sprintf("%s期 %s %d %s %s\n",
+		guessPointLotteryRecord.IssueNumber,
+		"猜点数",
+		guessPointLotteryRecord.Value,
+		bigSmall.Name,
+		singleDouble.Name,
+	), nil
+}
+
+func (g *guessPointGameplay) FormatBetHistory(db *gorm.DB, record *model.BetRecord) (string, error) {
+	guessPointBetRecord := &model.GuessPointBetRecord{
+		Id: record.Id,
+	}
+	guessPointBetRecord, err := guessPointBetRecord.QueryById(db)
+	if err != nil {
+		return "", err
+	}
+
+	betType, _ := enums.GetGameLotteryType(guessPointBetRecord.BetType)
+
+	betResultTypeName := "「未开奖」"
+
+	if guessPointBetRecord.BetResultType != nil {
+		betType, _ := enums.GetBetResultType(*guessPointBetRecord.BetResultType)
+		betResultTypeName = betType.Name
+	}
+
+	return fmt.Sprintf("%s期 %s %s %v %s %v \n",
+		record.IssueNumber,
+		"猜点数",
+		betType.Name,
+		guessPointBetRecord.BetAmount,
+		betResultTypeName,
+		formatBetResultAmount(guessPointBetRecord.BetResultAmount),
+	), nil
+}
+
+func (g *guessPointGameplay) HelpText(group *model.ChatGroup) (string, error) {
+	guessPointConfig, err := model.QueryGuessPointConfigByChatGroupId(store.DB(), group.Id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("当前倍率:\n"+
+		"点数%v倍丨简易%v倍\n\n"+
+		"支持竞猜类型: 点数(点1-点6)、单、双、大(4-6)、小(1-3)\n"+
+		"竞猜示例(竞猜类型-点数3,下注积分-20):\n #点3 20\n"+
+		"竞猜示例(竞猜类型-大,下注积分-20):\n #大 20",
+		guessPointConfig.PointOdds, guessPointConfig.SimpleOdds), nil
+}
+
+func (g *guessPointGameplay) ConfigInlineKeyboardRows(group *model.ChatGroup, callbackDataQueryString string) ([][]tgbotapi.InlineKeyboardButton, error) {
+	// 查询该配置
+	guessPointConfig, err := model.QueryGuessPointConfigByChatGroupId(store.DB(), group.Id)
+	if err != nil {
+		return nil, err
+	}
+	return [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️点数倍率: %v 倍", guessPointConfig.PointOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateGuessPointPointOdds.Value, callbackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️简易倍率: %v 倍", guessPointConfig.SimpleOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateGuessPointSimpleOdds.Value, callbackDataQueryString)),
+		),
+	}, nil
+}
+
+// determineGuessPointResult 根据单颗骰子点数确定结果（单/双，大/小）。
+func determineGuessPointResult(value int) (string, string) {
+	var singleOrDouble string
+	var bigOrSmall string
+
+	if value <= 3 {
+		bigOrSmall = enums.Small.Value
+	} else {
+		bigOrSmall = enums.Big.Value
+	}
+
+	if value%2 == 1 {
+		singleOrDouble = enums.Single.Value
+	} else {
+		singleOrDouble = enums.Double.Value
+	}
+
+	return singleOrDouble, bigOrSmall
+}
+
+// guessPointBetOdds 根据开奖结果计算下注类型的中奖倍率
+func guessPointBetOdds(guessPointConfig *model.GuessPointConfig, betType string, lotteryRecord *model.GuessPointLotteryRecord) (decimal.Decimal, bool) {
+	if betType == lotteryRecord.SingleDouble || betType == lotteryRecord.BigSmall {
+		return guessPointConfig.SimpleOdds, true
+	}
+	if pointType, b := enums.GetPointGameLotteryType(lotteryRecord.Value); b && betType == pointType.Value {
+		return guessPointConfig.PointOdds, true
+	}
+	return 0, false
+}
@@ -0,0 +1,417 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+	"time"
+)
+
+// 比大小最高点数相同时的最大加赛轮数 超过后平分奖池
+const highestRollMaxTieBreakRounds = 3
+
+type highestRollGameplay struct{}
+
+func init() {
+	registerGameplay(enums.HighestRoll, &highestRollGameplay{})
+}
+
+func (g *highestRollGameplay) InitConfig(tx *gorm.DB, chatGroupId string) error {
+	_, err := model.QueryHighestRollConfigByChatGroupId(tx, chatGroupId)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	highestRollConfig := &model.HighestRollConfig{
+		ChatGroupId: chatGroupId,
+		EntryFee:    decimal.NewFromInt(10),
+		CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
+	}
+	return highestRollConfig.Create(tx)
+}
+
+func (g *highestRollGameplay) ParseBet(group *model.ChatGroup, text string) (*Bet, error) {
+	// 解析参与命令，示例命令格式：#参与
+	if strings.TrimSpace(text) != "#"+enums.Join.Name {
+		return nil, nil
+	}
+
+	// 参与费用固定为群配置的参与费用
+	highestRollConfig, err := model.QueryHighestRollConfigByChatGroupId(store.DB(), group.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": group.Id,
+			"err":         err,
+		}).Error("查询群的比大小配置异常")
+		return nil, err
+	}
+
+	return &Bet{
+		BetType:   enums.Join.Value,
+		BetAmount: highestRollConfig.EntryFee,
+	}, nil
+}
+
+func (g *highestRollGameplay) StoreBet(tx *gorm.DB, betRecord *model.BetRecord, bet *Bet) error {
+	// 每期每人只能参与一次
+	participantQuery := &model.HighestRollParticipant{
+		ChatGroupUserId: betRecord.ChatGroupUserId,
+		IssueNumber:     betRecord.IssueNumber,
+	}
+	_, err := participantQuery.QueryByChatGroupUserIdAndIssueNumber(tx)
+	if err == nil {
+		return &BetRejectedError{Reason: "您已参与本期比大小,请等待开奖!"}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// 保存比大小参与记录
+	highestRollParticipant := &model.HighestRollParticipant{
+		Id:              betRecord.Id,
+		ChatGroupUserId: betRecord.ChatGroupUserId,
+		ChatGroupId:     betRecord.ChatGroupId,
+		IssueNumber:     betRecord.IssueNumber,
+		EntryFee:        bet.BetAmount,
+		SettleStatus:    enums.Unsettled.Value,
+		UpdateTime:      betRecord.UpdateTime,
+		CreateTime:      betRecord.CreateTime,
+	}
+	return highestRollParticipant.Create(tx)
+}
+
+func (g *highestRollGameplay) Draw(bot *tgbotapi.BotAPI, group *model.ChatGroup, lotteryRecord *model.LotteryRecord) (Lottery, error) {
+	// 获取本期所有参与者
+	participantQuery := &model.HighestRollParticipant{
+		ChatGroupId: group.Id,
+		IssueNumber: lotteryRecord.IssueNumber,
+	}
+	participants, err := participantQuery.ListByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": group.Id,
+			"IssueNumber": lotteryRecord.IssueNumber,
+			"err":         err,
+		}).Error("获取比大小参与记录异常")
+		return nil, err
+	}
+
+	highestRollLotteryRecord := &model.HighestRollLotteryRecord{
+		Id:               lotteryRecord.Id,
+		ChatGroupId:      lotteryRecord.ChatGroupId,
+		IssueNumber:      lotteryRecord.IssueNumber,
+		ParticipantCount: len(participants),
+		CreateTime:       lotteryRecord.CreateTime,
+		Participants:     participants,
+	}
+
+	if len(participants) == 0 {
+		return highestRollLotteryRecord, nil
+	}
+
+	for _, participant := range participants {
+		highestRollLotteryRecord.PoolAmount += participant.EntryFee
+	}
+
+	// 按参与顺序为每位参与者掷一颗骰子
+	diceSource := newGroupDiceSource(bot, group)
+	diceValues, err := diceSource.Roll("🎲", len(participants))
+	if err != nil {
+		return nil, err
+	}
+	for i, participant := range participants {
+		value := diceValues[i]
+		participant.Value = &value
+	}
+
+	leaders := highestRollLeaders(participants, diceValues)
+	highestRollLotteryRecord.MaxValue = *leaders[0].Value
+
+	// 最高点数相同时加赛
+	for round := 1; len(leaders) > 1 && round <= highestRollMaxTieBreakRounds; round++ {
+		tieBreakMsg := tgbotapi.NewMessage(group.TgChatGroupId,
+			fmt.Sprintf("最高点数相同,第%d轮加赛: %s", round, formatHighestRollParticipantNames(leaders)))
+		_, err = sendMessage(bot, &tieBreakMsg)
+		if err != nil {
+			return nil, err
+		}
+
+		diceValues, err = diceSource.Roll("🎲", len(leaders))
+		if err != nil {
+			return nil, err
+		}
+		for i, participant := range leaders {
+			if participant.TieBreakValues != "" {
+				participant.TieBreakValues += ","
+			}
+			participant.TieBreakValues += strconv.Itoa(diceValues[i])
+		}
+		leaders = highestRollLeaders(leaders, diceValues)
+	}
+
+	highestRollLotteryRecord.WinnerCount = len(leaders)
+	highestRollLotteryRecord.Winners = leaders
+
+	return highestRollLotteryRecord, nil
+}
+
+func (g *highestRollGameplay) Settle(bot *tgbotapi.BotAPI, group *model.ChatGroup, lottery Lottery) error {
+	lotteryRecord := lottery.(*model.HighestRollLotteryRecord)
+
+	if lotteryRecord.WinnerCount == 0 {
+		return nil
+	}
+
+	// 奖池由最高点数者平分
+	winAmount := lotteryRecord.PoolAmount.Div(int64(lotteryRecord.WinnerCount))
+
+	return settleBets(bot, lotteryRecord.Participants, func(participant *model.HighestRollParticipant) betSettlement {
+		betText := fmt.Sprintf("参与比大小(参与费用%v积分),掷出%d点", participant.EntryFee, *participant.Value)
+		if isHighestRollWinner(lotteryRecord, participant) {
+			return betSettlement{betResultType: enums.Win, amount: winAmount, betText: betText}
+		}
+		return betSettlement{betResultType: enums.Loss, betText: betText}
+	})
+}
+
+func (g *highestRollGameplay) ListUnsettledIssues(db *gorm.DB) ([]*UnsettledIssue, error) {
+	highestRollParticipant := &model.HighestRollParticipant{
+		SettleStatus: enums.Unsettled.Value,
+	}
+	highestRollParticipants, err := highestRollParticipant.ListIssuesBySettleStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	unsettledIssues := make([]*UnsettledIssue, 0, len(highestRollParticipants))
+	for _, participant := range highestRollParticipants {
+		unsettledIssues = append(unsettledIssues, &UnsettledIssue{
+			ChatGroupId: participant.ChatGroupId,
+			IssueNumber: participant.IssueNumber,
+		})
+	}
+	return unsettledIssues, nil
+}
+
+func (g *highestRollGameplay) QueryLottery(db *gorm.DB, record *model.LotteryRecord) (Lottery, error) {
+	highestRollLotteryRecord := &model.HighestRollLotteryRecord{
+		Id: record.Id,
+	}
+	highestRollLotteryRecord, err := highestRollLotteryRecord.QueryById(db)
+	if err != nil {
+		return nil, err
+	}
+
+	participantQuery := &model.HighestRollParticipant{
+		ChatGroupId: highestRollLotteryRecord.ChatGroupId,
+		IssueNumber: highestRollLotteryRecord.IssueNumber,
+	}
+	participants, err := participantQuery.ListByChatGroupIdAndIssueNumber(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// 根据保存的掷骰点数及加赛点数还原瓜分奖池的参与者
+	highestRollLotteryRecord.Participants = participants
+	highestRollLotteryRecord.Winners = highestRollWinners(participants)
+	return highestRollLotteryRecord, nil
+}
+
+func (g *highestRollGameplay) RefundBets(tx *gorm.DB, group *model.ChatGroup, issueNumber string) ([]*RefundedBet, error) {
+	highestRollParticipant := &model.HighestRollParticipant{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+	}
+	highestRollParticipants, err := highestRollParticipant.ListByChatGroupIdAndIssueNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return refundBets(tx, highestRollParticipants, func(participant *model.HighestRollParticipant) string {
+		return enums.Join.Name
+	})
+}
+
+func (g *highestRollGameplay) FormatResult(lottery Lottery) (string, error) {
+	lotteryRecord := lottery.(*model.HighestRollLotteryRecord)
+
+	if lotteryRecord.ParticipantCount == 0 {
+		return fmt.Sprintf(""+
+			"本期无人参与比大小\n"+
+			"期号: %s ",
+			lotteryRecord.IssueNumber,
+		), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("参与人数: %d 奖池: %.2f积分\n", lotteryRecord.ParticipantCount, lotteryRecord.PoolAmount))
+	for _, participant := range lotteryRecord.Participants {
+		sb.WriteString(fmt.Sprintf("%s: %d点", formatHighestRollParticipantNames([]*model.HighestRollParticipant{participant}), *participant.Value))
+		if participant.TieBreakValues != "" {
+			sb.WriteString(fmt.Sprintf(" 加赛: %s", participant.TieBreakValues))
+		}
+		sb.WriteString("\n")
+	}
+
+	winnerNames := formatHighestRollParticipantNames(lotteryRecord.Winners)
+	if lotteryRecord.WinnerCount == 1 {
+		sb.WriteString(fmt.Sprintf("🏆%s 赢得奖池%.2f积分\n", winnerNames, lotteryRecord.PoolAmount))
+	} else {
+		sb.WriteString(fmt.Sprintf("🏆%s 平分奖池,每人%.2f积分\n", winnerNames, lotteryRecord.PoolAmount.Div(int64(lotteryRecord.WinnerCount))))
+	}
+	sb.WriteString(fmt.Sprintf("期号: %s ", lotteryRecord.IssueNumber))
+
+	return sb.String(), nil
+}
+
+func (g *highestRollGameplay) FormatLotteryHistory(db *gorm.DB, record *model.LotteryRecord) (string, error) {
+	highestRollLotteryRecord := &model.HighestRollLotteryRecord{
+		Id: record.Id,
+	}
+	highestRollLotteryRecord, err := highestRollLotteryRecord.QueryById(db)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s期 %s %d人参与 最高%d点 奖池%v\n",
+		highestRollLotteryRecord.IssueNumber,
+		"比大小",
+		highestRollLotteryRecord.ParticipantCount,
+		highestRollLotteryRecord.MaxValue,
+		highestRollLotteryRecord.PoolAmount,
+	), nil
+}
+
+func (g *highestRollGameplay) FormatBetHistory(db *gorm.DB, record *model.BetRecord) (string, error) {
+	highestRollParticipant := &model.HighestRollParticipant{
+		Id: record.Id,
+	}
+	highestRollParticipant, err := highestRollParticipant.QueryById(db)
+	if err != nil {
+		return "", err
+	}
+
+	betResultTypeName := "「未开奖」"
+
+	if highestRollParticipant.BetResultType != nil {
+		betType, _ := enums.GetBetResultType(*highestRollParticipant.BetResultType)
+		betResultTypeName = betType.Name
+	}
+
+	return fmt.Sprintf("%s期 %s %s %v %s %v \n",
+		record.IssueNumber,
+		"比大小",
+		enums.Join.Name,
+		highestRollParticipant.EntryFee,
+		betResultTypeName,
+		formatBetResultAmount(highestRollParticipant.BetResultAmount),
+	), nil
+}
+
+func (g *highestRollGameplay) HelpText(group *model.ChatGroup) (string, error) {
+	highestRollConfig, err := model.QueryHighestRollConfigByChatGroupId(store.DB(), group.Id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("当前参与费用: %v积分\n\n"+
+		"开奖时为每位参与者掷一颗骰子,点数最高者赢得全部奖池;\n"+
+		"最高点数相同时加赛,加赛%d轮后仍相同则平分奖池。\n"+
+		"每期每人限参与一次。\n"+
+		"参与示例:\n #%s",
+		highestRollConfig.EntryFee, highestRollMaxTieBreakRounds, enums.Join.Name), nil
+}
+
+func (g *highestRollGameplay) ConfigInlineKeyboardRows(group *model.ChatGroup, callbackDataQueryString string) ([][]tgbotapi.InlineKeyboardButton, error) {
+	// 查询该配置
+	highestRollConfig, err := model.QueryHighestRollConfigByChatGroupId(store.DB(), group.Id)
+	if err != nil {
+		return nil, err
+	}
+	return [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("💰参与费用: %v 积分", highestRollConfig.EntryFee), fmt.Sprintf("%s%s", enums.CallbackUpdateHighestRollEntryFee.Value, callbackDataQueryString)),
+		),
+	}, nil
+}
+
+// highestRollLeaders 返回点数最高的参与者 values 与 participants 一一对应
+func highestRollLeaders(participants []*model.HighestRollParticipant, values []int) []*model.HighestRollParticipant {
+	maxValue := 0
+	for _, value := range values {
+		if value > maxValue {
+			maxValue = value
+		}
+	}
+
+	var leaders []*model.HighestRollParticipant
+	for i, participant := range participants {
+		if values[i] == maxValue {
+			leaders = append(leaders, participant)
+		}
+	}
+	return leaders
+}
+
+// highestRollWinners 根据已保存的掷骰点数及加赛点数计算瓜分奖池的参与者 与开奖时的加赛过程一致
+func highestRollWinners(participants []*model.HighestRollParticipant) []*model.HighestRollParticipant {
+	values := make([]int, 0, len(participants))
+	for _, participant := range participants {
+		value := 0
+		if participant.Value != nil {
+			value = *participant.Value
+		}
+		values = append(values, value)
+	}
+	leaders := highestRollLeaders(participants, values)
+
+	for round := 0; len(leaders) > 1; round++ {
+		values = make([]int, 0, len(leaders))
+		for _, participant := range leaders {
+			tieBreakValues := strings.Split(participant.TieBreakValues, ",")
+			if participant.TieBreakValues == "" || round >= len(tieBreakValues) {
+				// 未再加赛 剩余参与者平分奖池
+				return leaders
+			}
+			value, _ := strconv.Atoi(tieBreakValues[round])
+			values = append(values, value)
+		}
+		leaders = highestRollLeaders(leaders, values)
+	}
+	return leaders
+}
+
+// isHighestRollWinner 是否为本期瓜分奖池的参与者
+func isHighestRollWinner(lotteryRecord *model.HighestRollLotteryRecord, participant *model.HighestRollParticipant) bool {
+	for _, winner := range lotteryRecord.Winners {
+		if winner.Id == participant.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// formatHighestRollParticipantNames 参与者用户名 以顿号分隔
+func formatHighestRollParticipantNames(participants []*model.HighestRollParticipant) string {
+	names := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		chatGroupUser := &model.ChatGroupUser{Id: participant.ChatGroupUserId}
+		chatGroupUser, err := store.ChatGroupUsers().QueryById(chatGroupUser.Id)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"ChatGroupUserId": participant.ChatGroupUserId,
+				"err":             err,
+			}).Error("查询该用户信息异常")
+			names = append(names, "【未知用户】")
+			continue
+		}
+		names = append(names, fmt.Sprintf("【@%s】", chatGroupUser.Username))
+	}
+	return strings.Join(names, "、")
+}
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+	"time"
+)
+
+// 流水对账间隔
+const balanceLedgerReconcileInterval = 24 * time.Hour
+
+// recordBalanceChange 记录积分流水 需与余额变动在同一事务中调用 chatGroupUser 为变动后的用户信息
+func recordBalanceChange(tx *gorm.DB, chatGroupUser *model.ChatGroupUser, amount decimal.Decimal, reasonType enums.LedgerReasonType, referenceId string) error {
+	balanceLedger := &model.BalanceLedger{
+		ChatGroupUserId: chatGroupUser.Id,
+		ChatGroupId:     chatGroupUser.ChatGroupId,
+		Amount:          amount,
+		BalanceAfter:    chatGroupUser.Balance,
+		ReasonType:      reasonType.Value,
+		ReferenceId:     referenceId,
+		CreateTime:      time.Now().Format("2006-01-02 15:04:05"),
+	}
+	return balanceLedger.Create(tx)
+}
+
+// initBalanceLedgerTask 启动时对账 之后定时对账
+func initBalanceLedgerTask() {
+	reconcileBalanceLedger()
+
+	go func() {
+		ticker := time.NewTicker(balanceLedgerReconcileInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			reconcileBalanceLedger()
+		}
+	}()
+}
+
+// reconcileBalanceLedger 校验每个用户的流水合计与积分余额是否一致
+// 启用流水前已注册的用户没有流水 补记一条期初余额
+func reconcileBalanceLedger() {
+	// 在同一事务中读取余额与流水 保证读到一致的快照
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	balanceLedgerSummaries, err := model.ListBalanceLedgerSummaries(tx.DB())
+	if err != nil {
+		tx.Rollback()
+		logrus.WithField("err", err).Error("查询积分流水合计异常")
+		return
+	}
+
+	var openingSummaries []*model.BalanceLedgerSummary
+	mismatchCount := 0
+	for _, summary := range balanceLedgerSummaries {
+		if summary.LedgerCount == 0 {
+			if summary.Balance != 0 {
+				openingSummaries = append(openingSummaries, summary)
+			}
+			continue
+		}
+		if summary.LedgerAmount != summary.Balance {
+			mismatchCount++
+			logrus.WithFields(logrus.Fields{
+				"ChatGroupUserId": summary.ChatGroupUserId,
+				"ChatGroupId":     summary.ChatGroupId,
+				"Balance":         summary.Balance,
+				"LedgerAmount":    summary.LedgerAmount,
+			}).Error("积分流水合计与余额不一致")
+		}
+	}
+	tx.Rollback()
+
+	for _, summary := range openingSummaries {
+		openBalanceLedger(summary)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"userCount":     len(balanceLedgerSummaries),
+		"openingCount":  len(openingSummaries),
+		"mismatchCount": mismatchCount,
+	}).Info("积分流水对账完成")
+}
+
+// openBalanceLedger 为没有流水的用户补记期初余额
+func openBalanceLedger(summary *model.BalanceLedgerSummary) {
+	chatGroup, err := store.ChatGroups().QueryById(summary.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": summary.ChatGroupId,
+			"err":         err,
+		}).Error("查询群信息异常")
+		return
+	}
+
+	chatGroupUser := &model.ChatGroupUser{Id: summary.ChatGroupUserId}
+	chatGroupUser, err = store.ChatGroupUsers().QueryById(chatGroupUser.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupUserId": summary.ChatGroupUserId,
+			"err":             err,
+		}).Error("查询该用户信息异常")
+		return
+	}
+
+	// 获取用户对应的互斥锁
+	userLock := getUserLock(fmt.Sprintf(ChatGroupUserLockKey, chatGroup.TgChatGroupId, chatGroupUser.TgUserId))
+	userLock.Lock()
+	defer userLock.Unlock()
+
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	// 加锁后重新查询 期间已产生流水时不再补记
+	chatGroupUser, err = tx.ChatGroupUsers().QueryById(chatGroupUser.Id)
+	if err != nil {
+		tx.Rollback()
+		return
+	}
+	balanceLedgerQuery := &model.BalanceLedger{ChatGroupUserId: chatGroupUser.Id}
+	ledgerCount, err := balanceLedgerQuery.CountByChatGroupUserId(tx.DB())
+	if err != nil || ledgerCount > 0 {
+		tx.Rollback()
+		return
+	}
+
+	err = recordBalanceChange(tx.DB(), chatGroupUser, chatGroupUser.Balance, enums.LedgerOpening, "")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupUserId": chatGroupUser.Id,
+			"err":             err,
+		}).Error("补记期初余额异常")
+		tx.Rollback()
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+	}
+}
@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"context"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// ShutdownTimeout 收到退出信号后等待处理中的消息、开奖与结算完成的最长秒数
+	ShutdownTimeout = "SHUTDOWN_TIMEOUT"
+
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+var (
+	// gameTaskWG 运行中的开奖任务 正在开奖的一期完成后任务才会退出
+	gameTaskWG sync.WaitGroup
+	// settlementWG 运行中的结算worker及直接结算
+	settlementWG sync.WaitGroup
+	// settlementStopCh 关闭后结算worker处理完已领取的任务即退出
+	settlementStopCh = make(chan struct{})
+)
+
+// notifyShutdown 收到 SIGINT 或 SIGTERM 时关闭返回的通道
+func notifyShutdown() <-chan struct{} {
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
+
+	shutdownCh := make(chan struct{})
+	go func() {
+		sig := <-signalCh
+		logrus.WithField("signal", sig.String()).Info("收到退出信号 开始停止服务")
+		close(shutdownCh)
+	}()
+	return shutdownCh
+}
+
+func shutdownTimeout() time.Duration {
+	value := os.Getenv(ShutdownTimeout)
+	if value == "" {
+		return defaultShutdownTimeout
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		logrus.WithField("value", value).Warn("退出等待时间配置错误 使用默认值")
+		return defaultShutdownTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// shutdown 按顺序停止服务 超时后不再等待直接退出
+// 停止接收更新 -> 处理完已接收的更新 -> 停止开奖任务(正在开奖的一期继续完成) -> 结算完已发布的任务 -> 关闭数据库与 Redis
+// 未开奖的期号保存在状态存储中 未结算的期在下次启动时补结算
+func shutdown(bot *tgbotapi.BotAPI, updates tgbotapi.UpdatesChannel, updateDispatcher *dispatcher) {
+	timeout := shutdownTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		stopReceivingUpdates(ctx, bot, updates, updateDispatcher)
+		updateDispatcher.Close()
+		logrus.Info("已处理完接收的更新")
+
+		stopAllGameTasks()
+		gameTaskWG.Wait()
+		logrus.Info("开奖任务已停止")
+
+		close(settlementStopCh)
+		settlementWG.Wait()
+		logrus.Info("结算任务已停止")
+
+		stopDuelTimers()
+		logrus.Info("对决过期任务已停止")
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logrus.WithField("timeout", timeout.String()).Warn("等待处理中的任务超时 强制退出")
+		return
+	}
+
+	// 配置了 Redis 时状态存储与结算队列共用同一个连接 随状态存储一起关闭
+	if err := kvStore.Close(); err != nil {
+		logrus.WithField("err", err).Error("关闭状态存储异常")
+	}
+	if err := store.Close(); err != nil {
+		logrus.WithField("err", err).Error("关闭数据库连接异常")
+	}
+	logrus.Info("服务已停止")
+}
+
+// stopReceivingUpdates 停止接收更新 已接收的更新仍交给分发器处理
+func stopReceivingUpdates(ctx context.Context, bot *tgbotapi.BotAPI, updates tgbotapi.UpdatesChannel, updateDispatcher *dispatcher) {
+	if webhookServer != nil {
+		// 等待处理中的推送请求完成 期间继续分发它们写入的更新
+		stopped := make(chan struct{})
+		go func() {
+			if err := webhookServer.Shutdown(ctx); err != nil {
+				logrus.WithField("err", err).Error("关闭 webhook HTTP 服务异常")
+			}
+			close(stopped)
+		}()
+		for {
+			select {
+			case update := <-updates:
+				updateDispatcher.Dispatch(update)
+			case <-stopped:
+				drainUpdates(updates, updateDispatcher)
+				return
+			}
+		}
+	}
+
+	// 正在进行的长轮询请求返回前不会关闭 updates 不再等待
+	bot.StopReceivingUpdates()
+	drainUpdates(updates, updateDispatcher)
+}
+
+// drainUpdates 分发已缓冲的更新
+func drainUpdates(updates tgbotapi.UpdatesChannel, updateDispatcher *dispatcher) {
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			updateDispatcher.Dispatch(update)
+		default:
+			return
+		}
+	}
+}
+
+// stopAllGameTasks 通知所有开奖任务停止
+func stopAllGameTasks() {
+	stopTaskFlagsMutex.Lock()
+	chatGroupIds := make([]string, 0, len(stopTaskFlags))
+	for chatGroupId := range stopTaskFlags {
+		chatGroupIds = append(chatGroupIds, chatGroupId)
+	}
+	stopTaskFlagsMutex.Unlock()
+	for _, chatGroupId := range chatGroupIds {
+		gameTaskStopById(chatGroupId)
+	}
+}
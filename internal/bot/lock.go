@@ -25,12 +25,12 @@ func getUserLock(userID string) *sync.Mutex {
 	return userLocks[userID]
 }
 
-// getUserLock 根据userID获取对应的互斥锁，如果不存在则创建一个新的锁
+// getChatLock 根据chatId获取对应的互斥锁，如果不存在则创建一个新的锁
 func getChatLock(chatId string) *sync.Mutex {
 	chatLocksMutex.Lock()
 	defer chatLocksMutex.Unlock()
 
-	if _, ok := userLocks[chatId]; !ok {
+	if _, ok := chatLocks[chatId]; !ok {
 		chatLocks[chatId] = &sync.Mutex{}
 	}
 
@@ -17,11 +17,12 @@ func handleMessage(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	} else {
 		// 非cmd
 		if message.Chat.IsSuperGroup() || message.Chat.IsGroup() {
-			go handleGroupMigrateFromChatID(bot, message)
-			go handleGroupNewChatTitle(bot, message)
-			go handleGroupNewMembers(bot, message)
-			go handleGroupLeftChatMember(bot, message)
-			go handleGroupText(bot, message)
+			// 由分发器的worker按顺序处理 保证同一个群的消息不会并发处理
+			handleGroupMigrateFromChatID(bot, message)
+			handleGroupNewChatTitle(bot, message)
+			handleGroupNewMembers(bot, message)
+			handleGroupLeftChatMember(bot, message)
+			handleGroupText(bot, message)
 		} else if message.Chat.IsPrivate() {
 			handlePrivateText(bot, message)
 		}
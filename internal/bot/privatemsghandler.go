@@ -4,16 +4,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/go-redis/redis/v8"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"telegram-dice-bot/internal/common"
+	"telegram-dice-bot/internal/decimal"
 	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/kv"
 	"telegram-dice-bot/internal/model"
+	"telegram-dice-bot/internal/schedule"
+	"telegram-dice-bot/internal/utils"
+	"time"
 )
 
 var whiteList = os.Getenv(WhiteList)
@@ -62,38 +67,79 @@ func handlePrivateText(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 
 	// 检查当前botChatStatus
 	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, userId)
-	result := redisDB.Get(redisDB.Context(), redisKey)
-	if errors.Is(result.Err(), redis.Nil) || result == nil {
+	botPrivateChatCacheString, err := kvStore.Get(redisKey)
+	if errors.Is(err, kv.ErrNil) {
 		//logrus.WithFields(logrus.Fields{
 		//	"redisKey": redisKey,
 		//}).Info("当前机器人暂无对话状态")
 		return
-	} else if result.Err() != nil {
+	} else if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"redisKey": redisKey,
-			"err":      result.Err(),
+			"err":      err,
 		}).Error("获取机器人对话状态缓存异常")
 		return
 	} else {
 		var botPrivateChatCache common.BotPrivateChatCache
-		botPrivateChatCacheString, _ := result.Result()
 		err := json.Unmarshal([]byte(botPrivateChatCacheString), &botPrivateChatCache)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"botPrivateChatCacheString": botPrivateChatCacheString,
-				"err":                       result.Err(),
+				"err":                       err,
 			}).Error("BotPrivateChatCache 解析异常")
 			return
 		}
 		if enums.WaitGameDrawCycle.Value == botPrivateChatCache.ChatStatus {
 			// 开奖周期设置
 			updateGameDrawCycle(bot, message, &botPrivateChatCache)
+		} else if enums.WaitBetCloseSeconds.Value == botPrivateChatCache.ChatStatus {
+			// 开奖前封盘时间设置
+			updateBetCloseSeconds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitDrawSchedule.Value == botPrivateChatCache.ChatStatus {
+			// 开奖计划设置
+			updateDrawSchedule(bot, message, &botPrivateChatCache)
 		} else if enums.WaitQuickThereSimpleOdds.Value == botPrivateChatCache.ChatStatus {
 			// 快三简易倍率设置
 			updateQuickThereSimpleOdds(bot, message, &botPrivateChatCache)
 		} else if enums.WaitQuickThereTripletOdds.Value == botPrivateChatCache.ChatStatus {
 			// 快三豹子倍率设置
 			updateQuickThereTripletOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitQuickThereComboOdds.Value == botPrivateChatCache.ChatStatus {
+			// 快三组合倍率设置
+			updateQuickThereComboOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitQuickThereSumOdds.Value == botPrivateChatCache.ChatStatus {
+			// 快三和值倍率设置
+			updateQuickThereSumOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitQuickThereSpecificTripletOdds.Value == botPrivateChatCache.ChatStatus {
+			// 快三指定豹子倍率设置
+			updateQuickThereSpecificTripletOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitQuickTherePairOdds.Value == botPrivateChatCache.ChatStatus {
+			// 快三对子倍率设置
+			updateQuickTherePairOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitQuickThereSpecificPairOdds.Value == botPrivateChatCache.ChatStatus {
+			// 快三指定对子倍率设置
+			updateQuickThereSpecificPairOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitQuickThereNumberOdds.Value == botPrivateChatCache.ChatStatus {
+			// 快三单号倍率设置
+			updateQuickThereNumberOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitQuickTherePoolRakeRate.Value == botPrivateChatCache.ChatStatus {
+			// 快三奖池抽成比例设置
+			updateQuickTherePoolRakeRate(bot, message, &botPrivateChatCache)
+		} else if enums.WaitGuessPointPointOdds.Value == botPrivateChatCache.ChatStatus {
+			// 猜点数点数倍率设置
+			updateGuessPointPointOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitGuessPointSimpleOdds.Value == botPrivateChatCache.ChatStatus {
+			// 猜点数简易倍率设置
+			updateGuessPointSimpleOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitEmojiGameOdds.Value == botPrivateChatCache.ChatStatus {
+			// 表情骰子玩法倍率设置
+			updateEmojiGameOdds(bot, message, &botPrivateChatCache)
+		} else if enums.WaitHighestRollEntryFee.Value == botPrivateChatCache.ChatStatus {
+			// 比大小参与费用设置
+			updateHighestRollEntryFee(bot, message, &botPrivateChatCache)
+		} else if enums.WaitDuelFeeRate.Value == botPrivateChatCache.ChatStatus {
+			// 对决抽成比例设置
+			updateDuelFeeRate(bot, message, &botPrivateChatCache)
 		} else if enums.WaitQueryUser.Value == botPrivateChatCache.ChatStatus {
 			// 查询用户信息
 			queryUser(bot, message, &botPrivateChatCache)
@@ -130,14 +176,14 @@ func transferBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivate
 	// 分割字符串
 	chatGroupUserId := text[:index]
 	updateBalanceStr := text[index+1:]
-	updateBalance, err := strconv.ParseFloat(updateBalanceStr, 64)
+	updateBalance, err := decimal.Parse(updateBalanceStr)
 	if err != nil {
 		logrus.WithField("updateBalanceStr", updateBalanceStr).Error("updateBalance转int异常")
 		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("积分存在非法字符:%s", updateBalanceStr))
 		_, err = sendMessage(bot, &sendMsg)
 		blockedOrKicked(err, chatId)
 		return
-	} else if updateBalance <= 0 || updateBalance > 9999999999 {
+	} else if updateBalance <= 0 || updateBalance > decimal.NewFromInt(9999999999) {
 		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("积分不合法,可转让积分范围[0-9999999999]"))
 		_, err = sendMessage(bot, &sendMsg)
 		blockedOrKicked(err, chatId)
@@ -149,7 +195,7 @@ func transferBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivate
 		Id:          chatGroupUserId,
 		ChatGroupId: botPrivateChatCache.ChatGroupId,
 	}
-	groupUser, err := chatGroupUser.QueryByIdAndChatGroupId(db)
+	groupUser, err := store.ChatGroupUsers().QueryByIdAndChatGroupId(chatGroupUser.Id, chatGroupUser.ChatGroupId)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"chatGroupUserId": chatGroupUserId,
@@ -166,7 +212,7 @@ func transferBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivate
 	chatGroup := &model.ChatGroup{
 		Id: groupUser.ChatGroupId,
 	}
-	group, err := model.QueryChatGroupById(db, chatGroup.Id)
+	group, err := store.ChatGroups().QueryById(chatGroup.Id)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"ChatGroupId": chatGroup.Id,
@@ -175,18 +221,12 @@ func transferBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivate
 		return
 	}
 
-	// 获取被转让用户对应的互斥锁
-	userLockKey := fmt.Sprintf(ChatGroupUserLockKey, group.TgChatGroupId, groupUser.TgUserId)
-	userLock := getUserLock(userLockKey)
-	userLock.Lock()
-	defer userLock.Unlock()
-
 	// 查询发起转让用户信息
 	sendChatGroupUser := &model.ChatGroupUser{
 		TgUserId:    fromUser.ID,
 		ChatGroupId: chatGroup.Id,
 	}
-	sendGroupUser, err := sendChatGroupUser.QueryByTgUserIdAndChatGroupId(db)
+	sendGroupUser, err := store.ChatGroupUsers().QueryByTgUserIdAndChatGroupId(sendChatGroupUser.TgUserId, sendChatGroupUser.ChatGroupId)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"TgUserId":    fromUser.ID,
@@ -205,33 +245,91 @@ func transferBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivate
 		return
 	}
 
-	// 获取发起转让用户对应的互斥锁
-	sendUserLockKey := fmt.Sprintf(ChatGroupUserLockKey, chatGroup.TgChatGroupId, sendGroupUser.TgUserId)
-	sendUserLock := getUserLock(sendUserLockKey)
-	sendUserLock.Lock()
-	defer sendUserLock.Unlock()
+	// 按用户ID顺序获取双方的互斥锁 与下注、结算的加锁顺序一致 避免互相转让时死锁
+	lockUsers := []*model.ChatGroupUser{groupUser, sendGroupUser}
+	sort.Slice(lockUsers, func(i, j int) bool {
+		return lockUsers[i].Id < lockUsers[j].Id
+	})
+	for _, lockUser := range lockUsers {
+		userLockKey := fmt.Sprintf(ChatGroupUserLockKey, group.TgChatGroupId, lockUser.TgUserId)
+		userLock := getUserLock(userLockKey)
+		userLock.Lock()
+		defer userLock.Unlock()
+	}
 
-	tx := db.Begin()
+	tx := store.Begin()
+	defer tx.Rollback()
 
 	// 重新查询用户信息
-	groupUser, _ = groupUser.QueryById(tx)
-	sendGroupUser, _ = sendGroupUser.QueryById(tx)
+	groupUser, err = tx.ChatGroupUsers().QueryById(groupUser.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupUserId": chatGroupUserId,
+			"err":             err,
+		}).Error("重新查询被转让用户信息异常")
+		return
+	}
+	sendGroupUser, err = tx.ChatGroupUsers().QueryById(sendGroupUser.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupUserId": sendGroupUser.Id,
+			"err":             err,
+		}).Error("重新查询发起转让用户信息异常")
+		return
+	}
 
 	// 根据运算符执行特定逻辑
 	switch operator {
 	case "+":
 		if sendGroupUser.Balance < updateBalance {
+			tx.Rollback()
 			sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("积分余额不足,您的积分余额为%.2f。", sendGroupUser.Balance))
 		} else {
-			groupUser.Balance += updateBalance
-			tx.Save(&groupUser)
-			sendGroupUser.Balance -= updateBalance
-			tx.Save(&sendGroupUser)
+			// 按增量先扣除发起方再增加被转让方积分
+			var deducted bool
+			deducted, err = sendGroupUser.DeductBalanceById(tx.DB(), updateBalance)
+			if err != nil || !deducted {
+				logrus.WithFields(logrus.Fields{
+					"chatGroupUserId": sendGroupUser.Id,
+					"deducted":        deducted,
+					"err":             err,
+				}).Error("扣除发起转让用户积分异常")
+				return
+			}
+			err = groupUser.AddBalanceById(tx.DB(), updateBalance)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"chatGroupUserId": groupUser.Id,
+					"err":             err,
+				}).Error("更新被转让用户积分异常")
+				return
+			}
+			groupUser, err = tx.ChatGroupUsers().QueryById(groupUser.Id)
+			if err == nil {
+				sendGroupUser, err = tx.ChatGroupUsers().QueryById(sendGroupUser.Id)
+			}
+			if err != nil {
+				logrus.WithField("err", err).Error("查询转让双方用户信息异常")
+				return
+			}
+			// 转出与转入两条流水使用同一转让ID关联
+			var transferId string
+			transferId, err = utils.NextID()
+			if err == nil {
+				err = recordBalanceChange(tx.DB(), sendGroupUser, -updateBalance, enums.LedgerTransferOut, transferId)
+			}
+			if err == nil {
+				err = recordBalanceChange(tx.DB(), groupUser, updateBalance, enums.LedgerTransferIn, transferId)
+			}
+			if err != nil {
+				logrus.WithField("err", err).Error("记录积分流水异常")
+				tx.Rollback()
+				return
+			}
 			sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("转让成功!【%s】中的用户【@%s】增加%.2f积分,您的积分余额为%.2f。", group.TgChatGroupTitle, groupUser.Username, updateBalance, sendGroupUser.Balance))
 			// 提交事务
-			if err := tx.Commit().Error; err != nil {
-				// 提交事务时出现异常，回滚事务
-				tx.Rollback()
+			if err = tx.Commit(); err != nil {
+				logrus.WithField("err", err).Error("转让积分提交事务异常")
 				return
 			}
 		}
@@ -240,7 +338,7 @@ func transferBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivate
 	_, err = sendMessage(bot, &sendMsg)
 	// 删除bot与当前对话人的cache
 	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, fromUser.ID)
-	redisDB.Del(redisDB.Context(), redisKey)
+	kvStore.Del(redisKey)
 	blockedOrKicked(err, chatId)
 
 	// 发送被转让用户的提示消息
@@ -250,7 +348,7 @@ func transferBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivate
 	return
 }
 
-func updateQuickThereTripletOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+func updateQuickThereSumOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
 	text := message.Text
 	tgUserId := message.From.ID
 	chatId := message.Chat.ID
@@ -266,10 +364,95 @@ func updateQuickThereTripletOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message
 		return
 	}
 
-	// 将字符串转换为float64
-	tripletOdds, err := strconv.ParseFloat(text, 64)
+	quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), botPrivateChatCache.ChatGroupId)
 	if err != nil {
-		fmt.Println(err)
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"err":         err,
+		}).Error("查询群的快三配置异常")
+		return
+	}
+
+	sumOdds := quickThereSumOdds(quickThereConfig)
+
+	// 解析 [和值]=[倍率] 例子: 3=240 10=9
+	for _, item := range strings.Fields(strings.ReplaceAll(text, ",", " ")) {
+		totalStr, oddsStr, found := strings.Cut(item, "=")
+		total, totalErr := strconv.Atoi(totalStr)
+		odds, oddsErr := decimal.Parse(oddsStr)
+		if !found || totalErr != nil || oddsErr != nil || total < 3 || total > 18 || odds <= 0 {
+			sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("格式不合法:%s\n和值范围[3-18],倍率需大于0 例子: 3=240 10=9", item))
+			sendMsg.ReplyToMessageID = messageId
+			_, err = sendMessage(bot, &sendMsg)
+			blockedOrKicked(err, chatId)
+			return
+		}
+		sumOdds[total] = odds
+	}
+
+	sumOddsBytes, err := json.Marshal(sumOdds)
+	if err != nil {
+		logrus.WithField("err", err).Error("快三和值倍率序列化异常")
+		return
+	}
+
+	quickThereConfig = &model.QuickThereConfig{
+		ChatGroupId: botPrivateChatCache.ChatGroupId,
+		SumOdds:     string(sumOddsBytes),
+	}
+
+	err = quickThereConfig.UpdateSumOddsByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"SumOdds":     quickThereConfig.SumOdds,
+		}).Error("设置快三和值倍率异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【经典快三】和值倍率已设置为:\n%s", formatQuickThereSumOdds(sumOdds)))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+// parseOddsText 解析管理员输入的倍率 格式错误或不大于0时回复提示并返回 false
+func parseOddsText(bot *tgbotapi.BotAPI, message *tgbotapi.Message) (decimal.Decimal, bool) {
+	odds, err := decimal.Parse(message.Text)
+	if err == nil && odds > 0 {
+		return odds, true
+	}
+	sendMsg := tgbotapi.NewMessage(message.Chat.ID, "倍率格式不合法,需大于0且最多两位小数 例子: 1.95")
+	sendMsg.ReplyToMessageID = message.MessageID
+	_, err = sendMessage(bot, &sendMsg)
+	blockedOrKicked(err, message.Chat.ID)
+	return 0, false
+}
+
+func updateQuickThereTripletOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	tripletOdds, ok := parseOddsText(bot, message)
+	if !ok {
 		return
 	}
 
@@ -278,7 +461,7 @@ func updateQuickThereTripletOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message
 		TripletOdds: tripletOdds,
 	}
 
-	err = quickThereConfig.UpdateTripletOddsByChatGroupId(db)
+	err = quickThereConfig.UpdateTripletOddsByChatGroupId(store.DB())
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"ChatGroupId": botPrivateChatCache.ChatGroupId,
@@ -297,11 +480,10 @@ func updateQuickThereTripletOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message
 	}
 	// 删除bot与当前对话人的cache
 	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
-	redisDB.Del(redisDB.Context(), redisKey)
+	kvStore.Del(redisKey)
 }
 
 func updateQuickThereSimpleOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
-	text := message.Text
 	tgUserId := message.From.ID
 	chatId := message.Chat.ID
 	messageId := message.MessageID
@@ -316,10 +498,8 @@ func updateQuickThereSimpleOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message,
 		return
 	}
 
-	// 将字符串转换为float64
-	simpleOdds, err := strconv.ParseFloat(text, 64)
-	if err != nil {
-		fmt.Println(err)
+	simpleOdds, ok := parseOddsText(bot, message)
+	if !ok {
 		return
 	}
 
@@ -328,7 +508,7 @@ func updateQuickThereSimpleOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message,
 		SimpleOdds:  simpleOdds,
 	}
 
-	err = quickThereConfig.UpdateSimpleOddsByChatGroupId(db)
+	err = quickThereConfig.UpdateSimpleOddsByChatGroupId(store.DB())
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"ChatGroupId": botPrivateChatCache.ChatGroupId,
@@ -347,13 +527,13 @@ func updateQuickThereSimpleOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message,
 	}
 	// 删除bot与当前对话人的cache
 	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
-	redisDB.Del(redisDB.Context(), redisKey)
+	kvStore.Del(redisKey)
 }
 
-func updateUserBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+func updateQuickThereComboOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
 	tgUserId := message.From.ID
-	text := message.Text
 	chatId := message.Chat.ID
+	messageId := message.MessageID
 
 	// 校验当前对话人是否为该群管理员
 	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
@@ -365,130 +545,86 @@ func updateUserBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPriva
 		return
 	}
 
-	var operator string
-	var index int
-
-	// 检查字符串中的运算符
-	if strings.Contains(text, "+") {
-		operator = "+"
-		index = strings.Index(text, "+")
-	} else if strings.Contains(text, "-") {
-		operator = "-"
-		index = strings.Index(text, "-")
-	} else if strings.Contains(text, "=") {
-		operator = "="
-		index = strings.Index(text, "=")
-	} else {
-		logrus.WithFields(logrus.Fields{
-			"text": text,
-		}).Warn("未知的运算符")
+	comboOdds, ok := parseOddsText(bot, message)
+	if !ok {
 		return
 	}
 
-	var sendMsg tgbotapi.MessageConfig
+	quickThereConfig := &model.QuickThereConfig{
+		ChatGroupId: botPrivateChatCache.ChatGroupId,
+		ComboOdds:   comboOdds,
+	}
 
-	// 分割字符串
-	chatGroupUserId := text[:index]
-	updateBalanceStr := text[index+1:]
-	updateBalance, err := strconv.ParseFloat(updateBalanceStr, 64)
+	err = quickThereConfig.UpdateComboOddsByChatGroupId(store.DB())
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"updateBalanceStr": updateBalanceStr,
-			"err":              err,
-		}).Error("updateBalance转int异常")
-		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("积分存在非法字符:%s", updateBalanceStr))
-		_, err = sendMessage(bot, &sendMsg)
-		blockedOrKicked(err, chatId)
-		return
-	} else if updateBalance <= 0 || updateBalance > 9999999999 {
-		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("积分不合法,可调整积分范围[0-9999999999]"))
-		_, err = sendMessage(bot, &sendMsg)
-		blockedOrKicked(err, chatId)
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"ComboOdds":   comboOdds,
+		}).Error("设置快三组合倍率异常")
 		return
 	}
 
-	// 查询用户信息
-	chatGroupUser := &model.ChatGroupUser{
-		Id:          chatGroupUserId,
-		ChatGroupId: botPrivateChatCache.ChatGroupId,
-	}
-	groupUser, err := chatGroupUser.QueryByIdAndChatGroupId(db)
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【经典快三】组合倍率已设置为%.2f倍!", comboOdds))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"chatGroupUserId": chatGroupUserId,
-			"ChatGroupId":     botPrivateChatCache.ChatGroupId,
-			"err":             err,
-		}).Warn("查询用户信息异常")
-		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("当前群组内未查询到该用户,用户Id:%s", chatGroupUserId))
-		_, err = sendMessage(bot, &sendMsg)
 		blockedOrKicked(err, chatId)
 		return
 	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
 
-	// 查询用户群信息
-	chatGroup := &model.ChatGroup{
-		Id: groupUser.ChatGroupId,
-	}
-	group, err := model.QueryChatGroupById(db, chatGroup.Id)
-	if err != nil {
+func updateQuickThereSpecificTripletOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logrus.WithFields(logrus.Fields{
-			"ChatGroupId": groupUser.ChatGroupId,
-			"err":         err,
-		}).Error("群配置信息查询异常")
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
 		return
 	}
 
-	// 获取用户对应的互斥锁
-	userLockKey := fmt.Sprintf(ChatGroupUserLockKey, group.TgChatGroupId, groupUser.TgUserId)
-	userLock := getUserLock(userLockKey)
-	userLock.Lock()
-	defer userLock.Unlock()
-
-	// 重新查询用户信息
-	groupUser, _ = chatGroupUser.QueryById(db)
-	var sendNotifyMsg tgbotapi.MessageConfig
+	specificTripletOdds, ok := parseOddsText(bot, message)
+	if !ok {
+		return
+	}
 
-	// 根据运算符执行特定逻辑
-	switch operator {
-	case "+":
-		groupUser.Balance += updateBalance
-		db.Save(&groupUser)
-		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("已为【%s】中的用户【@%s】增加%.2f积分,积分余额为%.2f。", group.TgChatGroupTitle, groupUser.Username, updateBalance, groupUser.Balance))
-		sendNotifyMsg = tgbotapi.NewMessage(groupUser.TgUserId, fmt.Sprintf("【%s】管理员为您增加了%.2f积分,您的积分余额为%.2f。", group.TgChatGroupTitle, updateBalance, groupUser.Balance))
-	case "-":
-		if groupUser.Balance < updateBalance {
-			sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("【%s】中的用户【@%s】积分余额为%.2f,小于您想扣除的积分，请留点积分吧。", group.TgChatGroupTitle, groupUser.Username, groupUser.Balance))
-			_, err = sendMessage(bot, &sendMsg)
-			blockedOrKicked(err, chatId)
-			return
-		} else {
-			groupUser.Balance -= updateBalance
-			db.Save(&groupUser)
-			sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("已为【%s】中的用户【@%s】扣除%.2f积分,积分余额为%.2f。", group.TgChatGroupTitle, groupUser.Username, updateBalance, groupUser.Balance))
-			sendNotifyMsg = tgbotapi.NewMessage(groupUser.TgUserId, fmt.Sprintf("【%s】管理员扣除了您%.2f积分,您的积分余额为%.2f。", group.TgChatGroupTitle, updateBalance, groupUser.Balance))
-		}
-	case "=":
-		groupUser.Balance = updateBalance
-		db.Save(&groupUser)
-		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("已将【%s】中的用户【@%s】积分修改为%.2f。", group.TgChatGroupTitle, groupUser.Username, groupUser.Balance))
-		sendNotifyMsg = tgbotapi.NewMessage(groupUser.TgUserId, fmt.Sprintf("【%s】管理员将您的积分修改为%.2f。", group.TgChatGroupTitle, groupUser.Balance))
+	quickThereConfig := &model.QuickThereConfig{
+		ChatGroupId:         botPrivateChatCache.ChatGroupId,
+		SpecificTripletOdds: specificTripletOdds,
 	}
 
-	_, err = sendMessage(bot, &sendMsg)
-	blockedOrKicked(err, chatId)
+	err = quickThereConfig.UpdateSpecificTripletOddsByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId":         botPrivateChatCache.ChatGroupId,
+			"SpecificTripletOdds": specificTripletOdds,
+		}).Error("设置快三指定豹子倍率异常")
+		return
+	}
 
-	_, err = sendMessage(bot, &sendNotifyMsg)
-	blockedOrKicked(err, groupUser.TgUserId)
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【经典快三】指定豹子倍率已设置为%.2f倍!", specificTripletOdds))
+	sendMsg.ReplyToMessageID = messageId
 
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
 	// 删除bot与当前对话人的cache
 	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
-	redisDB.Del(redisDB.Context(), redisKey)
-	return
-
+	kvStore.Del(redisKey)
 }
 
-func queryUser(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
-	text := message.Text
+func updateQuickTherePairOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
 	tgUserId := message.From.ID
 	chatId := message.Chat.ID
 	messageId := message.MessageID
@@ -503,40 +639,39 @@ func queryUser(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCa
 		return
 	}
 
-	chatGroupUser := &model.ChatGroupUser{
-		ChatGroupId: botPrivateChatCache.ChatGroupId,
-		Username:    text[1:],
+	pairOdds, ok := parseOddsText(bot, message)
+	if !ok {
+		return
 	}
 
-	groupUser, err := chatGroupUser.QueryByUsernameAndChatGroupId(db)
+	quickThereConfig := &model.QuickThereConfig{
+		ChatGroupId: botPrivateChatCache.ChatGroupId,
+		PairOdds:    pairOdds,
+	}
 
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		// 没有找到记录
-		msgConfig := tgbotapi.NewMessage(chatId, "未查询到该用户，未注册或用户名已更改!")
-		msgConfig.ReplyToMessageID = messageId
-		_, err := sendMessage(bot, &msgConfig)
-		blockedOrKicked(err, chatId)
-		return
-	} else if err != nil {
+	err = quickThereConfig.UpdatePairOddsByChatGroupId(store.DB())
+	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"ChatGroupId": chatGroupUser.ChatGroupId,
-			"Username":    chatGroupUser.Username,
-		}).Error("群id+用户名查找群成员异常")
-	} else {
-		// 查询到记录
-		msgConfig := tgbotapi.NewMessage(chatId, fmt.Sprintf("用户ID:%v\n用户名称:%s\n积分余额:%.2f", groupUser.Id, groupUser.Username, groupUser.Balance))
-		msgConfig.ReplyToMessageID = messageId
-		_, err := sendMessage(bot, &msgConfig)
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"PairOdds":    pairOdds,
+		}).Error("设置快三对子倍率异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【经典快三】对子倍率已设置为%.2f倍!", pairOdds))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
 		blockedOrKicked(err, chatId)
-		// 删除bot与当前对话人的cache
-		redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
-		redisDB.Del(redisDB.Context(), redisKey)
 		return
 	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
 }
 
-func updateGameDrawCycle(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
-	text := message.Text
+func updateQuickThereSpecificPairOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
 	tgUserId := message.From.ID
 	chatId := message.Chat.ID
 	messageId := message.MessageID
@@ -551,25 +686,739 @@ func updateGameDrawCycle(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPri
 		return
 	}
 
-	drawCycle, err := strconv.Atoi(text)
-	if err != nil {
-		logrus.WithField("err", err).Error("drawCycle转int异常")
+	specificPairOdds, ok := parseOddsText(bot, message)
+	if !ok {
 		return
 	}
 
-	if drawCycle <= 0 || drawCycle > 60 {
-		sendMsg := tgbotapi.NewMessage(chatId, "开奖周期必须大于0分钟小于60分钟哦!")
-		_, err = sendMessage(bot, &sendMsg)
-		blockedOrKicked(err, chatId)
-		return
+	quickThereConfig := &model.QuickThereConfig{
+		ChatGroupId:      botPrivateChatCache.ChatGroupId,
+		SpecificPairOdds: specificPairOdds,
 	}
 
-	chatGroup := &model.ChatGroup{
+	err = quickThereConfig.UpdateSpecificPairOddsByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId":      botPrivateChatCache.ChatGroupId,
+			"SpecificPairOdds": specificPairOdds,
+		}).Error("设置快三指定对子倍率异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【经典快三】指定对子倍率已设置为%.2f倍!", specificPairOdds))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+func updateQuickThereNumberOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	text := message.Text
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), botPrivateChatCache.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"err":         err,
+		}).Error("查询群的快三配置异常")
+		return
+	}
+
+	numberOdds := quickThereNumberOdds(quickThereConfig)
+
+	// 解析 [出现次数]=[倍率] 例子: 1=2 2=3 3=4
+	for _, item := range strings.Fields(strings.ReplaceAll(text, ",", " ")) {
+		countStr, oddsStr, found := strings.Cut(item, "=")
+		count, countErr := strconv.Atoi(countStr)
+		odds, oddsErr := decimal.Parse(oddsStr)
+		if !found || countErr != nil || oddsErr != nil || count < 1 || count > 3 || odds <= 0 {
+			sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("格式不合法:%s\n出现次数范围[1-3],倍率需大于0 例子: 1=2 2=3 3=4", item))
+			sendMsg.ReplyToMessageID = messageId
+			_, err = sendMessage(bot, &sendMsg)
+			blockedOrKicked(err, chatId)
+			return
+		}
+		numberOdds[count] = odds
+	}
+
+	numberOddsBytes, err := json.Marshal(numberOdds)
+	if err != nil {
+		logrus.WithField("err", err).Error("快三单号倍率序列化异常")
+		return
+	}
+
+	quickThereConfig = &model.QuickThereConfig{
+		ChatGroupId: botPrivateChatCache.ChatGroupId,
+		NumberOdds:  string(numberOddsBytes),
+	}
+
+	err = quickThereConfig.UpdateNumberOddsByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"NumberOdds":  quickThereConfig.NumberOdds,
+		}).Error("设置快三单号倍率异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【经典快三】单号倍率已设置为:\n%s", formatQuickThereNumberOdds(numberOdds)))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+func updateQuickTherePoolRakeRate(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	text := message.Text
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	rakeRate, err := decimal.Parse(text)
+	if err != nil {
+		logrus.WithField("err", err).Error("rakeRate转decimal异常")
+		return
+	}
+
+	if rakeRate < 0 || rakeRate >= decimal.NewFromInt(100) {
+		sendMsg := tgbotapi.NewMessage(chatId, "奖池抽成比例必须大于等于0小于100哦!")
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	}
+
+	quickThereConfig := &model.QuickThereConfig{
+		ChatGroupId:  botPrivateChatCache.ChatGroupId,
+		PoolRakeRate: rakeRate,
+	}
+
+	err = quickThereConfig.UpdatePoolRakeRateByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId":  botPrivateChatCache.ChatGroupId,
+			"PoolRakeRate": rakeRate,
+		}).Error("设置快三奖池抽成比例异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【经典快三】奖池抽成比例已设置为%v%%,下一期起生效!", rakeRate))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+func updateGuessPointPointOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	pointOdds, ok := parseOddsText(bot, message)
+	if !ok {
+		return
+	}
+
+	guessPointConfig := &model.GuessPointConfig{
+		ChatGroupId: botPrivateChatCache.ChatGroupId,
+		PointOdds:   pointOdds,
+	}
+
+	err = guessPointConfig.UpdatePointOddsByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"PointOdds":   pointOdds,
+		}).Error("设置猜点数点数倍率异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【猜点数】点数倍率已设置为%.2f倍!", pointOdds))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+func updateGuessPointSimpleOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	simpleOdds, ok := parseOddsText(bot, message)
+	if !ok {
+		return
+	}
+
+	guessPointConfig := &model.GuessPointConfig{
+		ChatGroupId: botPrivateChatCache.ChatGroupId,
+		SimpleOdds:  simpleOdds,
+	}
+
+	err = guessPointConfig.UpdateSimpleOddsByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"SimpleOdds":  simpleOdds,
+		}).Error("设置猜点数简易倍率异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【猜点数】简易倍率已设置为%.2f倍!", simpleOdds))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+func updateHighestRollEntryFee(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	text := message.Text
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	entryFee, err := decimal.Parse(text)
+	if err != nil {
+		logrus.WithField("err", err).Error("entryFee转decimal异常")
+		return
+	}
+
+	if entryFee <= 0 {
+		sendMsg := tgbotapi.NewMessage(chatId, "参与费用必须大于0哦!")
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	}
+
+	highestRollConfig := &model.HighestRollConfig{
+		ChatGroupId: botPrivateChatCache.ChatGroupId,
+		EntryFee:    entryFee,
+	}
+
+	err = highestRollConfig.UpdateEntryFeeByChatGroupId(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"EntryFee":    entryFee,
+		}).Error("设置比大小参与费用异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【比大小】每期参与费用已设置为%.2f积分!", entryFee))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+func updateEmojiGameOdds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	text := message.Text
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(botPrivateChatCache.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": botPrivateChatCache.ChatGroupId,
+			"err":         err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+
+	gameplay, b := getGameplay(chatGroup.GameplayType)
+	emojiGame, isEmojiGame := gameplay.(*emojiGameplay)
+	if !b || !isEmojiGame {
+		logrus.WithField("GameplayType", chatGroup.GameplayType).Error("群配置玩法非表情骰子玩法")
+		return
+	}
+
+	emojiGameConfig, err := model.QueryEmojiGameConfigByChatGroupIdAndGameplayType(store.DB(), chatGroup.Id, chatGroup.GameplayType)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId":  chatGroup.Id,
+			"GameplayType": chatGroup.GameplayType,
+			"err":          err,
+		}).Error("查询群的玩法配置异常")
+		return
+	}
+
+	odds := emojiGame.odds(emojiGameConfig)
+
+	// 解析 [竞猜类型]=[倍率] 例子: 进球=1.5
+	for _, item := range strings.Fields(strings.ReplaceAll(text, ",", " ")) {
+		betTypeName, oddsStr, found := strings.Cut(item, "=")
+		betType, betTypeFound := emojiGame.betTypeForName(betTypeName)
+		betOdds, oddsErr := decimal.Parse(oddsStr)
+		if !found || !betTypeFound || oddsErr != nil || betOdds <= 0 {
+			sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("格式不合法:%s\n竞猜类型需为该玩法支持的类型,倍率需大于0\n当前倍率: %s", item, emojiGame.formatOdds(odds)))
+			sendMsg.ReplyToMessageID = messageId
+			_, err = sendMessage(bot, &sendMsg)
+			blockedOrKicked(err, chatId)
+			return
+		}
+		odds[betType.Value] = betOdds
+	}
+
+	oddsBytes, err := json.Marshal(odds)
+	if err != nil {
+		logrus.WithField("err", err).Error("玩法倍率序列化异常")
+		return
+	}
+
+	emojiGameConfig = &model.EmojiGameConfig{
+		ChatGroupId:  chatGroup.Id,
+		GameplayType: chatGroup.GameplayType,
+		Odds:         string(oddsBytes),
+	}
+
+	err = emojiGameConfig.UpdateOddsByChatGroupIdAndGameplayType(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId":  chatGroup.Id,
+			"GameplayType": chatGroup.GameplayType,
+			"Odds":         emojiGameConfig.Odds,
+		}).Error("设置玩法倍率异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!\n【%s】倍率已设置为:\n%s", emojiGame.gameplayType.Name, emojiGame.formatOdds(odds)))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+func updateUserBalance(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	tgUserId := message.From.ID
+	text := message.Text
+	chatId := message.Chat.ID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	var operator string
+	var index int
+
+	// 检查字符串中的运算符
+	if strings.Contains(text, "+") {
+		operator = "+"
+		index = strings.Index(text, "+")
+	} else if strings.Contains(text, "-") {
+		operator = "-"
+		index = strings.Index(text, "-")
+	} else if strings.Contains(text, "=") {
+		operator = "="
+		index = strings.Index(text, "=")
+	} else {
+		logrus.WithFields(logrus.Fields{
+			"text": text,
+		}).Warn("未知的运算符")
+		return
+	}
+
+	var sendMsg tgbotapi.MessageConfig
+
+	// 分割字符串
+	chatGroupUserId := text[:index]
+	updateBalanceStr := text[index+1:]
+	updateBalance, err := decimal.Parse(updateBalanceStr)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"updateBalanceStr": updateBalanceStr,
+			"err":              err,
+		}).Error("updateBalance转int异常")
+		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("积分存在非法字符:%s", updateBalanceStr))
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	} else if updateBalance <= 0 || updateBalance > decimal.NewFromInt(9999999999) {
+		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("积分不合法,可调整积分范围[0-9999999999]"))
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	}
+
+	// 查询用户信息
+	chatGroupUser := &model.ChatGroupUser{
+		Id:          chatGroupUserId,
+		ChatGroupId: botPrivateChatCache.ChatGroupId,
+	}
+	groupUser, err := store.ChatGroupUsers().QueryByIdAndChatGroupId(chatGroupUser.Id, chatGroupUser.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupUserId": chatGroupUserId,
+			"ChatGroupId":     botPrivateChatCache.ChatGroupId,
+			"err":             err,
+		}).Warn("查询用户信息异常")
+		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("当前群组内未查询到该用户,用户Id:%s", chatGroupUserId))
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	}
+
+	// 查询用户群信息
+	chatGroup := &model.ChatGroup{
+		Id: groupUser.ChatGroupId,
+	}
+	group, err := store.ChatGroups().QueryById(chatGroup.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": groupUser.ChatGroupId,
+			"err":         err,
+		}).Error("群配置信息查询异常")
+		return
+	}
+
+	// 获取用户对应的互斥锁
+	userLockKey := fmt.Sprintf(ChatGroupUserLockKey, group.TgChatGroupId, groupUser.TgUserId)
+	userLock := getUserLock(userLockKey)
+	userLock.Lock()
+	defer userLock.Unlock()
+
+	tx := store.Begin()
+	defer tx.Rollback()
+
+	// 重新查询用户信息
+	groupUser, err = tx.ChatGroupUsers().QueryById(chatGroupUser.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupUserId": chatGroupUserId,
+			"err":             err,
+		}).Error("重新查询用户信息异常")
+		return
+	}
+
+	// 根据运算符计算积分变动
+	var amount decimal.Decimal
+	switch operator {
+	case "+":
+		amount = updateBalance
+	case "-":
+		amount = -updateBalance
+	case "=":
+		amount = updateBalance - groupUser.Balance
+	}
+
+	// 按增量更新积分 扣除时余额不足则不扣除
+	if amount < 0 {
+		deducted, err := groupUser.DeductBalanceById(tx.DB(), -amount)
+		if err != nil {
+			logrus.WithField("err", err).Error("更新用户余额异常")
+			tx.Rollback()
+			return
+		}
+		if !deducted {
+			tx.Rollback()
+			sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("【%s】中的用户【@%s】积分余额为%.2f,小于您想扣除的积分，请留点积分吧。", group.TgChatGroupTitle, groupUser.Username, groupUser.Balance))
+			_, err = sendMessage(bot, &sendMsg)
+			blockedOrKicked(err, chatId)
+			return
+		}
+	} else {
+		err = groupUser.AddBalanceById(tx.DB(), amount)
+		if err != nil {
+			logrus.WithField("err", err).Error("更新用户余额异常")
+			tx.Rollback()
+			return
+		}
+	}
+	groupUser, err = tx.ChatGroupUsers().QueryById(chatGroupUser.Id)
+	if err != nil {
+		logrus.WithField("err", err).Error("查询用户信息异常")
+		tx.Rollback()
+		return
+	}
+
+	// 记录流水 关联ID为操作的管理员
+	err = recordBalanceChange(tx.DB(), groupUser, amount, enums.LedgerAdminAdjust, strconv.FormatInt(tgUserId, 10))
+	if err != nil {
+		logrus.WithField("err", err).Error("记录积分流水异常")
+		tx.Rollback()
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		logrus.WithField("err", err).Error("调整积分提交事务异常")
+		tx.Rollback()
+		return
+	}
+
+	var sendNotifyMsg tgbotapi.MessageConfig
+	switch operator {
+	case "+":
+		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("已为【%s】中的用户【@%s】增加%.2f积分,积分余额为%.2f。", group.TgChatGroupTitle, groupUser.Username, updateBalance, groupUser.Balance))
+		sendNotifyMsg = tgbotapi.NewMessage(groupUser.TgUserId, fmt.Sprintf("【%s】管理员为您增加了%.2f积分,您的积分余额为%.2f。", group.TgChatGroupTitle, updateBalance, groupUser.Balance))
+	case "-":
+		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("已为【%s】中的用户【@%s】扣除%.2f积分,积分余额为%.2f。", group.TgChatGroupTitle, groupUser.Username, updateBalance, groupUser.Balance))
+		sendNotifyMsg = tgbotapi.NewMessage(groupUser.TgUserId, fmt.Sprintf("【%s】管理员扣除了您%.2f积分,您的积分余额为%.2f。", group.TgChatGroupTitle, updateBalance, groupUser.Balance))
+	case "=":
+		sendMsg = tgbotapi.NewMessage(chatId, fmt.Sprintf("已将【%s】中的用户【@%s】积分修改为%.2f。", group.TgChatGroupTitle, groupUser.Username, groupUser.Balance))
+		sendNotifyMsg = tgbotapi.NewMessage(groupUser.TgUserId, fmt.Sprintf("【%s】管理员将您的积分修改为%.2f。", group.TgChatGroupTitle, groupUser.Balance))
+	}
+
+	_, err = sendMessage(bot, &sendMsg)
+	blockedOrKicked(err, chatId)
+
+	_, err = sendMessage(bot, &sendNotifyMsg)
+	blockedOrKicked(err, groupUser.TgUserId)
+
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+	return
+
+}
+
+func queryUser(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	text := message.Text
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	chatGroupUser := &model.ChatGroupUser{
+		ChatGroupId: botPrivateChatCache.ChatGroupId,
+		Username:    text[1:],
+	}
+
+	groupUser, err := store.ChatGroupUsers().QueryByUsernameAndChatGroupId(chatGroupUser.Username, chatGroupUser.ChatGroupId)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 没有找到记录
+		msgConfig := tgbotapi.NewMessage(chatId, "未查询到该用户，未注册或用户名已更改!")
+		msgConfig.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &msgConfig)
+		blockedOrKicked(err, chatId)
+		return
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": chatGroupUser.ChatGroupId,
+			"Username":    chatGroupUser.Username,
+		}).Error("群id+用户名查找群成员异常")
+	} else {
+		// 查询到记录
+		msgConfig := tgbotapi.NewMessage(chatId, fmt.Sprintf("用户ID:%v\n用户名称:%s\n积分余额:%.2f", groupUser.Id, groupUser.Username, groupUser.Balance))
+		msgConfig.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &msgConfig)
+		blockedOrKicked(err, chatId)
+		// 删除bot与当前对话人的cache
+		redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+		kvStore.Del(redisKey)
+		return
+	}
+}
+
+func updateDuelFeeRate(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	text := message.Text
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	feeRate, err := decimal.Parse(text)
+	if err != nil {
+		logrus.WithField("err", err).Error("feeRate转decimal异常")
+		return
+	}
+
+	if feeRate < 0 || feeRate >= decimal.NewFromInt(100) {
+		sendMsg := tgbotapi.NewMessage(chatId, "对决抽成比例必须大于等于0小于100哦!")
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	}
+
+	chatGroup := &model.ChatGroup{
+		Id:          botPrivateChatCache.ChatGroupId,
+		DuelFeeRate: feeRate,
+	}
+
+	err = store.ChatGroups().UpdateColumnsById(chatGroup, "duel_fee_rate")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"DuelFeeRate": feeRate,
+		}).Error("设置对决抽成比例异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!当前群组对决抽成比例为%v%%,新发起的对决生效哦!", feeRate))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+func updateGameDrawCycle(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	text := message.Text
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	drawCycle, err := strconv.Atoi(text)
+	if err != nil {
+		logrus.WithField("err", err).Error("drawCycle转int异常")
+		return
+	}
+
+	if drawCycle <= 0 || drawCycle > 60 {
+		sendMsg := tgbotapi.NewMessage(chatId, "开奖周期必须大于0分钟小于60分钟哦!")
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	}
+
+	chatGroup := &model.ChatGroup{
 		Id:            botPrivateChatCache.ChatGroupId,
 		GameDrawCycle: drawCycle,
 	}
 
-	err = chatGroup.UpdateGameDrawCycleById(db)
+	err = store.ChatGroups().UpdateColumnsById(chatGroup, "game_draw_cycle")
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"chatGroupId":   botPrivateChatCache.ChatGroupId,
@@ -588,5 +1437,174 @@ func updateGameDrawCycle(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPri
 	}
 	// 删除bot与当前对话人的cache
 	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
-	redisDB.Del(redisDB.Context(), redisKey)
+	kvStore.Del(redisKey)
+}
+
+func updateBetCloseSeconds(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	text := message.Text
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	betCloseSeconds, err := strconv.Atoi(text)
+	if err != nil {
+		logrus.WithField("err", err).Error("betCloseSeconds转int异常")
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(botPrivateChatCache.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"err":         err,
+		}).Error("群配置查询异常")
+		return
+	}
+
+	// 封盘时间需小于最短开奖间隔 否则间隔最短的一期无法下注
+	minIntervalSeconds := int(minDrawInterval(chatGroup) / time.Second)
+	if betCloseSeconds < 0 || betCloseSeconds >= minIntervalSeconds {
+		sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("封盘时间必须大于等于0秒小于最短开奖间隔(%d秒)哦!", minIntervalSeconds))
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	}
+
+	chatGroupUpdate := &model.ChatGroup{
+		Id:              botPrivateChatCache.ChatGroupId,
+		BetCloseSeconds: betCloseSeconds,
+	}
+
+	err = store.ChatGroups().UpdateColumnsById(chatGroupUpdate, "bet_close_seconds")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId":     botPrivateChatCache.ChatGroupId,
+			"BetCloseSeconds": betCloseSeconds,
+		}).Error("设置开奖前封盘时间异常")
+		return
+	}
+
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!当前群组开奖前%v秒封盘,重新开启游戏后生效哦!", betCloseSeconds))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
+}
+
+func updateDrawSchedule(bot *tgbotapi.BotAPI, message *tgbotapi.Message, botPrivateChatCache *common.BotPrivateChatCache) {
+	text := strings.TrimSpace(message.Text)
+	tgUserId := message.From.ID
+	chatId := message.Chat.ID
+	messageId := message.MessageID
+
+	// 校验当前对话人是否为该群管理员
+	err := checkGroupAdmin(botPrivateChatCache.ChatGroupId, tgUserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"tgUserId":    tgUserId,
+		}).Error("当前对话人非该群管理员")
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryById(botPrivateChatCache.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": botPrivateChatCache.ChatGroupId,
+			"err":         err,
+		}).Error("群配置查询异常")
+		return
+	}
+
+	// 解析 key=value 格式的每一行 清除时三项均置空
+	chatGroupUpdate := &model.ChatGroup{Id: chatGroup.Id}
+	if text != "清除" {
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			key, value, found := strings.Cut(line, "=")
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "cron":
+				chatGroupUpdate.DrawSchedule = value
+			case "quiet":
+				chatGroupUpdate.QuietHours = value
+			case "tz":
+				chatGroupUpdate.Timezone = value
+			default:
+				found = false
+			}
+			if !found {
+				sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("格式不合法:%s\n每行一项 例子: cron=0 12,20 * * *", line))
+				sendMsg.ReplyToMessageID = messageId
+				_, err = sendMessage(bot, &sendMsg)
+				blockedOrKicked(err, chatId)
+				return
+			}
+		}
+	}
+
+	drawSchedule, err := schedule.Parse(chatGroupUpdate.DrawSchedule, chatGroupUpdate.QuietHours, chatGroupUpdate.Timezone, drawCycle(chatGroup))
+	var drawTimes []time.Time
+	if err == nil {
+		drawTimes, err = drawSchedule.Preview(time.Now(), 5)
+	}
+	if err != nil {
+		sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("开奖计划不合法:%s", err))
+		sendMsg.ReplyToMessageID = messageId
+		_, err = sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, chatId)
+		return
+	}
+
+	err = store.ChatGroups().UpdateColumnsById(chatGroupUpdate, "draw_schedule", "quiet_hours", "timezone")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId":  chatGroup.Id,
+			"DrawSchedule": chatGroupUpdate.DrawSchedule,
+			"QuietHours":   chatGroupUpdate.QuietHours,
+			"Timezone":     chatGroupUpdate.Timezone,
+			"err":          err,
+		}).Error("设置开奖计划异常")
+		return
+	}
+
+	chatGroup.DrawSchedule = chatGroupUpdate.DrawSchedule
+	chatGroup.QuietHours = chatGroupUpdate.QuietHours
+	chatGroup.Timezone = chatGroupUpdate.Timezone
+
+	var drawTimeTexts []string
+	for _, drawTime := range drawTimes {
+		drawTimeTexts = append(drawTimeTexts, drawTime.Format("2006-01-02 15:04:05"))
+	}
+	sendMsg := tgbotapi.NewMessage(chatId, fmt.Sprintf("设置成功!当前开奖计划:\n%s\n\n未来%d次开奖时间:\n%s\n\n重新开启游戏后生效哦!",
+		formatDrawSchedule(chatGroup), len(drawTimes), strings.Join(drawTimeTexts, "\n")))
+	sendMsg.ReplyToMessageID = messageId
+
+	_, err = sendMessage(bot, &sendMsg)
+	if err != nil {
+		blockedOrKicked(err, chatId)
+		return
+	}
+	// 删除bot与当前对话人的cache
+	redisKey := fmt.Sprintf(RedisBotPrivateChatCacheKey, tgUserId)
+	kvStore.Del(redisKey)
 }
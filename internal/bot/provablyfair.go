@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strings"
+	"telegram-dice-bot/internal/model"
+)
+
+// generateServerSeed 生成32字节随机服务端种子(hex)
+func generateServerSeed() (string, error) {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(seed), nil
+}
+
+// seedCommitment 种子承诺 SHA-256(种子)
+func seedCommitment(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueServerSeed 获取该期的服务端种子 不存在时生成并随开期记录保存 开期记录需已由 openIssue 保存
+func issueServerSeed(group *model.ChatGroup, issueNumber string) (string, error) {
+	issueRecordQuery := &model.IssueRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+	}
+	issueRecord, err := issueRecordQuery.QueryByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil {
+		return "", err
+	}
+	if issueRecord.ServerSeed != "" {
+		return issueRecord.ServerSeed, nil
+	}
+
+	serverSeed, err := generateServerSeed()
+	if err != nil {
+		return "", err
+	}
+	issueRecordUpdate := &model.IssueRecord{
+		Id:             issueRecord.Id,
+		ServerSeed:     serverSeed,
+		SeedCommitment: seedCommitment(serverSeed),
+	}
+	updated, err := issueRecordUpdate.UpdateServerSeedById(store.DB())
+	if err != nil {
+		return "", err
+	} else if !updated {
+		// 并发开期时以先写入的种子为准
+		issueRecord, err = issueRecord.QueryByChatGroupIdAndIssueNumber(store.DB())
+		if err != nil {
+			return "", err
+		}
+		return issueRecord.ServerSeed, nil
+	}
+	return serverSeed, nil
+}
+
+// revealServerSeed 开奖时取出该期开期时保存的服务端种子 开期时未公布种子承诺(如切换骰子来源后)时返回空 该期无法验证
+func revealServerSeed(group *model.ChatGroup, issueNumber string) (string, error) {
+	issueRecordQuery := &model.IssueRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+	}
+	issueRecord, err := issueRecordQuery.QueryByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if err != nil || issueRecord.ServerSeed == "" {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+		}).Warn("该期开期时未公布种子承诺 无法验证")
+		return "", nil
+	}
+	return issueRecord.ServerSeed, nil
+}
+
+func handleVerifyCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	messageId := message.MessageID
+	tgChatId := message.Chat.ID
+
+	// 解析验证命令，示例命令格式：/verify 20240101120000
+	issueNumber := strings.TrimSpace(message.CommandArguments())
+	if issueNumber == "" {
+		sendMsg := tgbotapi.NewMessage(tgChatId, "命令格式错误,示例: /verify 期号")
+		sendMsg.ReplyToMessageID = messageId
+		_, err := sendMessage(bot, &sendMsg)
+		blockedOrKicked(err, tgChatId)
+		return
+	}
+
+	chatGroup, err := store.ChatGroups().QueryByTgChatId(tgChatId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"tgChatId": tgChatId,
+			"err":      err,
+		}).Error("群配置查询异常")
+		return
+	}
+
+	lotteryRecordQuery := &model.QuickThereLotteryRecord{
+		ChatGroupId: chatGroup.Id,
+		IssueNumber: issueNumber,
+	}
+	lotteryRecord, err := lotteryRecordQuery.QueryByIssueNumberAndChatGroupId(store.DB())
+	var replyText string
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		replyText = fmt.Sprintf("未查询到第%s期开奖记录!", issueNumber)
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": chatGroup.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Error("查询开奖记录异常")
+		return
+	} else if lotteryRecord.ServerSeed == "" {
+		replyText = fmt.Sprintf("第%s期使用Telegram骰子开奖,无法验证!", issueNumber)
+	} else {
+		replyText = formatVerifyResult(lotteryRecord)
+	}
+
+	sendMsg := tgbotapi.NewMessage(tgChatId, replyText)
+	sendMsg.ReplyToMessageID = messageId
+	_, err = sendMessage(bot, &sendMsg)
+	blockedOrKicked(err, tgChatId)
+}
+
+// formatVerifyResult 重新计算种子承诺与点数并与开奖记录比对
+func formatVerifyResult(lotteryRecord *model.QuickThereLotteryRecord) string {
+	commitmentOk := seedCommitment(lotteryRecord.ServerSeed) == lotteryRecord.SeedCommitment
+	diceValues, _ := newSeededDiceSource(lotteryRecord.ServerSeed, lotteryRecord.IssueNumber).Roll("🎲", 3)
+	valuesOk := diceValues[0] == lotteryRecord.ValueA && diceValues[1] == lotteryRecord.ValueB && diceValues[2] == lotteryRecord.ValueC
+
+	result := "✅验证通过"
+	if !commitmentOk || !valuesOk {
+		result = "❌验证失败"
+	}
+
+	return fmt.Sprintf("第%s期 %s\n"+
+		"种子: %s\n"+
+		"承诺: %s\n"+
+		"SHA-256(种子)与承诺一致: %v\n"+
+		"重算点数: %d %d %d\n"+
+		"开奖点数: %d %d %d\n\n"+
+		"算法: 依次取 HMAC-SHA256(key=种子, msg=期号) 的字节b, b<252时点数为b%%6+1, 否则丢弃",
+		lotteryRecord.IssueNumber, result,
+		lotteryRecord.ServerSeed,
+		lotteryRecord.SeedCommitment,
+		commitmentOk,
+		diceValues[0], diceValues[1], diceValues[2],
+		lotteryRecord.ValueA, lotteryRecord.ValueB, lotteryRecord.ValueC)
+}
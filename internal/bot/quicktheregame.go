@@ -1,193 +1,463 @@
 package bot
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"telegram-dice-bot/internal/decimal"
 	"telegram-dice-bot/internal/enums"
 	"telegram-dice-bot/internal/model"
-	"telegram-dice-bot/internal/utils"
 	"time"
 )
 
-func quickThereTask(bot *tgbotapi.BotAPI, group *model.ChatGroup, issueNumber string) (nextIssueNumber string, err error) {
-	// 执行任务前对群组校验 如果只剩1个人那必然是自己
-	chatMembersLen, err := bot.GetChatMembersCount(tgbotapi.ChatMemberCountConfig{
-		ChatConfig: tgbotapi.ChatConfig{
-			ChatID: group.TgChatGroupId,
-		},
-	})
-	if chatMembersLen == 1 {
-		logrus.WithField("GroupId", group.Id).Warn("群内只剩机器人")
-		// 更新群状态
-		group.GameplayStatus = 0
-		db.Save(group)
-		return "", errors.New("群内只剩机器人")
+type quickThereGameplay struct{}
+
+// 快三支持的下注类型(和值除外)
+var quickThereBetTypes = []enums.GameLotteryType{
+	enums.Single, enums.Double, enums.Big, enums.Small, enums.Triplet,
+	enums.BigSingle, enums.BigDouble, enums.SmallSingle, enums.SmallDouble,
+	enums.Triplet1, enums.Triplet2, enums.Triplet3, enums.Triplet4, enums.Triplet5, enums.Triplet6,
+	enums.Pair, enums.Pair1, enums.Pair2, enums.Pair3, enums.Pair4, enums.Pair5, enums.Pair6,
+	enums.Number1, enums.Number2, enums.Number3, enums.Number4, enums.Number5, enums.Number6,
+}
+
+// 快三和值默认倍率
+var defaultQuickThereSumOdds = map[int]decimal.Decimal{
+	3: decimal.NewFromInt(240), 4: decimal.NewFromInt(80), 5: decimal.NewFromInt(40), 6: decimal.NewFromInt(25), 7: decimal.NewFromInt(16), 8: decimal.NewFromInt(12), 9: decimal.NewFromInt(10), 10: decimal.NewFromInt(9),
+	11: decimal.NewFromInt(9), 12: decimal.NewFromInt(10), 13: decimal.NewFromInt(12), 14: decimal.NewFromInt(16), 15: decimal.NewFromInt(25), 16: decimal.NewFromInt(40), 17: decimal.NewFromInt(80), 18: decimal.NewFromInt(240),
+}
+
+// 快三单号默认倍率 key为该点数出现次数
+var defaultQuickThereNumberOdds = map[int]decimal.Decimal{
+	1: decimal.NewFromInt(2), 2: decimal.NewFromInt(3), 3: decimal.NewFromInt(4),
+}
+
+func init() {
+	registerGameplay(enums.QuickThere, &quickThereGameplay{})
+}
+
+func (g *quickThereGameplay) InitConfig(tx *gorm.DB, chatGroupId string) error {
+	_, err := model.QueryQuickThereConfigByChatGroupId(tx, chatGroupId)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	sumOdds, err := json.Marshal(defaultQuickThereSumOdds)
+	if err != nil {
+		return err
+	}
+	numberOdds, err := json.Marshal(defaultQuickThereNumberOdds)
+	if err != nil {
+		return err
+	}
+	quickThereConfig := &model.QuickThereConfig{
+		ChatGroupId:         chatGroupId,
+		SimpleOdds:          decimal.NewFromInt(2),
+		TripletOdds:         decimal.NewFromInt(10),
+		ComboOdds:           decimal.MustParse("3.5"),
+		SumOdds:             string(sumOdds),
+		SpecificTripletOdds: decimal.NewFromInt(180),
+		PairOdds:            decimal.NewFromInt(2),
+		SpecificPairOdds:    decimal.NewFromInt(11),
+		NumberOdds:          string(numberOdds),
+		TripleKill:          enums.TripleKillOFF.Value,
+		OddsMode:            enums.OddsModeFixed.Value,
+		CreateTime:          time.Now().Format("2006-01-02 15:04:05"),
+	}
+	return quickThereConfig.Create(tx)
+}
+
+func (g *quickThereGameplay) ParseBet(group *model.ChatGroup, text string) (*Bet, error) {
+	// 解析下注命令，示例命令格式：#单 20
+	parts := strings.Fields(text)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "#") {
+		return nil, nil
 	}
 
-	redisKey := fmt.Sprintf(RedisCurrentIssueNumberKey, group.Id)
-	// 删除当前期号和对话ID
-	err = redisDB.Del(redisDB.Context(), redisKey).Err()
+	// 获取下注类型和下注积分
+	betTypeName := parts[0][1:]
+	if !isQuickThereBetTypeName(betTypeName) {
+		return nil, nil
+	}
+
+	betAmount, err := parseBetAmount(parts[1])
 	if err != nil {
-		logrus.WithField("redisKey", redisKey).Error("删除当前期号和对话ID异常")
-		return "", err
+		return nil, err
+	}
+
+	// 映射下注类型
+	betType, b := enums.GetGameLotteryTypeForName(betTypeName)
+	if !b {
+		logrus.WithField("betType", betTypeName).Error("下注类型映射异常")
+		return nil, errors.New("该下注类型映射异常")
 	}
 
-	currentTime := time.Now().Format("2006-01-02 15:04:05")
+	return &Bet{
+		BetType:   betType.Value,
+		BetAmount: betAmount,
+	}, nil
+}
+
+// isQuickThereBetTypeName 是否为快三支持的下注类型
+func isQuickThereBetTypeName(betTypeName string) bool {
+	for _, betType := range quickThereBetTypes {
+		if betType.Name == betTypeName {
+			return true
+		}
+	}
+	return isQuickThereSumBetTypeName(betTypeName)
+}
 
-	diceValues, err := rollDice(bot, group.TgChatGroupId, 3)
+// isQuickThereSumBetTypeName 是否为和值下注类型 例: 和10
+func isQuickThereSumBetTypeName(betTypeName string) bool {
+	if !strings.HasPrefix(betTypeName, "和") {
+		return false
+	}
+	total, err := strconv.Atoi(strings.TrimPrefix(betTypeName, "和"))
 	if err != nil {
-		blockedOrKicked(err, group.TgChatGroupId)
-		return "", err
+		return false
+	}
+	_, b := enums.GetSumGameLotteryType(total)
+	return b
+}
+
+func (g *quickThereGameplay) StoreBet(tx *gorm.DB, betRecord *model.BetRecord, bet *Bet) error {
+	// 保存快三下注记录
+	quickThereBetRecord := &model.QuickThereBetRecord{
+		Id:              betRecord.Id,
+		ChatGroupUserId: betRecord.ChatGroupUserId,
+		ChatGroupId:     betRecord.ChatGroupId,
+		IssueNumber:     betRecord.IssueNumber,
+		BetType:         bet.BetType,
+		BetAmount:       bet.BetAmount,
+		SettleStatus:    enums.Unsettled.Value,
+		UpdateTime:      betRecord.UpdateTime,
+		CreateTime:      betRecord.CreateTime,
+	}
+	return quickThereBetRecord.Create(tx)
+}
+
+func (g *quickThereGameplay) Draw(bot *tgbotapi.BotAPI, group *model.ChatGroup, lotteryRecord *model.LotteryRecord) (Lottery, error) {
+	var diceSource DiceSource
+	var serverSeed, commitment string
+	var err error
+	if group.DiceSource == enums.DiceSourceProvablyFair.Value {
+		// 可验证随机 公布种子并由种子推导点数
+		serverSeed, err = revealServerSeed(group, lotteryRecord.IssueNumber)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"chatGroupId": group.Id,
+				"issueNumber": lotteryRecord.IssueNumber,
+				"err":         err,
+			}).Error("获取服务端种子异常")
+			return nil, err
+		}
+	}
+	if serverSeed != "" {
+		commitment = seedCommitment(serverSeed)
+		diceSource = newSeededDiceSource(serverSeed, lotteryRecord.IssueNumber)
+	} else if group.DiceSource == enums.DiceSourceProvablyFair.Value {
+		// 开期时未公布种子承诺 改用 Telegram 骰子开奖 开奖记录不保存种子 该期无法验证
+		diceSource = &telegramDiceSource{bot: bot, chatID: group.TgChatGroupId}
+	} else {
+		diceSource = newGroupDiceSource(bot, group)
+	}
+	diceValues, err := diceSource.Roll("🎲", 3)
+	if err != nil {
+		return nil, err
 	}
 	count := sumDiceValues(diceValues)
 	singleOrDouble, bigOrSmall := determineResult(count)
 
-	time.Sleep(3 * time.Second)
 	triplet := 0
 	if diceValues[0] == diceValues[1] && diceValues[1] == diceValues[2] {
 		triplet = 1
 	}
-	message, err := formatMessage(diceValues[0], diceValues[1], diceValues[2], count, singleOrDouble, bigOrSmall, triplet, issueNumber)
+
+	return &model.QuickThereLotteryRecord{
+		Id:             lotteryRecord.Id,
+		ChatGroupId:    lotteryRecord.ChatGroupId,
+		IssueNumber:    lotteryRecord.IssueNumber,
+		ValueA:         diceValues[0],
+		ValueB:         diceValues[1],
+		ValueC:         diceValues[2],
+		Total:          count,
+		SingleDouble:   singleOrDouble,
+		BigSmall:       bigOrSmall,
+		Triplet:        triplet,
+		ServerSeed:     serverSeed,
+		SeedCommitment: commitment,
+		CreateTime:     lotteryRecord.CreateTime,
+	}, nil
+}
+
+func (g *quickThereGameplay) Settle(bot *tgbotapi.BotAPI, group *model.ChatGroup, lottery Lottery) error {
+	lotteryRecord := lottery.(*model.QuickThereLotteryRecord)
+
+	// 获取所有参与竞猜的用户下注记录
+	quickThereBetRecord := &model.QuickThereBetRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: lotteryRecord.IssueNumber,
+	}
+	quickThereBetRecords, err := quickThereBetRecord.ListByChatGroupIdAndIssueNumber(store.DB())
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"issueNumber": issueNumber,
+			"ChatGroupId": group.Id,
+			"IssueNumber": lotteryRecord.IssueNumber,
+			"err":         err,
+		}).Error("获取用户下注记录异常")
+		return err
+	}
+	// 查询此群的快三配置 赔付模式及奖池抽成按开期时的配置
+	quickThereConfig, err := queryQuickThereIssueConfig(group, lotteryRecord.IssueNumber)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": group.Id,
 			"err":         err,
-		}).Warn("开奖结果消息格式化异常")
+		}).Error("查询群的快三配置异常")
+		return err
 	}
 
-	tx := db.Begin()
+	// 奖池模式按整期下注计算每笔下注的派彩
+	if quickThereConfig.OddsMode == enums.OddsModePool.Value {
+		return settleBets(bot, quickThereBetRecords, quickTherePoolPayout(quickThereConfig, quickThereBetRecords, lotteryRecord))
+	}
+	return settleBets(bot, quickThereBetRecords, quickThereFixedPayout(quickThereConfig, lotteryRecord))
+}
 
-	id, err := utils.NextID()
+// queryQuickThereIssueConfig 查询群的快三配置 赔付模式及奖池抽成替换为开期时保存的配置
+// 开期记录不存在时(如升级前开期)使用当前配置
+func queryQuickThereIssueConfig(group *model.ChatGroup, issueNumber string) (*model.QuickThereConfig, error) {
+	quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), group.Id)
 	if err != nil {
-		logrus.Error("SnowFlakeId create error")
-		return "", err
+		return nil, err
 	}
 
-	// 插入开奖主表
-	record := &model.LotteryRecord{
-		Id:           id,
-		ChatGroupId:  group.Id,
-		IssueNumber:  issueNumber,
-		GameplayType: enums.QuickThere.Value,
-		CreateTime:   currentTime,
+	issueRecordQuery := &model.IssueRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
 	}
-	err = record.Create(tx)
-	if err != nil {
-		logrus.WithField("err", err).Error("开奖记录插入异常")
-		tx.Rollback()
-		return "", err
+	issueRecord, err := issueRecordQuery.QueryByChatGroupIdAndIssueNumber(store.DB())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+		}).Warn("未查询到开期记录 使用当前快三配置")
+		return quickThereConfig, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if issueRecord.OddsMode != "" {
+		quickThereConfig.OddsMode = issueRecord.OddsMode
+		quickThereConfig.PoolRakeRate = issueRecord.PoolRakeRate
 	}
+	return quickThereConfig, nil
+}
 
-	// 插入快三开奖表
-	lotteryRecord := &model.QuickThereLotteryRecord{
-		Id:           id,
-		ChatGroupId:  group.Id,
-		IssueNumber:  issueNumber,
-		ValueA:       diceValues[0],
-		ValueB:       diceValues[1],
-		ValueC:       diceValues[2],
-		Total:        count,
-		SingleDouble: singleOrDouble,
-		BigSmall:     bigOrSmall,
-		Triplet:      triplet,
-		CreateTime:   currentTime,
+// PoolAmount 奖池模式下当前期的奖池积分
+func (g *quickThereGameplay) PoolAmount(group *model.ChatGroup, issueNumber string) (decimal.Decimal, bool) {
+	quickThereConfig, err := queryQuickThereIssueConfig(group, issueNumber)
+	if err != nil || quickThereConfig.OddsMode != enums.OddsModePool.Value {
+		return 0, false
+	}
+	quickThereBetRecord := &model.QuickThereBetRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+	}
+	poolAmount, err := quickThereBetRecord.SumBetAmountByChatGroupIdAndIssueNumber(store.DB())
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": group.Id,
+			"IssueNumber": issueNumber,
+			"err":         err,
+		}).Error("查询快三奖池积分异常")
+		return 0, false
 	}
+	return poolAmount, true
+}
 
-	err = lotteryRecord.Create(tx)
+func (g *quickThereGameplay) ListUnsettledIssues(db *gorm.DB) ([]*UnsettledIssue, error) {
+	quickThereBetRecord := &model.QuickThereBetRecord{
+		SettleStatus: enums.Unsettled.Value,
+	}
+	quickThereBetRecords, err := quickThereBetRecord.ListIssuesBySettleStatus(db)
 	if err != nil {
-		logrus.WithField("err", err).Error("开奖记录插入异常")
-		tx.Rollback()
-		return "", err
+		return nil, err
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		// 提交事务时出现异常，回滚事务
-		tx.Rollback()
+	unsettledIssues := make([]*UnsettledIssue, 0, len(quickThereBetRecords))
+	for _, betRecord := range quickThereBetRecords {
+		unsettledIssues = append(unsettledIssues, &UnsettledIssue{
+			ChatGroupId: betRecord.ChatGroupId,
+			IssueNumber: betRecord.IssueNumber,
+		})
 	}
+	return unsettledIssues, nil
+}
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("开奖历史", enums.CallbackLotteryHistory.Value),
-		),
-	)
+func (g *quickThereGameplay) QueryLottery(db *gorm.DB, record *model.LotteryRecord) (Lottery, error) {
+	quickThereLotteryRecord := &model.QuickThereLotteryRecord{
+		Id: record.Id,
+	}
+	return quickThereLotteryRecord.QueryById(db)
+}
 
-	msg := tgbotapi.NewMessage(group.TgChatGroupId, message)
-	msg.ReplyMarkup = keyboard
-	_, err = sendMessage(bot, &msg)
+func (g *quickThereGameplay) RefundBets(tx *gorm.DB, group *model.ChatGroup, issueNumber string) ([]*RefundedBet, error) {
+	quickThereBetRecord := &model.QuickThereBetRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+	}
+	quickThereBetRecords, err := quickThereBetRecord.ListByChatGroupIdAndIssueNumber(tx)
 	if err != nil {
-		blockedOrKicked(err, group.TgChatGroupId)
-		return "", err
+		return nil, err
 	}
 
-	nextIssueNumber = time.Now().Format("20060102150405")
+	return refundBets(tx, quickThereBetRecords, func(betRecord *model.QuickThereBetRecord) string {
+		lotteryType, _ := enums.GetGameLotteryType(betRecord.BetType)
+		return lotteryType.Name
+	})
+}
 
-	lotteryDrawTipMsgConfig := tgbotapi.NewMessage(group.TgChatGroupId, fmt.Sprintf("第%s期 %d分钟后开奖", nextIssueNumber, group.GameDrawCycle))
-	_, err = sendMessage(bot, &lotteryDrawTipMsgConfig)
-	if err != nil {
-		blockedOrKicked(err, group.TgChatGroupId)
-		return
+func (g *quickThereGameplay) FormatResult(lottery Lottery) (string, error) {
+	lotteryRecord := lottery.(*model.QuickThereLotteryRecord)
+	message, err := formatMessage(lotteryRecord.ValueA, lotteryRecord.ValueB, lotteryRecord.ValueC, lotteryRecord.Total, lotteryRecord.SingleDouble, lotteryRecord.BigSmall, lotteryRecord.Triplet, lotteryRecord.IssueNumber)
+	if err != nil || lotteryRecord.ServerSeed == "" {
+		return message, err
 	}
+	return message + fmt.Sprintf("\n种子: %s\n承诺: %s\n发送 /verify %s 验证本期结果",
+		lotteryRecord.ServerSeed, lotteryRecord.SeedCommitment, lotteryRecord.IssueNumber), nil
+}
 
-	// 设置新的期号和对话ID
-	err = redisDB.Set(redisDB.Context(), redisKey, nextIssueNumber, 0).Err()
+func (g *quickThereGameplay) FormatLotteryHistory(db *gorm.DB, record *model.LotteryRecord) (string, error) {
+	quickThereLotteryRecord := &model.QuickThereLotteryRecord{
+		Id: record.Id,
+	}
+	quickThereLotteryRecord, err := quickThereLotteryRecord.QueryById(db)
 	if err != nil {
-		logrus.WithField("err", err).Warn("存储新期号和对话ID异常")
+		return "", err
 	}
 
-	// 遍历下注记录，计算竞猜结果
-	go func() {
-		// 获取所有参与竞猜的用户下注记录
-		quickThereBetRecord := &model.QuickThereBetRecord{
-			ChatGroupId: group.Id,
-			IssueNumber: issueNumber,
-		}
-		quickThereBetRecords, err := quickThereBetRecord.ListByChatGroupIdAndIssueNumber(db)
-		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"ChatGroupId": group.Id,
-				"IssueNumber": issueNumber,
-				"err":         err,
-			}).Error("获取用户下注记录异常")
-			return
-		}
-		// 查询此群的快三配置
-		quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(db, group.Id)
-		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"ChatGroupId": group.Id,
-				"err":         err,
-			}).Error("查询群的快三配置异常")
-			return
-		}
+	bigSmall, _ := enums.GetGameLotteryType(quickThereLotteryRecord.BigSmall)
+	singleDouble, _ := enums.GetGameLotteryType(quickThereLotteryRecord.SingleDouble)
 
-		for _, betRecord := range quickThereBetRecords {
-			// 更新用户余额
-			updateBalanceByQuickThere(bot, quickThereConfig, betRecord, lotteryRecord)
-		}
-	}()
+	triplet := ""
+	if quickThereLotteryRecord.Triplet == 1 {
+		triplet = "【豹子】"
+	}
 
-	return nextIssueNumber, nil
+	return fmt.Sprintf("%s期 %s %d+%d+%d=%d %s %s %s\n",
+		quickThereLotteryRecord.IssueNumber,
+		"快三",
+		quickThereLotteryRecord.ValueA,
+		quickThereLotteryRecord.ValueB,
+		quickThereLotteryRecord.ValueC,
+		quickThereLotteryRecord.ValueA+quickThereLotteryRecord.ValueB+quickThereLotteryRecord.ValueC,
+		bigSmall.Name,
+		singleDouble.Name,
+		triplet,
+	), nil
 }
 
-// rollDice 模拟多次掷骰子。
-func rollDice(bot *tgbotapi.BotAPI, chatID int64, numDice int) ([]int, error) {
-	diceValues := make([]int, numDice)
-	diceConfig := tgbotapi.NewDiceWithEmoji(chatID, "🎲")
+func (g *quickThereGameplay) FormatBetHistory(db *gorm.DB, record *model.BetRecord) (string, error) {
+	quickThereBetRecord := &model.QuickThereBetRecord{
+		Id: record.Id,
+	}
+	quickThereBetRecord, err := quickThereBetRecord.QueryById(db)
+	if err != nil {
+		return "", err
+	}
 
-	for i := 0; i < numDice; i++ {
-		diceMsg, err := bot.Send(diceConfig)
-		if err != nil {
-			logrus.WithField("err", err).Error("发送骰子消息异常")
-			return nil, err
-		}
-		diceValues[i] = diceMsg.Dice.Value
+	betType, _ := enums.GetGameLotteryType(quickThereBetRecord.BetType)
+
+	betResultTypeName := "「未开奖」"
+
+	if quickThereBetRecord.BetResultType != nil {
+		betType, _ := enums.GetBetResultType(*quickThereBetRecord.BetResultType)
+		betResultTypeName = betType.Name
 	}
 
-	return diceValues, nil
+	return fmt.Sprintf("%s期 %s %s %v %s %v \n",
+		record.IssueNumber,
+		"快三",
+		betType.Name,
+		quickThereBetRecord.BetAmount,
+		betResultTypeName,
+		formatBetResultAmount(quickThereBetRecord.BetResultAmount),
+	), nil
+}
+
+func (g *quickThereGameplay) HelpText(group *model.ChatGroup) (string, error) {
+	quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), group.Id)
+	if err != nil {
+		return "", err
+	}
+	if quickThereConfig.OddsMode == enums.OddsModePool.Value {
+		return fmt.Sprintf("当前赔付模式: %s\n"+
+			"本期所有下注积分汇入奖池,扣除%v%%抽成后由中奖者按下注积分比例分配,无人中奖时退还全部下注。\n"+
+			"豹子通杀: %s\n\n"+
+			"支持竞猜类型: 单、双、大、小、豹子、大单、大双、小单、小双、和值(和3-和18)、"+
+			"指定豹子(豹子1-豹子6)、对子(任意两颗相同)、指定对子(对子1-对子6)、单号(号1-号6)\n"+
+			"竞猜示例(竞猜类型-单,下注积分-20):\n #单 20\n"+
+			"竞猜示例(竞猜类型-和值10,下注积分-50):\n #和10 50",
+			enums.OddsModePool.Name, quickThereConfig.PoolRakeRate,
+			quickThereTripleKillText(quickThereConfig)), nil
+	}
+	return fmt.Sprintf("当前倍率:\n"+
+		"简易%v倍丨豹子%v倍丨组合%v倍\n"+
+		"豹子通杀: %s\n"+
+		"指定豹子%v倍丨对子%v倍丨指定对子%v倍\n"+
+		"单号倍率:\n%s\n"+
+		"和值倍率:\n%s\n\n"+
+		"支持竞猜类型: 单、双、大、小、豹子、大单、大双、小单、小双、和值(和3-和18)、"+
+		"指定豹子(豹子1-豹子6)、对子(任意两颗相同)、指定对子(对子1-对子6)、单号(号1-号6,按出现次数赔付)\n"+
+		"竞猜示例(竞猜类型-单,下注积分-20):\n #单 20\n"+
+		"竞猜示例(竞猜类型-和值10,下注积分-50):\n #和10 50\n"+
+		"竞猜示例(竞猜类型-豹子3,下注积分-10):\n #豹子3 10\n"+
+		"竞猜示例(竞猜类型-号5,下注积分-10):\n #号5 10",
+		quickThereConfig.SimpleOdds, quickThereConfig.TripletOdds, quickThereConfig.ComboOdds,
+		quickThereTripleKillText(quickThereConfig),
+		quickThereConfig.SpecificTripletOdds, quickThereConfig.PairOdds, quickThereConfig.SpecificPairOdds,
+		formatQuickThereNumberOdds(quickThereNumberOdds(quickThereConfig)),
+		formatQuickThereSumOdds(quickThereSumOdds(quickThereConfig))), nil
+}
+
+func (g *quickThereGameplay) ConfigInlineKeyboardRows(group *model.ChatGroup, callbackDataQueryString string) ([][]tgbotapi.InlineKeyboardButton, error) {
+	// 查询该配置
+	quickThereConfig, err := model.QueryQuickThereConfigByChatGroupId(store.DB(), group.Id)
+	if err != nil {
+		return nil, err
+	}
+	tripleKillStatus, _ := enums.GetTripleKillStatus(quickThereConfig.TripleKill)
+	oddsMode, _ := enums.GetOddsMode(quickThereConfig.OddsMode)
+	return [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("💱赔付模式: %s", oddsMode.Name), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereOddsMode.Value, callbackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🏦奖池抽成: %v%%", quickThereConfig.PoolRakeRate), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickTherePoolRakeRate.Value, callbackDataQueryString)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️简易倍率: %v 倍", quickThereConfig.SimpleOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereSimpleOdds.Value, callbackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️豹子倍率: %v 倍", quickThereConfig.TripletOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereTripletOdds.Value, callbackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️组合倍率: %v 倍", quickThereConfig.ComboOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereComboOdds.Value, callbackDataQueryString)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️指定豹子倍率: %v 倍", quickThereConfig.SpecificTripletOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereSpecificTripletOdds.Value, callbackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🐆豹子通杀: %s", tripleKillStatus.Name), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereTripleKill.Value, callbackDataQueryString)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️对子倍率: %v 倍", quickThereConfig.PairOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickTherePairOdds.Value, callbackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️指定对子倍率: %v 倍", quickThereConfig.SpecificPairOdds), fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereSpecificPairOdds.Value, callbackDataQueryString)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚖️和值倍率", fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereSumOdds.Value, callbackDataQueryString)),
+			tgbotapi.NewInlineKeyboardButtonData("⚖️单号倍率", fmt.Sprintf("%s%s", enums.CallbackUpdateQuickThereNumberOdds.Value, callbackDataQueryString)),
+		),
+	}, nil
 }
 
 func sumDiceValues(diceValues []int) int {
@@ -253,104 +523,166 @@ func formatMessage(valueA int, valueB int, valueC int, count int, singleOrDouble
 	), nil
 }
 
-// updateBalance 更新用户余额
-func updateBalanceByQuickThere(bot *tgbotapi.BotAPI, quickThereConfig *model.QuickThereConfig, betRecord *model.QuickThereBetRecord, lotteryRecord *model.QuickThereLotteryRecord) {
+// quickThereBetOdds 根据开奖结果计算下注类型的中奖倍率
+func quickThereBetOdds(quickThereConfig *model.QuickThereConfig, betType string, lotteryRecord *model.QuickThereLotteryRecord) (decimal.Decimal, bool) {
+	// 豹子通杀 开出豹子时简易竞猜和组合竞猜不中奖
+	tripleKill := quickThereConfig.TripleKill == enums.TripleKillON.Value && lotteryRecord.Triplet == 1
+	if betType == lotteryRecord.SingleDouble || betType == lotteryRecord.BigSmall {
+		if tripleKill {
+			return 0, false
+		}
+		return quickThereConfig.SimpleOdds, true
+	}
+	if betType == enums.Triplet.Value && lotteryRecord.Triplet == 1 {
+		return quickThereConfig.TripletOdds, true
+	}
+	if comboType, b := quickThereComboType(lotteryRecord.BigSmall, lotteryRecord.SingleDouble); b && betType == comboType.Value {
+		if tripleKill {
+			return 0, false
+		}
+		return quickThereConfig.ComboOdds, true
+	}
+	if sumType, b := enums.GetSumGameLotteryType(lotteryRecord.Total); b && betType == sumType.Value {
+		return quickThereSumOdds(quickThereConfig)[lotteryRecord.Total], true
+	}
 
-	// 查找该用户信息
-	chatGroupUser := &model.ChatGroupUser{Id: betRecord.ChatGroupUserId}
-	chatGroupUser, err := chatGroupUser.QueryById(db)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		logrus.WithFields(logrus.Fields{
-			"ChatGroupUserId": betRecord.ChatGroupUserId,
-		}).Error("未查询到该用户信息")
-		return
-	} else if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"ChatGroupUserId": betRecord.ChatGroupUserId,
-			"err":             err,
-		}).Error("查询该用户信息异常")
-		return
+	// 各点数出现次数
+	valueCounts := make(map[int]int)
+	for _, value := range []int{lotteryRecord.ValueA, lotteryRecord.ValueB, lotteryRecord.ValueC} {
+		valueCounts[value]++
+	}
+	for value, count := range valueCounts {
+		if tripletType, b := enums.GetSpecificTripletGameLotteryType(value); b && count == 3 && betType == tripletType.Value {
+			return quickThereConfig.SpecificTripletOdds, true
+		}
+		if betType == enums.Pair.Value && count >= 2 {
+			return quickThereConfig.PairOdds, true
+		}
+		if pairType, b := enums.GetSpecificPairGameLotteryType(value); b && count >= 2 && betType == pairType.Value {
+			return quickThereConfig.SpecificPairOdds, true
+		}
+		if numberType, b := enums.GetNumberGameLotteryType(value); b && betType == numberType.Value {
+			return quickThereNumberOdds(quickThereConfig)[count], true
+		}
 	}
+	return 0, false
+}
 
-	// 查找该用户所属群
-	ChatGroup, err := model.QueryChatGroupById(db, chatGroupUser.ChatGroupId)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		logrus.WithFields(logrus.Fields{
-			"ChatGroupId": chatGroupUser.ChatGroupId,
-		}).Error("未查询到群信息")
-		return
-	} else if err != nil {
+// quickThereComboType 根据大小、单双开奖结果获取组合类型
+func quickThereComboType(bigSmall string, singleDouble string) (enums.GameLotteryType, bool) {
+	bigSmallType, b := enums.GetGameLotteryType(bigSmall)
+	if !b {
+		return enums.GameLotteryType{}, false
+	}
+	singleDoubleType, b := enums.GetGameLotteryType(singleDouble)
+	if !b {
+		return enums.GameLotteryType{}, false
+	}
+	return enums.GetGameLotteryTypeForName(bigSmallType.Name + singleDoubleType.Name)
+}
+
+// quickThereTripleKillText 豹子通杀规则说明
+func quickThereTripleKillText(quickThereConfig *model.QuickThereConfig) string {
+	if quickThereConfig.TripleKill == enums.TripleKillON.Value {
+		return "开启(开出豹子时大/小/单/双及大单/大双/小单/小双不中奖)"
+	}
+	return "关闭(开出豹子时大/小/单/双及大单/大双/小单/小双照常中奖)"
+}
+
+// quickThereSumOdds 解析群的和值倍率 未配置的和值使用默认倍率
+func quickThereSumOdds(quickThereConfig *model.QuickThereConfig) map[int]decimal.Decimal {
+	return parseQuickThereOddsTable(quickThereConfig.ChatGroupId, quickThereConfig.SumOdds, defaultQuickThereSumOdds)
+}
+
+// quickThereNumberOdds 解析群的单号倍率(按出现次数) 未配置的次数使用默认倍率
+func quickThereNumberOdds(quickThereConfig *model.QuickThereConfig) map[int]decimal.Decimal {
+	return parseQuickThereOddsTable(quickThereConfig.ChatGroupId, quickThereConfig.NumberOdds, defaultQuickThereNumberOdds)
+}
+
+// parseQuickThereOddsTable 解析JSON倍率表 并以默认倍率补全
+func parseQuickThereOddsTable(chatGroupId string, oddsTable string, defaultOddsTable map[int]decimal.Decimal) map[int]decimal.Decimal {
+	odds := make(map[int]decimal.Decimal)
+	for key, value := range defaultOddsTable {
+		odds[key] = value
+	}
+	if oddsTable == "" {
+		return odds
+	}
+
+	var configOdds map[int]decimal.Decimal
+	err := json.Unmarshal([]byte(oddsTable), &configOdds)
+	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"ChatGroupId": chatGroupUser.ChatGroupId,
+			"ChatGroupId": chatGroupId,
+			"oddsTable":   oddsTable,
 			"err":         err,
-		}).Error("查询群信息异常")
-		return
-	}
-
-	// 获取用户对应的互斥锁
-	userLockKey := fmt.Sprintf(ChatGroupUserLockKey, ChatGroup.TgChatGroupId, chatGroupUser.TgUserId)
-	userLock := getUserLock(userLockKey)
-	userLock.Lock()
-	defer userLock.Unlock()
-
-	tx := db.Begin()
-
-	var betResultTypeName string
-	if betRecord.BetType == lotteryRecord.SingleDouble ||
-		betRecord.BetType == lotteryRecord.BigSmall {
-		betRecord.BetResultAmount = fmt.Sprintf("+%.2f", betRecord.BetAmount*quickThereConfig.SimpleOdds)
-		chatGroupUser.Balance += betRecord.BetAmount * quickThereConfig.SimpleOdds
-		betResultType := 1
-		betResultTypeName = "赢"
-		betRecord.BetResultType = &betResultType
-	} else if betRecord.BetType == enums.Triplet.Value && lotteryRecord.Triplet == 1 {
-		betRecord.BetResultAmount = fmt.Sprintf("+%.2f", betRecord.BetAmount*quickThereConfig.SimpleOdds)
-		chatGroupUser.Balance += betRecord.BetAmount * quickThereConfig.TripletOdds
-		betResultType := 1
-		betResultTypeName = "赢"
-		betRecord.BetResultType = &betResultType
-	} else {
-		betRecord.BetResultAmount = fmt.Sprintf("-%.2f", betRecord.BetAmount)
-		betResultType := 0
-		betResultTypeName = "输"
-		betRecord.BetResultType = &betResultType
-	}
-
-	result := tx.Save(&chatGroupUser)
-	if result.Error != nil {
-		logrus.WithField("err", result.Error).Error("更新用户余额异常")
-		tx.Rollback()
-		return
-	}
-
-	// 更新下注记录表
-	betRecord.SettleStatus = 1
-	betRecord.UpdateTime = time.Now().Format("2006-01-02 15:04:05")
-	result = tx.Save(&betRecord)
-	if result.Error != nil {
-		logrus.WithField("err", result.Error).Error("更新下注记录异常")
-		tx.Rollback()
-		return
-	}
-
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		// 提交事务时出现异常，回滚事务
-		tx.Rollback()
-	}
-
-	lotteryType, _ := enums.GetGameLotteryType(betRecord.BetType)
-
-	// 消息提醒
-	sendMsg := tgbotapi.NewMessage(chatGroupUser.TgUserId,
-		fmt.Sprintf("您在【%s】第%s期下注%v积分猜【%s】,竞猜结果为【%s】,积分余额%.2f。",
-			ChatGroup.TgChatGroupTitle,
-			betRecord.IssueNumber,
-			betRecord.BetAmount,
-			lotteryType.Name,
-			betResultTypeName,
-			chatGroupUser.Balance))
-	_, err = sendMessage(bot, &sendMsg)
-	blockedOrKicked(err, chatGroupUser.TgUserId)
-	return
+		}).Error("快三倍率表解析异常")
+		return odds
+	}
+	for key, value := range configOdds {
+		odds[key] = value
+	}
+	return odds
+}
+
+// formatQuickThereNumberOdds 单号倍率展示 例: 出现1次: 2倍丨出现2次: 3倍丨出现3次: 4倍
+func formatQuickThereNumberOdds(numberOdds map[int]decimal.Decimal) string {
+	var numberOddsText string
+	for count := 1; count <= 3; count++ {
+		if count > 1 {
+			numberOddsText += "丨"
+		}
+		numberOddsText += fmt.Sprintf("出现%d次: %v倍", count, numberOdds[count])
+	}
+	return numberOddsText
+}
+
+// formatQuickThereSumOdds 和值倍率展示 每行4个 例: 和3: 240倍丨和4: 80倍
+func formatQuickThereSumOdds(sumOdds map[int]decimal.Decimal) string {
+	var sumOddsText string
+	for total := 3; total <= 18; total++ {
+		if total > 3 && (total-3)%4 == 0 {
+			sumOddsText += "\n"
+		} else if total > 3 {
+			sumOddsText += "丨"
+		}
+		sumOddsText += fmt.Sprintf("和%d: %v倍", total, sumOdds[total])
+	}
+	return sumOddsText
+}
+
+// quickThereFixedPayout 固定倍率模式 中奖按倍率赔付
+func quickThereFixedPayout(quickThereConfig *model.QuickThereConfig, lotteryRecord *model.QuickThereLotteryRecord) func(betRecord *model.QuickThereBetRecord) betSettlement {
+	return func(betRecord *model.QuickThereBetRecord) betSettlement {
+		betText := betTypeText(betRecord.BetAmount, betRecord.BetType)
+		if odds, win := quickThereBetOdds(quickThereConfig, betRecord.BetType, lotteryRecord); win {
+			return betSettlement{betResultType: enums.Win, amount: betRecord.BetAmount.Mul(odds), betText: betText}
+		}
+		return betSettlement{betResultType: enums.Loss, betText: betText}
+	}
+}
+
+// quickTherePoolPayout 奖池模式 整期下注扣除抽成后由中奖者按下注积分比例分配 无人中奖时全部退还
+func quickTherePoolPayout(quickThereConfig *model.QuickThereConfig, betRecords []*model.QuickThereBetRecord, lotteryRecord *model.QuickThereLotteryRecord) func(betRecord *model.QuickThereBetRecord) betSettlement {
+	var poolAmount, winBetAmount decimal.Decimal
+	for _, betRecord := range betRecords {
+		poolAmount += betRecord.BetAmount
+		if _, win := quickThereBetOdds(quickThereConfig, betRecord.BetType, lotteryRecord); win {
+			winBetAmount += betRecord.BetAmount
+		}
+	}
+	payoutAmount := poolAmount - poolAmount.Percent(quickThereConfig.PoolRakeRate)
+
+	return func(betRecord *model.QuickThereBetRecord) betSettlement {
+		betText := betTypeText(betRecord.BetAmount, betRecord.BetType)
+		if winBetAmount == 0 {
+			return betSettlement{betResultType: enums.Refund, amount: betRecord.BetAmount, betText: betText}
+		}
+		if _, win := quickThereBetOdds(quickThereConfig, betRecord.BetType, lotteryRecord); win {
+			// 向下取整到分 保证派彩总额不超过奖池
+			amount := payoutAmount.MulDiv(int64(betRecord.BetAmount), int64(winBetAmount))
+			return betSettlement{betResultType: enums.Win, amount: amount, betText: betText}
+		}
+		return betSettlement{betResultType: enums.Loss, betText: betText}
+	}
 }
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+	"testing"
+)
+
+// 开出豹子 5 5 5 时 豹子通杀对简易竞猜和组合竞猜生效
+func TestQuickThereBetOddsTripleKill(t *testing.T) {
+	lotteryRecord := &model.QuickThereLotteryRecord{
+		ValueA:       5,
+		ValueB:       5,
+		ValueC:       5,
+		Total:        15,
+		SingleDouble: enums.Single.Value,
+		BigSmall:     enums.Big.Value,
+		Triplet:      1,
+	}
+	tests := []struct {
+		name       string
+		tripleKill int
+		betType    string
+		wantOdds   decimal.Decimal
+		wantWin    bool
+	}{
+		{"通杀 简易", enums.TripleKillON.Value, enums.Big.Value, 0, false},
+		{"通杀 组合", enums.TripleKillON.Value, enums.BigSingle.Value, 0, false},
+		{"通杀 豹子照常中奖", enums.TripleKillON.Value, enums.Triplet.Value, decimal.MustParse("24"), true},
+		{"不通杀 简易", enums.TripleKillOFF.Value, enums.Single.Value, decimal.MustParse("1.95"), true},
+		{"不通杀 组合", enums.TripleKillOFF.Value, enums.BigSingle.Value, decimal.MustParse("3.8"), true},
+		{"不通杀 未中组合", enums.TripleKillOFF.Value, enums.SmallSingle.Value, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quickThereConfig := &model.QuickThereConfig{
+				SimpleOdds:  decimal.MustParse("1.95"),
+				ComboOdds:   decimal.MustParse("3.8"),
+				TripletOdds: decimal.MustParse("24"),
+				TripleKill:  tt.tripleKill,
+			}
+			odds, win := quickThereBetOdds(quickThereConfig, tt.betType, lotteryRecord)
+			if win != tt.wantWin || (win && odds != tt.wantOdds) {
+				t.Fatalf("quickThereBetOdds(%s) = %v, %v, want %v, %v", tt.betType, odds, win, tt.wantOdds, tt.wantWin)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/kv"
+	"telegram-dice-bot/internal/model"
+	"telegram-dice-bot/internal/utils"
+	"time"
+)
+
+// 恢复结算时开奖锁被占用的期号的重试间隔
+const lockedIssueRetryInterval = time.Minute
+
+// recoverUnsettledBets 启动时恢复结算 服务在开奖与结算之间中断时遗留的未结算下注
+// 已开奖的期号按开奖记录重新结算 未开奖、不是当前期且未被其他实例开奖的期号作废并退还下注
+// 结算与退还均只处理未结算的下注 重复执行不会重复派彩
+func recoverUnsettledBets(bot *tgbotapi.BotAPI) {
+	var lockedIssues []*lockedIssue
+	for gameplayType, gameplay := range gameplayRegistry {
+		unsettledIssues, err := gameplay.ListUnsettledIssues(store.DB())
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"GameplayType": gameplayType,
+				"err":          err,
+			}).Error("查询未结算期号异常")
+			continue
+		}
+
+		for _, unsettledIssue := range unsettledIssues {
+			if recoverUnsettledIssue(bot, gameplayType, gameplay, unsettledIssue) {
+				lockedIssues = append(lockedIssues, &lockedIssue{gameplayType, gameplay, unsettledIssue})
+			}
+		}
+	}
+
+	if len(lockedIssues) > 0 {
+		settlementWG.Add(1)
+		go retryLockedIssues(bot, lockedIssues)
+	}
+}
+
+// lockedIssue 恢复结算时开奖锁被占用的期号
+type lockedIssue struct {
+	gameplayType   string
+	gameplay       Gameplay
+	unsettledIssue *UnsettledIssue
+}
+
+// retryLockedIssues 定时重试开奖锁被占用的期号 其他实例开奖完成或中断后遗留的开奖锁到期后 由本实例补结算或作废
+func retryLockedIssues(bot *tgbotapi.BotAPI, lockedIssues []*lockedIssue) {
+	defer settlementWG.Done()
+
+	ticker := time.NewTicker(lockedIssueRetryInterval)
+	defer ticker.Stop()
+	for len(lockedIssues) > 0 {
+		select {
+		case <-settlementStopCh:
+			return
+		case <-ticker.C:
+		}
+
+		var remaining []*lockedIssue
+		for _, issue := range lockedIssues {
+			if recoverUnsettledIssue(bot, issue.gameplayType, issue.gameplay, issue.unsettledIssue) {
+				remaining = append(remaining, issue)
+			}
+		}
+		lockedIssues = remaining
+	}
+}
+
+// recoverUnsettledIssue 恢复一期的结算 开奖锁被占用时返回 true 稍后重试
+func recoverUnsettledIssue(bot *tgbotapi.BotAPI, gameplayType string, gameplay Gameplay, unsettledIssue *UnsettledIssue) bool {
+	group, err := store.ChatGroups().QueryById(unsettledIssue.ChatGroupId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": unsettledIssue.ChatGroupId,
+			"err":         err,
+		}).Error("查询群信息异常")
+		return false
+	}
+
+	// 当前期尚未开奖 由开奖任务继续处理
+	redisKey := fmt.Sprintf(RedisCurrentIssueNumberKey, group.Id)
+	currentIssueNumber, err := kvStore.Get(redisKey)
+	if err != nil && !errors.Is(err, kv.ErrNil) {
+		logrus.WithFields(logrus.Fields{
+			"redisKey": redisKey,
+			"err":      err,
+		}).Error("查询当前期号异常")
+		return false
+	} else if currentIssueNumber == unsettledIssue.IssueNumber {
+		return false
+	}
+
+	// 当前期号在开奖前已删除 其他实例正在开奖或开奖中断时持有开奖锁 稍后重试
+	locked, err := lockIssue(group.Id, unsettledIssue.IssueNumber)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": unsettledIssue.ChatGroupId,
+			"IssueNumber": unsettledIssue.IssueNumber,
+			"err":         err,
+		}).Error("获取开奖锁异常")
+		return false
+	} else if !locked {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": unsettledIssue.ChatGroupId,
+			"IssueNumber": unsettledIssue.IssueNumber,
+		}).Info("恢复结算 该期开奖锁被占用 稍后重试")
+		return true
+	}
+	defer unlockIssue(group.Id, unsettledIssue.IssueNumber)
+
+	lotteryRecordQuery := &model.LotteryRecord{
+		ChatGroupId: unsettledIssue.ChatGroupId,
+		IssueNumber: unsettledIssue.IssueNumber,
+	}
+	lotteryRecord, err := store.LotteryRecords().QueryByChatGroupIdAndIssueNumber(lotteryRecordQuery.ChatGroupId, lotteryRecordQuery.IssueNumber)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 未开奖 作废该期并退还下注
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": unsettledIssue.ChatGroupId,
+			"IssueNumber": unsettledIssue.IssueNumber,
+		}).Warn("恢复结算 该期未开奖 作废并退还下注")
+
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return false
+		}
+		record := &model.LotteryRecord{
+			Id:           id,
+			ChatGroupId:  group.Id,
+			IssueNumber:  unsettledIssue.IssueNumber,
+			GameplayType: gameplayType,
+			CreateTime:   time.Now().Format("2006-01-02 15:04:05"),
+		}
+		cancelIssue(bot, group, gameplay, record, "未开奖(服务中断)")
+		return false
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": unsettledIssue.ChatGroupId,
+			"IssueNumber": unsettledIssue.IssueNumber,
+			"err":         err,
+		}).Error("查询开奖记录异常")
+		return false
+	}
+
+	if lotteryRecord.Status == enums.LotteryCanceled.Value {
+		// 作废与退还在同一事务中完成 不应存在未结算下注
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": unsettledIssue.ChatGroupId,
+			"IssueNumber": unsettledIssue.IssueNumber,
+		}).Warn("恢复结算 该期已作废但仍有未结算下注")
+		return false
+	}
+
+	// 已开奖 按开奖记录重新结算
+	lottery, err := gameplay.QueryLottery(store.DB(), lotteryRecord)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": unsettledIssue.ChatGroupId,
+			"IssueNumber": unsettledIssue.IssueNumber,
+			"err":         err,
+		}).Error("查询玩法开奖记录异常")
+		return false
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"ChatGroupId": unsettledIssue.ChatGroupId,
+		"IssueNumber": unsettledIssue.IssueNumber,
+	}).Info("恢复结算 该期已开奖 重新结算")
+	err = gameplay.Settle(bot, group, lottery)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"ChatGroupId": unsettledIssue.ChatGroupId,
+			"IssueNumber": unsettledIssue.IssueNumber,
+			"err":         err,
+		}).Error("恢复结算异常")
+	}
+	return false
+}
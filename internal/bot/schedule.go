@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+	"telegram-dice-bot/internal/model"
+	"telegram-dice-bot/internal/schedule"
+	"time"
+)
+
+const (
+	// 期号格式 期号即该期的开奖时间
+	issueNumberLayout = "20060102150405"
+	// 每期至少可下注的时长 距下一个开奖时间不足时顺延一期
+	minIssueOpenDuration = 10 * time.Second
+	// 期号已使用时最多顺延的开奖时间数
+	maxIssueNumberSkips = 100
+)
+
+// minDrawIntervals 按开奖配置缓存的最短开奖间隔
+var minDrawIntervals sync.Map
+
+// drawCycle 群的开奖周期
+func drawCycle(group *model.ChatGroup) time.Duration {
+	if group.GameDrawCycle <= 0 {
+		return time.Minute
+	}
+	return time.Duration(group.GameDrawCycle) * time.Minute
+}
+
+// groupSchedule 群的开奖计划 配置异常时按开奖周期全天开奖
+func groupSchedule(group *model.ChatGroup) *schedule.Schedule {
+	groupSchedule, err := schedule.Parse(group.DrawSchedule, group.QuietHours, group.Timezone, drawCycle(group))
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId":  group.Id,
+			"DrawSchedule": group.DrawSchedule,
+			"QuietHours":   group.QuietHours,
+			"Timezone":     group.Timezone,
+			"err":          err,
+		}).Error("开奖计划解析异常 按开奖周期开奖")
+		groupSchedule, _ = schedule.Parse("", "", "", drawCycle(group))
+	}
+	return groupSchedule
+}
+
+// nextDrawTime 新开一期的开奖时间 可下注时长不足时顺延到下一个开奖时间
+func nextDrawTime(group *model.ChatGroup, now time.Time) time.Time {
+	openDuration := minIssueOpenDuration
+	if betClose, ok := betCloseDuration(group); ok {
+		openDuration += betClose
+	}
+
+	drawSchedule := groupSchedule(group)
+	drawTime := drawSchedule.Next(now)
+	if drawTime.IsZero() {
+		logrus.WithField("chatGroupId", group.Id).Error("开奖计划内没有可开奖的时间 按开奖周期开奖")
+		drawSchedule, _ = schedule.Parse("", "", "", drawCycle(group))
+		drawTime = drawSchedule.Next(now)
+	}
+	if drawTime.Sub(now) < openDuration {
+		if nextTime := drawSchedule.Next(drawTime); !nextTime.IsZero() {
+			drawTime = nextTime
+		}
+	}
+	return drawTime
+}
+
+// newIssueNumber 新开一期的期号 即该期的开奖时间(群时区)
+// 已开期或已有开奖记录(含作废)的期号不再使用 如同一开奖时间内作废后重新开启游戏 顺延到下一个开奖时间
+func newIssueNumber(group *model.ChatGroup) string {
+	location := groupSchedule(group).Location()
+	drawTime := nextDrawTime(group, time.Now())
+	issueNumber := drawTime.In(location).Format(issueNumberLayout)
+	for i := 0; i < maxIssueNumberSkips; i++ {
+		used, err := issueNumberUsed(group.Id, issueNumber)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"chatGroupId": group.Id,
+				"issueNumber": issueNumber,
+				"err":         err,
+			}).Error("查询期号是否已使用异常")
+			return issueNumber
+		} else if !used {
+			return issueNumber
+		}
+		drawTime = nextDrawTime(group, drawTime)
+		issueNumber = drawTime.In(location).Format(issueNumberLayout)
+	}
+	logrus.WithFields(logrus.Fields{
+		"chatGroupId": group.Id,
+		"issueNumber": issueNumber,
+	}).Error("顺延后的期号仍已使用")
+	return issueNumber
+}
+
+// issueNumberUsed 该期号是否已开期或已有开奖记录
+func issueNumberUsed(chatGroupId string, issueNumber string) (bool, error) {
+	issueRecordQuery := &model.IssueRecord{
+		ChatGroupId: chatGroupId,
+		IssueNumber: issueNumber,
+	}
+	_, err := issueRecordQuery.QueryByChatGroupIdAndIssueNumber(store.DB())
+	if err == nil {
+		return true, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	_, err = store.LotteryRecords().QueryByChatGroupIdAndIssueNumber(chatGroupId, issueNumber)
+	if err == nil {
+		return true, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	return false, nil
+}
+
+// parseIssueNumber 按群当前时区解析期号对应的开奖时间
+func parseIssueNumber(group *model.ChatGroup, issueNumber string) (time.Time, error) {
+	return time.ParseInLocation(issueNumberLayout, issueNumber, groupSchedule(group).Location())
+}
+
+// issueDrawTime 该期的开奖时间 按开期时保存的开奖时间 重启后按此恢复剩余倒计时 已过开奖时间时立即开奖
+func issueDrawTime(group *model.ChatGroup, issueNumber string) time.Time {
+	issueRecordQuery := &model.IssueRecord{
+		ChatGroupId: group.Id,
+		IssueNumber: issueNumber,
+	}
+	issueRecord, err := issueRecordQuery.QueryByChatGroupIdAndIssueNumber(store.DB())
+	if err == nil && issueRecord.DrawTime != "" {
+		drawTime, err := time.Parse(time.RFC3339, issueRecord.DrawTime)
+		if err == nil {
+			return drawTime
+		}
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+			"DrawTime":    issueRecord.DrawTime,
+			"err":         err,
+		}).Warn("开期记录的开奖时间解析异常 按期号解析开奖时间")
+	}
+
+	// 开期记录不存在时(如升级前开期)按群当前时区解析期号
+	drawTime, err := parseIssueNumber(group, issueNumber)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chatGroupId": group.Id,
+			"issueNumber": issueNumber,
+			"err":         err,
+		}).Warn("期号解析开奖时间异常 按下一个开奖时间开奖")
+		return nextDrawTime(group, time.Now())
+	}
+	return drawTime
+}
+
+// minDrawInterval 群的相邻两次开奖的最短间隔 按开奖计划计算 计算不出时为开奖周期
+func minDrawInterval(group *model.ChatGroup) time.Duration {
+	// 最短开奖间隔只取决于开奖配置 按配置缓存 避免每次封盘判断都遍历开奖时间
+	cacheKey := fmt.Sprintf("%s|%s|%s|%d", group.DrawSchedule, group.QuietHours, group.Timezone, group.GameDrawCycle)
+	if minInterval, ok := minDrawIntervals.Load(cacheKey); ok {
+		return minInterval.(time.Duration)
+	}
+	minInterval := groupSchedule(group).MinInterval(time.Now())
+	if minInterval <= 0 {
+		minInterval = drawCycle(group)
+	}
+	minDrawIntervals.Store(cacheKey, minInterval)
+	return minInterval
+}
+
+// betCloseDuration 开奖前封盘的时长 未设置封盘时间或封盘时间不小于最短开奖间隔时返回 false
+func betCloseDuration(group *model.ChatGroup) (time.Duration, bool) {
+	betClose := time.Duration(group.BetCloseSeconds) * time.Second
+	if betClose <= 0 || betClose >= minDrawInterval(group) {
+		return 0, false
+	}
+	return betClose, true
+}
+
+// waitDraw 等待该期开奖时间 期间到达封盘时间时封盘 任务停止时返回 false
+func waitDraw(bot *tgbotapi.BotAPI, group *model.ChatGroup, issueNumber string, stopCh <-chan struct{}) bool {
+	drawTime := issueDrawTime(group, issueNumber)
+	drawTimer := time.NewTimer(time.Until(drawTime))
+	defer drawTimer.Stop()
+
+	// 未设置封盘时间或重启前已封盘时 betCloseCh 为nil 不会触发
+	var betCloseCh <-chan time.Time
+	if betClose, ok := betCloseDuration(group); ok {
+		if closed, _ := isIssueClosed(group.Id, issueNumber); !closed {
+			betCloseTimer := time.NewTimer(time.Until(drawTime.Add(-betClose)))
+			defer betCloseTimer.Stop()
+			betCloseCh = betCloseTimer.C
+		}
+	}
+
+	for {
+		select {
+		case <-betCloseCh:
+			closeBetting(bot, group, issueNumber, drawTime)
+			betCloseCh = nil
+		case <-drawTimer.C:
+			return true
+		case <-stopCh:
+			return false
+		}
+	}
+}
+
+// formatDrawSchedule 开奖计划展示
+func formatDrawSchedule(group *model.ChatGroup) string {
+	drawScheduleText := fmt.Sprintf("开奖: 每%d分钟", group.GameDrawCycle)
+	if group.DrawSchedule != "" {
+		drawScheduleText = fmt.Sprintf("开奖: %s", group.DrawSchedule)
+	}
+	quietHoursText := "静默时段: 无"
+	if group.QuietHours != "" {
+		quietHoursText = fmt.Sprintf("静默时段: %s", group.QuietHours)
+	}
+	timezoneText := fmt.Sprintf("时区: %s(服务器时区)", time.Local.String())
+	if group.Timezone != "" {
+		timezoneText = fmt.Sprintf("时区: %s", group.Timezone)
+	}
+	return strings.Join([]string{drawScheduleText, quietHoursText, timezoneText}, "\n")
+}
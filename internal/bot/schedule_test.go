@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"telegram-dice-bot/internal/enums"
+	"telegram-dice-bot/internal/model"
+	"telegram-dice-bot/internal/repository"
+	"testing"
+	"time"
+)
+
+// setupTestStore 使用内存 SQLite 作为存储
+func setupTestStore(t *testing.T) {
+	t.Helper()
+	testStore, err := repository.Open("sqlite://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testStore.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	previousStore := store
+	store = testStore
+	t.Cleanup(func() {
+		store = previousStore
+		testStore.Close()
+	})
+}
+
+// 作废当前期后在同一开奖时间内重新开启游戏 不能沿用已作废的期号
+func TestNewIssueNumberSkipsUsedIssue(t *testing.T) {
+	tests := []struct {
+		name string
+		use  func(t *testing.T, group *model.ChatGroup, issueNumber string)
+	}{
+		{"已作废", func(t *testing.T, group *model.ChatGroup, issueNumber string) {
+			record := &model.LotteryRecord{
+				Id:           "lottery-" + issueNumber,
+				ChatGroupId:  group.Id,
+				IssueNumber:  issueNumber,
+				GameplayType: group.GameplayType,
+				Status:       enums.LotteryCanceled.Value,
+				CreateTime:   time.Now().Format("2006-01-02 15:04:05"),
+			}
+			if err := store.LotteryRecords().Create(record); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"已开期", func(t *testing.T, group *model.ChatGroup, issueNumber string) {
+			issueRecord := &model.IssueRecord{
+				Id:          "issue-" + issueNumber,
+				ChatGroupId: group.Id,
+				IssueNumber: issueNumber,
+				CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
+			}
+			if err := issueRecord.Create(store.DB()); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestStore(t)
+			group := &model.ChatGroup{
+				Id:            "group",
+				GameplayType:  enums.QuickThere.Value,
+				GameDrawCycle: 60,
+				Timezone:      "UTC",
+			}
+
+			issueNumber := newIssueNumber(group)
+			tt.use(t, group, issueNumber)
+
+			drawTime, err := parseIssueNumber(group, issueNumber)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := nextDrawTime(group, drawTime).Format(issueNumberLayout)
+			if got := newIssueNumber(group); got != want {
+				t.Fatalf("重新开期的期号 = %s, want %s(已使用 %s)", got, want, issueNumber)
+			}
+		})
+	}
+}
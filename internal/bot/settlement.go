@@ -0,0 +1,304 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
+	"strings"
+	"telegram-dice-bot/internal/model"
+	"time"
+)
+
+const (
+	SettlementWorkers = "SETTLEMENT_WORKERS"
+
+	// RedisSettlementStreamKey 开奖结算任务队列
+	RedisSettlementStreamKey = "SETTLEMENT_STREAM"
+	settlementConsumerGroup  = "SETTLEMENT"
+	// 队列保留的最大消息数(近似值)
+	settlementStreamMaxLen = 10000
+	// 单次读取的最大消息数
+	settlementReadCount = 10
+	// 无新消息时的阻塞等待时长 超时后认领超时未确认的消息
+	settlementReadBlock = 5 * time.Second
+	// 单条消息连续结算失败的最大次数 超过后保留在待确认列表中等待重新认领
+	settlementMaxAttempts    = 5
+	settlementRetryBaseDelay = time.Second
+	settlementRetryMaxDelay  = time.Minute
+	// 消息超过该时长未确认时由其他worker重新认领
+	settlementClaimMinIdle = 5 * time.Minute
+	// 未配置 Redis 时进程内结算队列的容量
+	localSettlementQueueSize = 1000
+)
+
+// localSettlementQueue 未配置 Redis 时的进程内结算队列 进程退出时未结算的期在下次启动时由 recoverUnsettledBets 补结算
+var localSettlementQueue chan string
+
+// publishSettlement 发布开奖结算任务
+func publishSettlement(record *model.LotteryRecord) error {
+	if redisDB == nil {
+		select {
+		case localSettlementQueue <- record.Id:
+			return nil
+		default:
+			return errors.New("结算队列已满")
+		}
+	}
+	return redisDB.XAdd(redisDB.Context(), &redis.XAddArgs{
+		Stream: RedisSettlementStreamKey,
+		MaxLen: settlementStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"lottery_record_id": record.Id,
+			"chat_group_id":     record.ChatGroupId,
+			"issue_number":      record.IssueNumber,
+			"gameplay_type":     record.GameplayType,
+		},
+	}).Err()
+}
+
+// initSettlementWorker 启动结算worker SETTLEMENT_WORKERS 为0时本进程只开奖不结算
+func initSettlementWorker(bot *tgbotapi.BotAPI) {
+	workers := 1
+	if value := os.Getenv(SettlementWorkers); value != "" {
+		var err error
+		workers, err = strconv.Atoi(value)
+		if err != nil || workers < 0 {
+			logrus.Fatal("结算worker数量配置错误:", value)
+		}
+	}
+	if redisDB == nil {
+		// 未配置 Redis 时只有本进程能结算
+		if workers == 0 {
+			logrus.Warn("未配置 Redis 时必须在本进程结算 结算worker数量改为1")
+			workers = 1
+		}
+		localSettlementQueue = make(chan string, localSettlementQueueSize)
+		for i := 0; i < workers; i++ {
+			settlementWG.Add(1)
+			go localSettlementWorker(bot)
+		}
+		return
+	}
+	if workers == 0 {
+		logrus.Info("未启动结算worker")
+		return
+	}
+
+	err := redisDB.XGroupCreateMkStream(redisDB.Context(), RedisSettlementStreamKey, settlementConsumerGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		logrus.Fatal("创建结算消费组失败:", err)
+	}
+
+	// 消费者名称在重启后保持不变 以便继续处理重启前未确认的消息
+	hostname, _ := os.Hostname()
+	for i := 0; i < workers; i++ {
+		settlementWG.Add(1)
+		go settlementWorker(bot, fmt.Sprintf("%s-%d", hostname, i))
+	}
+}
+
+func settlementWorker(bot *tgbotapi.BotAPI, consumer string) {
+	defer settlementWG.Done()
+
+	// 先处理本消费者未确认的消息 处理完后再读取新消息
+	lastId := "0"
+	for {
+		// 退出时未处理的消息留在待确认列表中 重启后继续处理
+		select {
+		case <-settlementStopCh:
+			return
+		default:
+		}
+
+		streams, err := redisDB.XReadGroup(redisDB.Context(), &redis.XReadGroupArgs{
+			Group:    settlementConsumerGroup,
+			Consumer: consumer,
+			Streams:  []string{RedisSettlementStreamKey, lastId},
+			Count:    settlementReadCount,
+			Block:    settlementReadBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			claimSettlementMessages(bot, consumer)
+			continue
+		} else if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"consumer": consumer,
+				"err":      err,
+			}).Error("读取结算任务异常")
+			select {
+			case <-settlementStopCh:
+			case <-time.After(settlementReadBlock):
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			if lastId != ">" && len(stream.Messages) == 0 {
+				lastId = ">"
+			}
+			for _, message := range stream.Messages {
+				processSettlementMessage(bot, consumer, message)
+				if lastId != ">" {
+					lastId = message.ID
+				}
+			}
+		}
+	}
+}
+
+// localSettlementWorker 进程内结算worker 多次失败的期在下次启动时补结算
+func localSettlementWorker(bot *tgbotapi.BotAPI) {
+	defer settlementWG.Done()
+
+	for {
+		select {
+		case lotteryRecordId := <-localSettlementQueue:
+			settleLocal(bot, lotteryRecordId)
+		case <-settlementStopCh:
+			// 退出前结算完已入队的任务 开奖任务此时均已停止 不会再有新任务
+			for {
+				select {
+				case lotteryRecordId := <-localSettlementQueue:
+					settleLocal(bot, lotteryRecordId)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func settleLocal(bot *tgbotapi.BotAPI, lotteryRecordId string) {
+	err := settleWithRetry(bot, lotteryRecordId)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"lotteryRecordId": lotteryRecordId,
+			"err":             err,
+		}).Error("结算任务多次失败 等待重启后补结算")
+	}
+}
+
+// claimSettlementMessages 认领超时未确认的消息(如worker崩溃或多次结算失败)
+func claimSettlementMessages(bot *tgbotapi.BotAPI, consumer string) {
+	messages, _, err := redisDB.XAutoClaim(redisDB.Context(), &redis.XAutoClaimArgs{
+		Stream:   RedisSettlementStreamKey,
+		Group:    settlementConsumerGroup,
+		MinIdle:  settlementClaimMinIdle,
+		Start:    "0",
+		Count:    settlementReadCount,
+		Consumer: consumer,
+	}).Result()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"consumer": consumer,
+			"err":      err,
+		}).Error("认领结算任务异常")
+		return
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"consumer": consumer,
+		"count":    len(messages),
+	}).Warn("重新认领超时未确认的结算任务")
+	for _, message := range messages {
+		processSettlementMessage(bot, consumer, message)
+	}
+}
+
+// processSettlementMessage 结算一条消息 失败时退避重试 成功后确认
+func processSettlementMessage(bot *tgbotapi.BotAPI, consumer string, message redis.XMessage) {
+	lotteryRecordId := fmt.Sprint(message.Values["lottery_record_id"])
+
+	err := settleWithRetry(bot, lotteryRecordId)
+	if err != nil {
+		backlog, _ := redisDB.XPending(redisDB.Context(), RedisSettlementStreamKey, settlementConsumerGroup).Result()
+		fields := logrus.Fields{
+			"messageId":       message.ID,
+			"lotteryRecordId": lotteryRecordId,
+			"err":             err,
+		}
+		if backlog != nil {
+			fields["pending"] = backlog.Count
+		}
+		logrus.WithFields(fields).Error("结算任务多次失败 等待重新认领")
+		return
+	}
+
+	err = redisDB.XAck(redisDB.Context(), RedisSettlementStreamKey, settlementConsumerGroup, message.ID).Err()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"consumer":  consumer,
+			"messageId": message.ID,
+			"err":       err,
+		}).Error("确认结算任务异常")
+	}
+}
+
+// settleWithRetry 结算该开奖记录 失败时退避重试 超过最大次数或停止服务时返回最后一次的错误
+func settleWithRetry(bot *tgbotapi.BotAPI, lotteryRecordId string) error {
+	delay := settlementRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := settleLotteryRecord(bot, lotteryRecordId)
+		if err == nil || attempt >= settlementMaxAttempts {
+			return err
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"lotteryRecordId": lotteryRecordId,
+			"attempt":         attempt,
+			"err":             err,
+		}).Warn("结算任务失败 稍后重试")
+		select {
+		case <-settlementStopCh:
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > settlementRetryMaxDelay {
+			delay = settlementRetryMaxDelay
+		}
+	}
+}
+
+// settleLotteryRecord 结算该开奖记录对应的一期 已结算的下注会被跳过 玩法或群数据异常时不再重试
+func settleLotteryRecord(bot *tgbotapi.BotAPI, lotteryRecordId string) error {
+	lotteryRecord := &model.LotteryRecord{Id: lotteryRecordId}
+	lotteryRecord, err := store.LotteryRecords().QueryById(lotteryRecord.Id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 开奖记录提交后才发布结算任务 查询不到时(如读到落后的从库)不确认消息 等待重试或重新认领
+		logrus.WithField("lotteryRecordId", lotteryRecordId).Error("未查询到开奖记录")
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	gameplay, b := getGameplay(lotteryRecord.GameplayType)
+	if !b {
+		logrus.WithField("GameplayType", lotteryRecord.GameplayType).Error("开奖记录玩法未注册")
+		return nil
+	}
+
+	group, err := store.ChatGroups().QueryById(lotteryRecord.ChatGroupId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithField("ChatGroupId", lotteryRecord.ChatGroupId).Error("未查询到群信息")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	lottery, err := gameplay.QueryLottery(store.DB(), lotteryRecord)
+	if err != nil {
+		return err
+	}
+
+	return gameplay.Settle(bot, group, lottery)
+}
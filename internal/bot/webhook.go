@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	// UpdateMode 接收更新的方式 polling(默认)为长轮询 webhook 为由 Telegram 推送
+	UpdateMode = "UPDATE_MODE"
+	// WebhookURL Telegram 推送更新的公网地址 例: https://bot.example.com/telegram/webhook
+	WebhookURL = "WEBHOOK_URL"
+	// WebhookSecretToken 校验推送请求的密钥 为空时每次启动随机生成
+	WebhookSecretToken = "WEBHOOK_SECRET_TOKEN"
+	// WebhookListenAddr HTTP 监听地址 默认与 Dockerfile 暴露的端口一致
+	WebhookListenAddr = "WEBHOOK_LISTEN_ADDR"
+
+	updateModePolling = "polling"
+	updateModeWebhook = "webhook"
+
+	defaultWebhookListenAddr = ":3000"
+	webhookSecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// 单个推送请求体的最大字节数
+	webhookMaxBodyBytes = 1 << 20
+)
+
+// webhookServer webhook 模式下的 HTTP 服务 长轮询模式下为 nil
+var webhookServer *http.Server
+
+// receiveUpdates 按 UPDATE_MODE 选择长轮询或 webhook 两种方式的更新进入同一个分发流程
+func receiveUpdates(bot *tgbotapi.BotAPI) tgbotapi.UpdatesChannel {
+	mode := os.Getenv(UpdateMode)
+	switch mode {
+	case "", updateModePolling:
+		// 之前设置过 webhook 时长轮询会失败 先删除
+		_, err := bot.Request(tgbotapi.DeleteWebhookConfig{})
+		if err != nil {
+			logrus.WithField("err", err).Error("删除 webhook 异常")
+		}
+		updateConfig := tgbotapi.NewUpdate(0)
+		updateConfig.Timeout = 60
+		logrus.Info("使用长轮询接收更新")
+		return bot.GetUpdatesChan(updateConfig)
+	case updateModeWebhook:
+		updates, err := startWebhook(bot)
+		if err != nil {
+			logrus.Fatal("启动 webhook 失败:", err)
+		}
+		return updates
+	default:
+		logrus.Fatal("接收更新方式配置错误:", mode)
+		return nil
+	}
+}
+
+// startWebhook 启动 HTTP 服务并向 Telegram 注册 webhook
+func startWebhook(bot *tgbotapi.BotAPI) (tgbotapi.UpdatesChannel, error) {
+	webhookURL, err := url.Parse(os.Getenv(WebhookURL))
+	if err != nil {
+		return nil, err
+	}
+	if webhookURL.Scheme != "https" || webhookURL.Host == "" {
+		return nil, errors.New("WEBHOOK_URL 必须为 https 地址")
+	}
+
+	secretToken := os.Getenv(WebhookSecretToken)
+	if secretToken == "" {
+		secretToken, err = generateWebhookSecretToken()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	listenAddr := os.Getenv(WebhookListenAddr)
+	if listenAddr == "" {
+		listenAddr = defaultWebhookListenAddr
+	}
+
+	path := webhookURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	updates := make(chan tgbotapi.Update, bot.Buffer)
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, webhookHandler(bot, secretToken, updates))
+	webhookServer = &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := webhookServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Fatal("webhook HTTP 服务异常:", err)
+		}
+	}()
+
+	// tgbotapi 的 WebhookConfig 不支持 secret_token 直接调用接口
+	params := tgbotapi.Params{}
+	params["url"] = webhookURL.String()
+	params["secret_token"] = secretToken
+	_, err = bot.MakeRequest("setWebhook", params)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"listenAddr": listenAddr,
+		"path":       path,
+		"host":       webhookURL.Host,
+	}).Info("使用 webhook 接收更新")
+	return updates, nil
+}
+
+// webhookHandler 校验密钥后解析更新 校验失败的请求不会进入分发流程
+func webhookHandler(bot *tgbotapi.BotAPI, secretToken string, updates chan<- tgbotapi.Update) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(webhookSecretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secretToken)) != 1 {
+			logrus.WithField("remoteAddr", r.RemoteAddr).Warn("webhook 密钥校验失败")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, webhookMaxBodyBytes)
+		update, err := bot.HandleUpdate(r)
+		if err != nil {
+			logrus.WithField("err", err).Warn("webhook 更新解析异常")
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		updates <- *update
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// generateWebhookSecretToken 随机生成密钥 Telegram 要求为1-256位字母、数字、_或-
+func generateWebhookSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
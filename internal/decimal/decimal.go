@@ -0,0 +1,192 @@
+package decimal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal 两位小数的定点数 用于积分、倍率及比例 存储值为实际值*100
+// 对应数据库 decimal(x, 2) 字段 避免 float64 运算的舍入误差
+type Decimal int64
+
+// 小数位数对应的倍数
+const scale = 100
+
+var (
+	ErrInvalidFormat         = errors.New("数值格式错误")
+	ErrTooManyDecimalPlaces  = errors.New("最多支持两位小数")
+	errUnsupportedScanSource = errors.New("不支持的数值类型")
+)
+
+// Zero 零值
+const Zero Decimal = 0
+
+// NewFromInt 整数值
+func NewFromInt(value int64) Decimal {
+	return Decimal(value * scale)
+}
+
+// Parse 解析数值文本 最多两位小数 用于用户输入
+func Parse(text string) (Decimal, error) {
+	return parse(text, true)
+}
+
+// MustParse 解析数值常量 格式错误时 panic
+func MustParse(text string) Decimal {
+	d, err := Parse(text)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// parse 解析数值文本 strict 为 false 时允许两位以后的小数为0(如数据库聚合结果 12.3000)
+func parse(text string, strict bool) (Decimal, error) {
+	text = strings.TrimSpace(text)
+	negative := false
+	if strings.HasPrefix(text, "-") {
+		negative = true
+		text = text[1:]
+	} else if strings.HasPrefix(text, "+") {
+		text = text[1:]
+	}
+
+	integerPart, fractionPart, hasPoint := strings.Cut(text, ".")
+	if integerPart == "" || (hasPoint && fractionPart == "") || !isDigits(integerPart) || !isDigits(fractionPart) {
+		return 0, ErrInvalidFormat
+	}
+	if len(fractionPart) > 2 {
+		if strict || strings.Trim(fractionPart[2:], "0") != "" {
+			return 0, ErrTooManyDecimalPlaces
+		}
+		fractionPart = fractionPart[:2]
+	}
+	fractionPart += strings.Repeat("0", 2-len(fractionPart))
+
+	integer, err := strconv.ParseInt(integerPart, 10, 64)
+	if err != nil || integer > (1<<63-1)/scale-1 {
+		return 0, ErrInvalidFormat
+	}
+	fraction, _ := strconv.ParseInt(fractionPart, 10, 64)
+
+	value := Decimal(integer*scale + fraction)
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func isDigits(text string) bool {
+	for _, c := range text {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Mul 乘以倍率 结果向下取整到两位小数
+func (d Decimal) Mul(multiplier Decimal) Decimal {
+	return d.MulDiv(int64(multiplier), scale)
+}
+
+// Percent 按百分比计算 如抽成 rate 为5表示5% 结果向下取整到两位小数
+func (d Decimal) Percent(rate Decimal) Decimal {
+	return d.MulDiv(int64(rate), 100*scale)
+}
+
+// Div 除以整数 结果向下取整到两位小数
+func (d Decimal) Div(divisor int64) Decimal {
+	return d.MulDiv(1, divisor)
+}
+
+// MulDiv 计算 d*numerator/denominator 结果向下取整到两位小数 中间结果不会溢出
+func (d Decimal) MulDiv(numerator int64, denominator int64) Decimal {
+	product := new(big.Int).Mul(big.NewInt(int64(d)), big.NewInt(numerator))
+	// big.Int.Div 为欧几里得除法 除数为正时即向下取整
+	quotient := new(big.Int).Div(product, big.NewInt(denominator))
+	return Decimal(quotient.Int64())
+}
+
+// String 去除末尾0的文本 如 12.3 10
+func (d Decimal) String() string {
+	text := d.StringFixed()
+	text = strings.TrimRight(text, "0")
+	return strings.TrimSuffix(text, ".")
+}
+
+// StringFixed 两位小数文本 如 12.30
+func (d Decimal) StringFixed() string {
+	sign := ""
+	value := int64(d)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/scale, value%scale)
+}
+
+// Format 实现 fmt.Formatter %f 按两位小数输出(支持 %+.2f) 其余动词输出 String
+func (d Decimal) Format(f fmt.State, verb rune) {
+	text := d.String()
+	if verb == 'f' {
+		text = d.StringFixed()
+		if precision, ok := f.Precision(); ok && precision > 2 {
+			text += strings.Repeat("0", precision-2)
+		}
+	}
+	if f.Flag('+') && d >= 0 {
+		text = "+" + text
+	}
+	_, _ = io.WriteString(f, text)
+}
+
+// Float64 浮点值 仅用于展示及日志
+func (d Decimal) Float64() float64 {
+	return float64(d) / scale
+}
+
+// Value 实现 driver.Valuer 以文本写入 decimal 字段
+func (d Decimal) Value() (driver.Value, error) {
+	return d.StringFixed(), nil
+}
+
+// Scan 实现 sql.Scanner 读取 decimal 字段
+func (d *Decimal) Scan(src interface{}) error {
+	var err error
+	switch value := src.(type) {
+	case nil:
+		*d = 0
+	case []byte:
+		*d, err = parse(string(value), false)
+	case string:
+		*d, err = parse(value, false)
+	case int64:
+		*d = NewFromInt(value)
+	case float64:
+		*d, err = parse(strconv.FormatFloat(value, 'f', 2, 64), false)
+	default:
+		err = errUnsupportedScanSource
+	}
+	return err
+}
+
+// MarshalJSON 以数值写入 JSON
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.StringFixed()), nil
+}
+
+// UnmarshalJSON 读取 JSON 数值或数值字符串
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	value, err := parse(strings.Trim(string(data), `"`), true)
+	if err != nil {
+		return err
+	}
+	*d = value
+	return nil
+}
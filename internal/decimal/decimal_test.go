@@ -0,0 +1,248 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Decimal
+		wantErr error
+	}{
+		{"0", 0, nil},
+		{"10", 1000, nil},
+		{"12.3", 1230, nil},
+		{"12.30", 1230, nil},
+		{"0.05", 5, nil},
+		{"+1.5", 150, nil},
+		{"-1.5", -150, nil},
+		{"-0.05", -5, nil},
+		{" 7.25 ", 725, nil},
+		{"12.345", 0, ErrTooManyDecimalPlaces},
+		{"12.300", 0, ErrTooManyDecimalPlaces},
+		{"", 0, ErrInvalidFormat},
+		{"-", 0, ErrInvalidFormat},
+		{".5", 0, ErrInvalidFormat},
+		{"5.", 0, ErrInvalidFormat},
+		{"1.2.3", 0, ErrInvalidFormat},
+		{"1e3", 0, ErrInvalidFormat},
+		{"abc", 0, ErrInvalidFormat},
+		{"--1", 0, ErrInvalidFormat},
+		{"99999999999999999999", 0, ErrInvalidFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) err = %v, want %v", tt.text, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Fatalf("Parse(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		text        string
+		string      string
+		stringFixed string
+	}{
+		{"0", "0", "0.00"},
+		{"10", "10", "10.00"},
+		{"12.3", "12.3", "12.30"},
+		{"12.34", "12.34", "12.34"},
+		{"0.05", "0.05", "0.05"},
+		{"-0.05", "-0.05", "-0.05"},
+		{"-12.5", "-12.5", "-12.50"},
+		{"-100", "-100", "-100.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			d := MustParse(tt.text)
+			if got := d.String(); got != tt.string {
+				t.Fatalf("String() = %q, want %q", got, tt.string)
+			}
+			if got := d.StringFixed(); got != tt.stringFixed {
+				t.Fatalf("StringFixed() = %q, want %q", got, tt.stringFixed)
+			}
+			for _, text := range []string{d.String(), d.StringFixed()} {
+				if parsed, err := Parse(text); err != nil || parsed != d {
+					t.Fatalf("Parse(%q) = %d, %v, want %d", text, parsed, err, d)
+				}
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		value  Decimal
+		want   string
+	}{
+		{"%v", MustParse("12.3"), "12.3"},
+		{"%s", MustParse("-0.5"), "-0.5"},
+		{"%.2f", MustParse("12.3"), "12.30"},
+		{"%.2f", MustParse("-0.05"), "-0.05"},
+		{"%.4f", MustParse("1.5"), "1.5000"},
+		{"%+.2f", MustParse("3"), "+3.00"},
+		{"%+.2f", MustParse("-3"), "-3.00"},
+		{"%+v", Zero, "+0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.value.StringFixed(), func(t *testing.T) {
+			if got := fmt.Sprintf(tt.format, tt.value); got != tt.want {
+				t.Fatalf("Sprintf(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       Decimal
+		numerator   int64
+		denominator int64
+		want        Decimal
+	}{
+		{"整除", MustParse("10"), 3, 2, MustParse("15")},
+		{"向下取整", MustParse("10"), 1, 3, MustParse("3.33")},
+		{"负数向下取整", MustParse("-10"), 1, 3, MustParse("-3.34")},
+		{"负数整除", MustParse("-10"), 1, 2, MustParse("-5")},
+		{"不足一分", MustParse("0.01"), 1, 3, 0},
+		{"负数不足一分", MustParse("-0.01"), 1, 3, MustParse("-0.01")},
+		{"中间结果超出int64", Decimal(1 << 60), 1 << 10, 1 << 10, Decimal(1 << 60)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.value.MulDiv(tt.numerator, tt.denominator); got != tt.want {
+				t.Fatalf("MulDiv(%d, %d) = %v, want %v", tt.numerator, tt.denominator, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulPercentDiv(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Decimal
+		want Decimal
+	}{
+		{"Mul 倍率", MustParse("10").Mul(MustParse("1.95")), MustParse("19.5")},
+		{"Mul 向下取整", MustParse("0.99").Mul(MustParse("1.95")), MustParse("1.93")},
+		{"Mul 负数", MustParse("-0.99").Mul(MustParse("1.95")), MustParse("-1.94")},
+		{"Percent 抽成", MustParse("200").Percent(MustParse("5")), MustParse("10")},
+		{"Percent 向下取整", MustParse("0.99").Percent(MustParse("5")), MustParse("0.04")},
+		{"Percent 小数比例", MustParse("100").Percent(MustParse("2.5")), MustParse("2.5")},
+		{"Div 平分", MustParse("10").Div(3), MustParse("3.33")},
+		{"Div 负数", MustParse("-10").Div(3), MustParse("-3.34")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Fatalf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Decimal
+		wantErr error
+	}{
+		{"nil", nil, 0, nil},
+		{"decimal 字段文本", []byte("12.30"), MustParse("12.3"), nil},
+		{"聚合结果多余的0", "12.3000", MustParse("12.3"), nil},
+		{"聚合结果负数", []byte("-0.0500"), MustParse("-0.05"), nil},
+		{"整数文本", "100", MustParse("100"), nil},
+		{"int64", int64(42), MustParse("42"), nil},
+		{"float64", float64(12.3), MustParse("12.3"), nil},
+		{"float64 负数", float64(-0.05), MustParse("-0.05"), nil},
+		{"多余的非0小数", "12.3001", 0, ErrTooManyDecimalPlaces},
+		{"空字符串", "", 0, ErrInvalidFormat},
+		{"不支持的类型", true, 0, errUnsupportedScanSource},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Decimal
+			err := got.Scan(tt.src)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Scan(%v) err = %v, want %v", tt.src, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Fatalf("Scan(%v) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		value Decimal
+		want  string
+	}{
+		{Zero, "0.00"},
+		{MustParse("12.3"), "12.30"},
+		{MustParse("-0.05"), "-0.05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := tt.value.Value()
+			if err != nil || got != tt.want {
+				t.Fatalf("Value() = %v, %v, want %q", got, err, tt.want)
+			}
+
+			// 写入的文本读回后不变
+			var scanned Decimal
+			if err := scanned.Scan(got); err != nil || scanned != tt.value {
+				t.Fatalf("Scan(%v) = %v, %v, want %v", got, scanned, err, tt.value)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Decimal
+		wantErr error
+	}{
+		{"数值", `12.5`, MustParse("12.5"), nil},
+		{"数值字符串", `"-0.05"`, MustParse("-0.05"), nil},
+		{"超过两位小数", `1.234`, 0, ErrTooManyDecimalPlaces},
+		{"格式错误", `"abc"`, 0, ErrInvalidFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Decimal
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unmarshal(%s) err = %v, want %v", tt.data, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Fatalf("Unmarshal(%s) = %v, want %v", tt.data, got, tt.want)
+			}
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if roundTrip := MustParse(string(data)); roundTrip != got {
+				t.Fatalf("Marshal 后再解析 = %v, want %v", roundTrip, got)
+			}
+		})
+	}
+}
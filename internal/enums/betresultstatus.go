@@ -18,8 +18,9 @@ func newBetResultType(value int, name string) BetResultType {
 
 // 使用构造函数定义枚举值
 var (
-	Loss = newBetResultType(0, "输")
-	Win  = newBetResultType(1, "赢")
+	Loss   = newBetResultType(0, "输")
+	Win    = newBetResultType(1, "赢")
+	Refund = newBetResultType(2, "退还")
 )
 
 // GetBetResultType 通过 value 获取枚举项
@@ -18,12 +18,26 @@ func newBotPrivateChatStatus(value string, name string) BotPrivateChatStatus {
 
 // 使用构造函数定义枚举值等
 var (
-	WaitGameDrawCycle         = newBotPrivateChatStatus("WAIT_GAME_DRAW_CYCLE", "开奖周期设置")
-	WaitQueryUser             = newBotPrivateChatStatus("WAIT_QUERY_USER", "查询用户信息")
-	WaitUpdateUserBalance     = newBotPrivateChatStatus("WAIT_UPDATE_USER_BALANCE", "修改用户积分")
-	WaitQuickThereSimpleOdds  = newBotPrivateChatStatus("WAIT_QUICK_THERE_SIMPLE_ODDS", "快三简易倍率")
-	WaitQuickThereTripletOdds = newBotPrivateChatStatus("WAIT_QUICK_THERE_TRIPLET_ODDS", "快三豹子倍率")
-	WaitTransferBalance       = newBotPrivateChatStatus("WAIT_TRANSFER_BALANCE", "转让用户积分")
+	WaitGameDrawCycle                 = newBotPrivateChatStatus("WAIT_GAME_DRAW_CYCLE", "开奖周期设置")
+	WaitQueryUser                     = newBotPrivateChatStatus("WAIT_QUERY_USER", "查询用户信息")
+	WaitUpdateUserBalance             = newBotPrivateChatStatus("WAIT_UPDATE_USER_BALANCE", "修改用户积分")
+	WaitQuickThereSimpleOdds          = newBotPrivateChatStatus("WAIT_QUICK_THERE_SIMPLE_ODDS", "快三简易倍率")
+	WaitQuickThereTripletOdds         = newBotPrivateChatStatus("WAIT_QUICK_THERE_TRIPLET_ODDS", "快三豹子倍率")
+	WaitQuickThereSumOdds             = newBotPrivateChatStatus("WAIT_QUICK_THERE_SUM_ODDS", "快三和值倍率")
+	WaitQuickThereComboOdds           = newBotPrivateChatStatus("WAIT_QUICK_THERE_COMBO_ODDS", "快三组合倍率")
+	WaitQuickThereSpecificTripletOdds = newBotPrivateChatStatus("WAIT_QUICK_THERE_SPECIFIC_TRIPLET_ODDS", "快三指定豹子倍率")
+	WaitQuickTherePairOdds            = newBotPrivateChatStatus("WAIT_QUICK_THERE_PAIR_ODDS", "快三对子倍率")
+	WaitQuickThereSpecificPairOdds    = newBotPrivateChatStatus("WAIT_QUICK_THERE_SPECIFIC_PAIR_ODDS", "快三指定对子倍率")
+	WaitQuickThereNumberOdds          = newBotPrivateChatStatus("WAIT_QUICK_THERE_NUMBER_ODDS", "快三单号倍率")
+	WaitQuickTherePoolRakeRate        = newBotPrivateChatStatus("WAIT_QUICK_THERE_POOL_RAKE_RATE", "快三奖池抽成比例")
+	WaitGuessPointPointOdds           = newBotPrivateChatStatus("WAIT_GUESS_POINT_POINT_ODDS", "猜点数点数倍率")
+	WaitGuessPointSimpleOdds          = newBotPrivateChatStatus("WAIT_GUESS_POINT_SIMPLE_ODDS", "猜点数简易倍率")
+	WaitEmojiGameOdds                 = newBotPrivateChatStatus("WAIT_EMOJI_GAME_ODDS", "表情骰子玩法倍率")
+	WaitHighestRollEntryFee           = newBotPrivateChatStatus("WAIT_HIGHEST_ROLL_ENTRY_FEE", "比大小参与费用")
+	WaitDuelFeeRate                   = newBotPrivateChatStatus("WAIT_DUEL_FEE_RATE", "对决抽成比例")
+	WaitBetCloseSeconds               = newBotPrivateChatStatus("WAIT_BET_CLOSE_SECONDS", "开奖前封盘时间")
+	WaitDrawSchedule                  = newBotPrivateChatStatus("WAIT_DRAW_SCHEDULE", "开奖计划")
+	WaitTransferBalance               = newBotPrivateChatStatus("WAIT_TRANSFER_BALANCE", "转让用户积分")
 )
 
 // GetBotPrivateChatStatus 通过 value 获取枚举项
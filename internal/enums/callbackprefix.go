@@ -18,26 +18,45 @@ func newCallbackPrefix(value string, name string) CallbackPrefix {
 
 // 使用构造函数定义枚举值
 var (
-	CallbackMainMenu                    = newCallbackPrefix("main_menu", "主菜单")
-	CallbackJoinedGroup                 = newCallbackPrefix("joined_group", "加入的群")
-	CallbackAdminGroup                  = newCallbackPrefix("admin_group", "管理的群")
-	CallbackAddAdminGroup               = newCallbackPrefix("add_admin_group", "添加管理的群")
-	CallbackAlreadyInvited              = newCallbackPrefix("already_invited", "已经邀请入群")
-	CallbackAlreadyReload               = newCallbackPrefix("already_reload", "群已经重新载入")
-	CallbackChatGroupConfig             = newCallbackPrefix("chat_group_config?", "群配置")
-	CallbackGameplayType                = newCallbackPrefix("gameplay_type?", "游戏类型")
-	CallbackUpdateGameplayType          = newCallbackPrefix("update_gameplay_type?", "更新游戏类型")
-	CallbackUpdateQuickThereSimpleOdds  = newCallbackPrefix("update_q_t_simple_odds?", "更新快三简易倍率")
-	CallbackUpdateQuickThereTripletOdds = newCallbackPrefix("update_q_t_triplet_odds?", "更新快三豹子倍率")
-	CallbackUpdateGameplayStatus        = newCallbackPrefix("update_gameplay_status?", "更新游戏类型状态")
-	CallbackUpdateGameDrawCycle         = newCallbackPrefix("update_game_draw_cycle?", "更新游戏开奖周期")
-	CallbackQueryChatGroupUser          = newCallbackPrefix("query_chat_group_user?", "查询群用户信息")
-	CallbackUpdateChatGroupUserBalance  = newCallbackPrefix("update_chat_group_user_balance?", "更新用户积分")
-	CallbackLotteryHistory              = newCallbackPrefix("lottery_history", "开奖历史")
-	CallbackChatGroupInfo               = newCallbackPrefix("chat_group_info?", "群详情信息")
-	CallbackTransferBalance             = newCallbackPrefix("transfer_balance?", "转让积分(用户)")
-	CallbackExitGroup                   = newCallbackPrefix("exit_group?", "退出群聊")
-	CallbackAdminExitGroup              = newCallbackPrefix("admin_exit_group?", "退出群聊")
+	CallbackMainMenu                            = newCallbackPrefix("main_menu", "主菜单")
+	CallbackJoinedGroup                         = newCallbackPrefix("joined_group", "加入的群")
+	CallbackAdminGroup                          = newCallbackPrefix("admin_group", "管理的群")
+	CallbackAddAdminGroup                       = newCallbackPrefix("add_admin_group", "添加管理的群")
+	CallbackAlreadyInvited                      = newCallbackPrefix("already_invited", "已经邀请入群")
+	CallbackAlreadyReload                       = newCallbackPrefix("already_reload", "群已经重新载入")
+	CallbackChatGroupConfig                     = newCallbackPrefix("chat_group_config?", "群配置")
+	CallbackGameplayType                        = newCallbackPrefix("gameplay_type?", "游戏类型")
+	CallbackUpdateGameplayType                  = newCallbackPrefix("update_gameplay_type?", "更新游戏类型")
+	CallbackUpdateQuickThereSimpleOdds          = newCallbackPrefix("update_q_t_simple_odds?", "更新快三简易倍率")
+	CallbackUpdateQuickThereTripletOdds         = newCallbackPrefix("update_q_t_triplet_odds?", "更新快三豹子倍率")
+	CallbackUpdateQuickThereSumOdds             = newCallbackPrefix("update_q_t_sum_odds?", "更新快三和值倍率")
+	CallbackUpdateQuickThereComboOdds           = newCallbackPrefix("update_q_t_combo_odds?", "更新快三组合倍率")
+	CallbackUpdateQuickThereSpecificTripletOdds = newCallbackPrefix("update_q_t_s_triplet_odds?", "更新快三指定豹子倍率")
+	CallbackUpdateQuickTherePairOdds            = newCallbackPrefix("update_q_t_pair_odds?", "更新快三对子倍率")
+	CallbackUpdateQuickThereSpecificPairOdds    = newCallbackPrefix("update_q_t_s_pair_odds?", "更新快三指定对子倍率")
+	CallbackUpdateQuickThereNumberOdds          = newCallbackPrefix("update_q_t_number_odds?", "更新快三单号倍率")
+	CallbackUpdateQuickThereTripleKill          = newCallbackPrefix("update_q_t_triple_kill?", "更新快三豹子通杀")
+	CallbackUpdateQuickThereOddsMode            = newCallbackPrefix("update_q_t_odds_mode?", "更新快三赔付模式")
+	CallbackUpdateQuickTherePoolRakeRate        = newCallbackPrefix("update_q_t_pool_rake_rate?", "更新快三奖池抽成比例")
+	CallbackUpdateDiceSource                    = newCallbackPrefix("update_dice_source?", "更新开奖骰子来源")
+	CallbackUpdateGuessPointPointOdds           = newCallbackPrefix("update_g_p_point_odds?", "更新猜点数点数倍率")
+	CallbackUpdateGuessPointSimpleOdds          = newCallbackPrefix("update_g_p_simple_odds?", "更新猜点数简易倍率")
+	CallbackUpdateEmojiGameOdds                 = newCallbackPrefix("update_e_g_odds?", "更新表情骰子玩法倍率")
+	CallbackUpdateHighestRollEntryFee           = newCallbackPrefix("update_h_r_entry_fee?", "更新比大小参与费用")
+	CallbackUpdateDuelFeeRate                   = newCallbackPrefix("update_duel_fee_rate?", "更新对决抽成比例")
+	CallbackAcceptDuel                          = newCallbackPrefix("accept_duel?", "接受对决")
+	CallbackDeclineDuel                         = newCallbackPrefix("decline_duel?", "拒绝对决")
+	CallbackUpdateGameplayStatus                = newCallbackPrefix("update_gameplay_status?", "更新游戏类型状态")
+	CallbackUpdateGameDrawCycle                 = newCallbackPrefix("update_game_draw_cycle?", "更新游戏开奖周期")
+	CallbackUpdateBetCloseSeconds               = newCallbackPrefix("update_bet_close_seconds?", "更新开奖前封盘时间")
+	CallbackUpdateDrawSchedule                  = newCallbackPrefix("update_draw_schedule?", "更新开奖计划")
+	CallbackQueryChatGroupUser                  = newCallbackPrefix("query_chat_group_user?", "查询群用户信息")
+	CallbackUpdateChatGroupUserBalance          = newCallbackPrefix("update_chat_group_user_balance?", "更新用户积分")
+	CallbackLotteryHistory                      = newCallbackPrefix("lottery_history", "开奖历史")
+	CallbackChatGroupInfo                       = newCallbackPrefix("chat_group_info?", "群详情信息")
+	CallbackTransferBalance                     = newCallbackPrefix("transfer_balance?", "转让积分(用户)")
+	CallbackExitGroup                           = newCallbackPrefix("exit_group?", "退出群聊")
+	CallbackAdminExitGroup                      = newCallbackPrefix("admin_exit_group?", "退出群聊")
 )
 
 // GetCallbackPrefix 通过 value 获取枚举项
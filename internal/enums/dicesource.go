@@ -0,0 +1,31 @@
+package enums
+
+// DiceSource 代表枚举的自定义类型 开奖骰子来源
+type DiceSource struct {
+	Value string
+	Name  string
+}
+
+// 枚举映射
+var DiceSourceMap = make(map[string]DiceSource)
+
+// 构造函数
+func newDiceSource(value string, name string) DiceSource {
+	enum := DiceSource{Value: value, Name: name}
+	DiceSourceMap[value] = enum
+	return enum
+}
+
+// 使用构造函数定义枚举值
+var (
+	DiceSourceTelegram     = newDiceSource("TELEGRAM", "Telegram骰子")
+	DiceSourceCrypto       = newDiceSource("CRYPTO", "系统随机")
+	DiceSourceProvablyFair = newDiceSource("PROVABLY_FAIR", "可验证随机")
+)
+
+// GetDiceSource 通过 value 获取枚举项
+func GetDiceSource(value string) (DiceSource, bool) {
+	enum, ok := DiceSourceMap[value]
+	return enum, ok
+
+}
@@ -0,0 +1,34 @@
+package enums
+
+// DuelStatus 代表枚举的自定义类型
+type DuelStatus struct {
+	Value string
+	Name  string
+}
+
+// 枚举映射
+var DuelStatusMap = make(map[string]DuelStatus)
+
+// 构造函数
+func newDuelStatus(value string, name string) DuelStatus {
+	enum := DuelStatus{Value: value, Name: name}
+	DuelStatusMap[value] = enum
+	return enum
+}
+
+// 使用构造函数定义枚举值
+var (
+	DuelPending  = newDuelStatus("PENDING", "待应战")
+	DuelAccepted = newDuelStatus("ACCEPTED", "对决中")
+	DuelFinished = newDuelStatus("FINISHED", "已结束")
+	DuelDeclined = newDuelStatus("DECLINED", "已拒绝")
+	DuelExpired  = newDuelStatus("EXPIRED", "已过期")
+	DuelCanceled = newDuelStatus("CANCELED", "已取消")
+)
+
+// GetDuelStatus 通过 value 获取枚举项
+func GetDuelStatus(value string) (DuelStatus, bool) {
+	enum, ok := DuelStatusMap[value]
+	return enum, ok
+
+}
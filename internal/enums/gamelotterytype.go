@@ -1,5 +1,7 @@
 package enums
 
+import "fmt"
+
 // GameLotteryType 代表枚举的自定义类型
 type GameLotteryType struct {
 	Value string
@@ -23,11 +25,67 @@ func newGameLotteryType(value string, name string) GameLotteryType {
 
 // 使用构造函数定义枚举值等
 var (
-	Big     = newGameLotteryType("BIG", "大")
-	Small   = newGameLotteryType("SMALL", "小")
-	Single  = newGameLotteryType("SINGLE", "单")
-	Double  = newGameLotteryType("DOUBLE", "双")
-	Triplet = newGameLotteryType("TRIPLET", "豹子")
+	Big         = newGameLotteryType("BIG", "大")
+	Small       = newGameLotteryType("SMALL", "小")
+	Single      = newGameLotteryType("SINGLE", "单")
+	Double      = newGameLotteryType("DOUBLE", "双")
+	Triplet     = newGameLotteryType("TRIPLET", "豹子")
+	BigSingle   = newGameLotteryType("BIG_SINGLE", "大单")
+	BigDouble   = newGameLotteryType("BIG_DOUBLE", "大双")
+	SmallSingle = newGameLotteryType("SMALL_SINGLE", "小单")
+	SmallDouble = newGameLotteryType("SMALL_DOUBLE", "小双")
+	Triplet1    = newGameLotteryType("TRIPLET_1", "豹子1")
+	Triplet2    = newGameLotteryType("TRIPLET_2", "豹子2")
+	Triplet3    = newGameLotteryType("TRIPLET_3", "豹子3")
+	Triplet4    = newGameLotteryType("TRIPLET_4", "豹子4")
+	Triplet5    = newGameLotteryType("TRIPLET_5", "豹子5")
+	Triplet6    = newGameLotteryType("TRIPLET_6", "豹子6")
+	Pair        = newGameLotteryType("PAIR", "对子")
+	Pair1       = newGameLotteryType("PAIR_1", "对子1")
+	Pair2       = newGameLotteryType("PAIR_2", "对子2")
+	Pair3       = newGameLotteryType("PAIR_3", "对子3")
+	Pair4       = newGameLotteryType("PAIR_4", "对子4")
+	Pair5       = newGameLotteryType("PAIR_5", "对子5")
+	Pair6       = newGameLotteryType("PAIR_6", "对子6")
+	Number1     = newGameLotteryType("NUMBER_1", "号1")
+	Number2     = newGameLotteryType("NUMBER_2", "号2")
+	Number3     = newGameLotteryType("NUMBER_3", "号3")
+	Number4     = newGameLotteryType("NUMBER_4", "号4")
+	Number5     = newGameLotteryType("NUMBER_5", "号5")
+	Number6     = newGameLotteryType("NUMBER_6", "号6")
+	Point1      = newGameLotteryType("POINT_1", "点1")
+	Point2      = newGameLotteryType("POINT_2", "点2")
+	Point3      = newGameLotteryType("POINT_3", "点3")
+	Point4      = newGameLotteryType("POINT_4", "点4")
+	Point5      = newGameLotteryType("POINT_5", "点5")
+	Point6      = newGameLotteryType("POINT_6", "点6")
+	Goal        = newGameLotteryType("GOAL", "进球")
+	Miss        = newGameLotteryType("MISS", "未进")
+	Bullseye    = newGameLotteryType("BULLSEYE", "靶心")
+	OnTarget    = newGameLotteryType("ON_TARGET", "中环")
+	OffTarget   = newGameLotteryType("OFF_TARGET", "脱靶")
+	Strike      = newGameLotteryType("STRIKE", "全中")
+	Gutter      = newGameLotteryType("GUTTER", "洗沟")
+	Slot777     = newGameLotteryType("SLOT_777", "777")
+	SlotTriple  = newGameLotteryType("SLOT_TRIPLE", "三连")
+	SlotDouble  = newGameLotteryType("SLOT_DOUBLE", "两连")
+	Join        = newGameLotteryType("JOIN", "参与")
+	Sum3        = newGameLotteryType("SUM_3", "和3")
+	Sum4        = newGameLotteryType("SUM_4", "和4")
+	Sum5        = newGameLotteryType("SUM_5", "和5")
+	Sum6        = newGameLotteryType("SUM_6", "和6")
+	Sum7        = newGameLotteryType("SUM_7", "和7")
+	Sum8        = newGameLotteryType("SUM_8", "和8")
+	Sum9        = newGameLotteryType("SUM_9", "和9")
+	Sum10       = newGameLotteryType("SUM_10", "和10")
+	Sum11       = newGameLotteryType("SUM_11", "和11")
+	Sum12       = newGameLotteryType("SUM_12", "和12")
+	Sum13       = newGameLotteryType("SUM_13", "和13")
+	Sum14       = newGameLotteryType("SUM_14", "和14")
+	Sum15       = newGameLotteryType("SUM_15", "和15")
+	Sum16       = newGameLotteryType("SUM_16", "和16")
+	Sum17       = newGameLotteryType("SUM_17", "和17")
+	Sum18       = newGameLotteryType("SUM_18", "和18")
 )
 
 // GetGameLotteryType 通过 value 获取枚举项
@@ -41,3 +99,28 @@ func GetGameLotteryTypeForName(name string) (GameLotteryType, bool) {
 	enum, ok := GameLotteryTypeMapForName[name]
 	return enum, ok
 }
+
+// GetSumGameLotteryType 通过和值获取枚举项
+func GetSumGameLotteryType(total int) (GameLotteryType, bool) {
+	return GetGameLotteryType(fmt.Sprintf("SUM_%d", total))
+}
+
+// GetSpecificTripletGameLotteryType 通过点数获取指定豹子枚举项
+func GetSpecificTripletGameLotteryType(value int) (GameLotteryType, bool) {
+	return GetGameLotteryType(fmt.Sprintf("TRIPLET_%d", value))
+}
+
+// GetSpecificPairGameLotteryType 通过点数获取指定对子枚举项
+func GetSpecificPairGameLotteryType(value int) (GameLotteryType, bool) {
+	return GetGameLotteryType(fmt.Sprintf("PAIR_%d", value))
+}
+
+// GetNumberGameLotteryType 通过点数获取单号枚举项
+func GetNumberGameLotteryType(value int) (GameLotteryType, bool) {
+	return GetGameLotteryType(fmt.Sprintf("NUMBER_%d", value))
+}
+
+// GetPointGameLotteryType 通过点数获取猜点数枚举项
+func GetPointGameLotteryType(value int) (GameLotteryType, bool) {
+	return GetGameLotteryType(fmt.Sprintf("POINT_%d", value))
+}
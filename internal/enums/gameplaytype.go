@@ -9,16 +9,27 @@ type GameplayType struct {
 // 枚举映射
 var GameplayTypeMap = make(map[string]GameplayType)
 
+// 按定义顺序排列的枚举项
+var GameplayTypeList []GameplayType
+
 // 构造函数
 func newGameplayType(value string, name string) GameplayType {
 	enum := GameplayType{Value: value, Name: name}
 	GameplayTypeMap[value] = enum
+	GameplayTypeList = append(GameplayTypeList, enum)
 	return enum
 }
 
 // 使用构造函数定义枚举值
 var (
-	QuickThere = newGameplayType("QUICK_THERE", "经典快三")
+	QuickThere  = newGameplayType("QUICK_THERE", "经典快三")
+	GuessPoint  = newGameplayType("GUESS_POINT", "猜点数")
+	Football    = newGameplayType("FOOTBALL", "⚽足球射门")
+	Basketball  = newGameplayType("BASKETBALL", "🏀篮球投篮")
+	Darts       = newGameplayType("DARTS", "🎯飞镖")
+	Bowling     = newGameplayType("BOWLING", "🎳保龄球")
+	SlotMachine = newGameplayType("SLOT_MACHINE", "🎰老虎机")
+	HighestRoll = newGameplayType("HIGHEST_ROLL", "🎲比大小")
 	//_          = newGameplayType("UNDEFINED1", "未定义玩法1")
 	//_          = newGameplayType("UNDEFINED2", "未定义玩法2")
 )
@@ -0,0 +1,40 @@
+package enums
+
+// LedgerReasonType 代表枚举的自定义类型 积分流水类型
+type LedgerReasonType struct {
+	Value string
+	Name  string
+}
+
+// 枚举映射
+var LedgerReasonTypeMap = make(map[string]LedgerReasonType)
+
+// 构造函数
+func newLedgerReasonType(value string, name string) LedgerReasonType {
+	enum := LedgerReasonType{Value: value, Name: name}
+	LedgerReasonTypeMap[value] = enum
+	return enum
+}
+
+// 使用构造函数定义枚举值
+var (
+	LedgerOpening     = newLedgerReasonType("OPENING", "期初余额")
+	LedgerRegister    = newLedgerReasonType("REGISTER", "注册奖励")
+	LedgerSignIn      = newLedgerReasonType("SIGN_IN", "签到奖励")
+	LedgerBet         = newLedgerReasonType("BET", "下注")
+	LedgerBetWin      = newLedgerReasonType("BET_WIN", "竞猜派彩")
+	LedgerBetRefund   = newLedgerReasonType("BET_REFUND", "作废退还")
+	LedgerDuelStake   = newLedgerReasonType("DUEL_STAKE", "对决押注")
+	LedgerDuelWin     = newLedgerReasonType("DUEL_WIN", "对决获胜")
+	LedgerDuelRefund  = newLedgerReasonType("DUEL_REFUND", "对决退还")
+	LedgerTransferOut = newLedgerReasonType("TRANSFER_OUT", "转出积分")
+	LedgerTransferIn  = newLedgerReasonType("TRANSFER_IN", "转入积分")
+	LedgerAdminAdjust = newLedgerReasonType("ADMIN_ADJUST", "管理员修改")
+)
+
+// GetLedgerReasonType 通过 value 获取枚举项
+func GetLedgerReasonType(value string) (LedgerReasonType, bool) {
+	enum, ok := LedgerReasonTypeMap[value]
+	return enum, ok
+
+}
@@ -0,0 +1,30 @@
+package enums
+
+// LotteryStatus 代表枚举的自定义类型 开奖状态
+type LotteryStatus struct {
+	Value int
+	Name  string
+}
+
+// 枚举映射
+var LotteryStatusMap = make(map[int]LotteryStatus)
+
+// 构造函数
+func newLotteryStatus(value int, name string) LotteryStatus {
+	enum := LotteryStatus{Value: value, Name: name}
+	LotteryStatusMap[value] = enum
+	return enum
+}
+
+// 使用构造函数定义枚举值
+var (
+	LotteryDrawn    = newLotteryStatus(0, "已开奖")
+	LotteryCanceled = newLotteryStatus(1, "已作废")
+)
+
+// GetLotteryStatus 通过 value 获取枚举项
+func GetLotteryStatus(value int) (LotteryStatus, bool) {
+	enum, ok := LotteryStatusMap[value]
+	return enum, ok
+
+}
@@ -0,0 +1,30 @@
+package enums
+
+// OddsMode 代表枚举的自定义类型 赔付模式
+type OddsMode struct {
+	Value string
+	Name  string
+}
+
+// 枚举映射
+var OddsModeMap = make(map[string]OddsMode)
+
+// 构造函数
+func newOddsMode(value string, name string) OddsMode {
+	enum := OddsMode{Value: value, Name: name}
+	OddsModeMap[value] = enum
+	return enum
+}
+
+// 使用构造函数定义枚举值
+var (
+	OddsModeFixed = newOddsMode("FIXED", "固定倍率")
+	OddsModePool  = newOddsMode("POOL", "奖池分成")
+)
+
+// GetOddsMode 通过 value 获取枚举项
+func GetOddsMode(value string) (OddsMode, bool) {
+	enum, ok := OddsModeMap[value]
+	return enum, ok
+
+}
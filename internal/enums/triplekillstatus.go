@@ -0,0 +1,30 @@
+package enums
+
+// TripleKillStatus 代表枚举的自定义类型 快三豹子通杀(开出豹子时大/小/单/双及组合不中奖)
+type TripleKillStatus struct {
+	Value int
+	Name  string
+}
+
+// 枚举映射
+var TripleKillStatusMap = make(map[int]TripleKillStatus)
+
+// 构造函数
+func newTripleKillStatus(value int, name string) TripleKillStatus {
+	enum := TripleKillStatus{Value: value, Name: name}
+	TripleKillStatusMap[value] = enum
+	return enum
+}
+
+// 使用构造函数定义枚举值
+var (
+	TripleKillOFF = newTripleKillStatus(0, "❌已关闭")
+	TripleKillON  = newTripleKillStatus(1, "✅已开启")
+)
+
+// GetTripleKillStatus 通过 value 获取枚举项
+func GetTripleKillStatus(value int) (TripleKillStatus, bool) {
+	enum, ok := TripleKillStatusMap[value]
+	return enum, ok
+
+}
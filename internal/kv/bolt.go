@@ -0,0 +1,164 @@
+package kv
+
+import (
+	"encoding/binary"
+	bolt "go.etcd.io/bbolt"
+	"time"
+)
+
+var boltBucket = []byte("state")
+
+// 过期时间占用的字节数 值的前8字节为过期时间(UnixNano) 0为不过期
+const boltExpireAtSize = 8
+
+type boltStore struct {
+	db     *bolt.DB
+	stopCh chan struct{}
+}
+
+// OpenBoltStore BoltDB 文件存储 重启后期号等状态不丢失 仅适合单实例部署
+func OpenBoltStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &boltStore{db: db, stopCh: make(chan struct{})}
+	// 启动时先清理上次运行遗留的过期键
+	if err = s.deleteExpired(time.Now()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	go s.sweep()
+	return s, nil
+}
+
+// sweep 定期清理过期键 读取时只判断过期不删除
+func (s *boltStore) sweep() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case now := <-ticker.C:
+			_ = s.deleteExpired(now)
+		}
+	}
+}
+
+func (s *boltStore) deleteExpired(now time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		var expiredKeys [][]byte
+		err := bucket.ForEach(func(key, value []byte) error {
+			if _, ok := decodeBoltValue(value, now); !ok {
+				expiredKeys = append(expiredKeys, append([]byte(nil), key...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range expiredKeys {
+			if err = bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func encodeBoltValue(value string, expiration time.Duration) []byte {
+	data := make([]byte, boltExpireAtSize+len(value))
+	if at := expireAt(expiration); !at.IsZero() {
+		binary.BigEndian.PutUint64(data, uint64(at.UnixNano()))
+	}
+	copy(data[boltExpireAtSize:], value)
+	return data
+}
+
+// decodeBoltValue 解析值 已过期或格式错误时返回 false
+func decodeBoltValue(data []byte, now time.Time) (string, bool) {
+	if len(data) < boltExpireAtSize {
+		return "", false
+	}
+	var at time.Time
+	if nano := binary.BigEndian.Uint64(data); nano != 0 {
+		at = time.Unix(0, int64(nano))
+	}
+	if expired(at, now) {
+		return "", false
+	}
+	return string(data[boltExpireAtSize:]), true
+}
+
+func (s *boltStore) Get(key string) (string, error) {
+	var value string
+	var ok bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltBucket).Get([]byte(key))
+		if data != nil {
+			value, ok = decodeBoltValue(data, time.Now())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrNil
+	}
+	return value, nil
+}
+
+func (s *boltStore) Set(key string, value string, expiration time.Duration) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), encodeBoltValue(value, expiration))
+	})
+}
+
+func (s *boltStore) SetNX(key string, value string, expiration time.Duration) (bool, error) {
+	set := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		if data := bucket.Get([]byte(key)); data != nil {
+			if _, ok := decodeBoltValue(data, time.Now()); ok {
+				return nil
+			}
+		}
+		set = true
+		return bucket.Put([]byte(key), encodeBoltValue(value, expiration))
+	})
+	if err != nil {
+		return false, err
+	}
+	return set, nil
+}
+
+func (s *boltStore) Del(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(key))
+	})
+}
+
+func (s *boltStore) Exists(key string) (bool, error) {
+	_, err := s.Get(key)
+	if err == ErrNil {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *boltStore) Close() error {
+	close(s.stopCh)
+	return s.db.Close()
+}
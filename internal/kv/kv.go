@@ -0,0 +1,35 @@
+package kv
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNil 键不存在或已过期
+var ErrNil = errors.New("键不存在")
+
+// 内置存储清理过期键的间隔
+const sweepInterval = time.Minute
+
+// Store 键值存储 保存期号、按钮回调数据、私聊状态等运行状态 expiration 为0时不过期
+type Store interface {
+	Get(key string) (string, error)
+	Set(key string, value string, expiration time.Duration) error
+	// SetNX 键不存在时才写入 返回是否写入成功
+	SetNX(key string, value string, expiration time.Duration) (bool, error)
+	Del(key string) error
+	Exists(key string) (bool, error)
+	Close() error
+}
+
+// expireAt 过期时间 expiration 为0时返回零值表示不过期
+func expireAt(expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(expiration)
+}
+
+func expired(expireAt time.Time, now time.Time) bool {
+	return !expireAt.IsZero() && !now.Before(expireAt)
+}
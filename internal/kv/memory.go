@@ -0,0 +1,103 @@
+package kv
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value    string
+	expireAt time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	stopCh  chan struct{}
+}
+
+// NewMemoryStore 进程内存储 重启后状态丢失 仅适合单实例部署
+func NewMemoryStore() Store {
+	s := &memoryStore{
+		entries: make(map[string]memoryEntry),
+		stopCh:  make(chan struct{}),
+	}
+	go s.sweep()
+	return s
+}
+
+// sweep 定期清理过期键 避免只写不读的键常驻内存
+func (s *memoryStore) sweep() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for key, entry := range s.entries {
+				if expired(entry.expireAt, now) {
+					delete(s.entries, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// get 调用方需持有锁
+func (s *memoryStore) get(key string) (memoryEntry, bool) {
+	entry, ok := s.entries[key]
+	if ok && expired(entry.expireAt, time.Now()) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, ok
+}
+
+func (s *memoryStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.get(key)
+	if !ok {
+		return "", ErrNil
+	}
+	return entry.value, nil
+}
+
+func (s *memoryStore) Set(key string, value string, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryEntry{value: value, expireAt: expireAt(expiration)}
+	return nil
+}
+
+func (s *memoryStore) SetNX(key string, value string, expiration time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.get(key); ok {
+		return false, nil
+	}
+	s.entries[key] = memoryEntry{value: value, expireAt: expireAt(expiration)}
+	return true, nil
+}
+
+func (s *memoryStore) Del(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memoryStore) Exists(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.get(key)
+	return ok, nil
+}
+
+func (s *memoryStore) Close() error {
+	close(s.stopCh)
+	return nil
+}
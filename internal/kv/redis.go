@@ -0,0 +1,45 @@
+package kv
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+type redisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore Redis 存储 多实例部署时共享状态
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Get(key string) (string, error) {
+	value, err := s.client.Get(s.client.Context(), key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNil
+	}
+	return value, err
+}
+
+func (s *redisStore) Set(key string, value string, expiration time.Duration) error {
+	return s.client.Set(s.client.Context(), key, value, expiration).Err()
+}
+
+func (s *redisStore) SetNX(key string, value string, expiration time.Duration) (bool, error) {
+	return s.client.SetNX(s.client.Context(), key, value, expiration).Result()
+}
+
+func (s *redisStore) Del(key string) error {
+	return s.client.Del(s.client.Context(), key).Err()
+}
+
+func (s *redisStore) Exists(key string) (bool, error) {
+	count, err := s.client.Exists(s.client.Context(), key).Result()
+	return count > 0, err
+}
+
+func (s *redisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/utils"
+)
+
+// BalanceLedger 积分流水 用户积分的每次变动都在同一事务中记录一条流水
+type BalanceLedger struct {
+	Id              string          `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupUserId string          `json:"chat_group_user_id" gorm:"type:varchar(64);not null;index"`
+	ChatGroupId     string          `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	Amount          decimal.Decimal `json:"amount" gorm:"type:decimal(20, 2);not null"`        // 变动积分 增加为正 扣除为负
+	BalanceAfter    decimal.Decimal `json:"balance_after" gorm:"type:decimal(20, 2);not null"` // 变动后积分余额
+	ReasonType      string          `json:"reason_type" gorm:"type:varchar(64);not null"`      // 流水类型 enums.LedgerReasonType
+	ReferenceId     string          `json:"reference_id" gorm:"type:varchar(64);default:null"` // 关联ID 下注/期号/转让/管理员等
+	CreateTime      string          `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+// BalanceLedgerSummary 用户积分余额与流水合计
+type BalanceLedgerSummary struct {
+	ChatGroupUserId string
+	ChatGroupId     string
+	Balance         decimal.Decimal
+	LedgerAmount    decimal.Decimal // 流水变动积分合计
+	LedgerCount     int64           // 流水条数
+}
+
+func (c *BalanceLedger) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *BalanceLedger) CountByChatGroupUserId(db *gorm.DB) (int64, error) {
+	var count int64
+	result := db.Model(&BalanceLedger{}).Where("chat_group_user_id = ?", c.ChatGroupUserId).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// ListBalanceLedgerSummaries 所有用户的积分余额与流水合计
+func ListBalanceLedgerSummaries(db *gorm.DB) ([]*BalanceLedgerSummary, error) {
+	var balanceLedgerSummaries []*BalanceLedgerSummary
+
+	result := db.Model(&ChatGroupUser{}).
+		Select("chat_group_users.id as chat_group_user_id, chat_group_users.chat_group_id, chat_group_users.balance, " +
+			"coalesce(sum(balance_ledgers.amount), 0) as ledger_amount, count(balance_ledgers.id) as ledger_count").
+		Joins("left join balance_ledgers on balance_ledgers.chat_group_user_id = chat_group_users.id").
+		Group("chat_group_users.id, chat_group_users.chat_group_id, chat_group_users.balance").
+		Scan(&balanceLedgerSummaries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return balanceLedgerSummaries, nil
+}
@@ -44,3 +44,15 @@ func (c *BetRecord) ListByChatGroupUserId(db *gorm.DB) ([]*BetRecord, error) {
 
 	return betRecords, nil
 }
+
+// ListChatGroupUserIdsByChatGroupIdAndIssueNumber 该期下注的用户ID 已去重
+func (c *BetRecord) ListChatGroupUserIdsByChatGroupIdAndIssueNumber(db *gorm.DB) ([]string, error) {
+	var chatGroupUserIds []string
+
+	result := db.Model(&BetRecord{}).Where("chat_group_id = ? and issue_number = ?", c.ChatGroupId, c.IssueNumber).Distinct().Pluck("chat_group_user_id", &chatGroupUserIds)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return chatGroupUserIds, nil
+}
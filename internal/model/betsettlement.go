@@ -0,0 +1,29 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+)
+
+// BetSettlement 玩法下注明细的结算结果
+type BetSettlement struct {
+	SettleStatus    int
+	BetResultType   int
+	BetResultAmount decimal.Decimal
+	UpdateTime      string
+}
+
+// SettleBetById 仅当下注明细仍为 unsettledStatus 时写入结算结果 betModel 为玩法下注明细表模型 如 &QuickThereBetRecord{}
+// 返回 false 表示该记录已被其他事务结算
+func SettleBetById(db *gorm.DB, betModel interface{}, id string, unsettledStatus int, settlement *BetSettlement) (bool, error) {
+	result := db.Model(betModel).Where("id = ? and settle_status = ?", id, unsettledStatus).Updates(map[string]interface{}{
+		"settle_status":     settlement.SettleStatus,
+		"bet_result_type":   settlement.BetResultType,
+		"bet_result_amount": settlement.BetResultAmount,
+		"update_time":       settlement.UpdateTime,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
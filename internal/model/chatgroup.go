@@ -3,18 +3,25 @@ package model
 import (
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
 	"telegram-dice-bot/internal/utils"
 )
 
 type ChatGroup struct {
-	Id               string `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
-	TgChatGroupTitle string `json:"tg_chat_group_title" gorm:"type:varchar(900);not null"`
-	TgChatGroupId    int64  `json:"tg_chat_group_id" gorm:"type:bigint(20);not null"`
-	GameplayType     string `json:"gameplay_type" gorm:"type:varchar(255);not null"`
-	GameDrawCycle    int    `json:"game_draw_cycle" gorm:"type:int(11);not null"`
-	GameplayStatus   int    `json:"gameplay_status" gorm:"type:int(11);not null"`
-	ChatGroupStatus  string `json:"chat_group_status" gorm:"type:varchar(255);not null"`
-	CreateTime       string `json:"create_time" gorm:"type:varchar(255);not null"`
+	Id               string          `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	TgChatGroupTitle string          `json:"tg_chat_group_title" gorm:"type:varchar(900);not null"`
+	TgChatGroupId    int64           `json:"tg_chat_group_id" gorm:"type:bigint(20);not null"`
+	GameplayType     string          `json:"gameplay_type" gorm:"type:varchar(255);not null"`
+	GameDrawCycle    int             `json:"game_draw_cycle" gorm:"type:int(11);not null"`
+	GameplayStatus   int             `json:"gameplay_status" gorm:"type:int(11);not null"`
+	ChatGroupStatus  string          `json:"chat_group_status" gorm:"type:varchar(255);not null"`
+	DuelFeeRate      decimal.Decimal `json:"duel_fee_rate" gorm:"type:decimal(5, 2);not null;default:0"`    // 对决抽成比例(%)
+	DiceSource       string          `json:"dice_source" gorm:"type:varchar(64);not null;default:TELEGRAM"` // 开奖骰子来源 enums.DiceSource
+	BetCloseSeconds  int             `json:"bet_close_seconds" gorm:"type:int(11);not null;default:0"`      // 开奖前停止下注的秒数 0为开奖前不封盘
+	DrawSchedule     string          `json:"draw_schedule" gorm:"type:varchar(255);not null;default:''"`    // 开奖 cron 表达式(分 时 日 月 周) 为空时按开奖周期开奖
+	QuietHours       string          `json:"quiet_hours" gorm:"type:varchar(255);not null;default:''"`      // 静默时段 例: 23:00-09:00,12:00-13:00 时段内不开奖
+	Timezone         string          `json:"timezone" gorm:"type:varchar(64);not null;default:''"`          // 开奖计划时区 例: Asia/Shanghai 为空时使用服务器时区
+	CreateTime       string          `json:"create_time" gorm:"type:varchar(255);not null"`
 }
 
 func (c *ChatGroup) Create(db *gorm.DB) error {
@@ -75,24 +82,9 @@ func QueryChatGroupById(db *gorm.DB, id string) (*ChatGroup, error) {
 	return chatGroup, nil
 }
 
-func UpdateChatGroupGameplayTypeById(db *gorm.DB, chatGroup *ChatGroup) error {
-	result := db.Model(&chatGroup).Select("gameplay_type").Updates(chatGroup)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
-}
-
-func (c *ChatGroup) UpdateGameDrawCycleById(db *gorm.DB) error {
-	result := db.Model(&c).Select("game_draw_cycle").Updates(c)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
-}
-
-func (c *ChatGroup) UpdateChatGroupStatusById(db *gorm.DB) error {
-	result := db.Model(&c).Select("gameplay_status").Updates(c)
+// UpdateChatGroupColumnsById 按 Id 更新指定字段 columns 为数据库字段名
+func UpdateChatGroupColumnsById(db *gorm.DB, chatGroup *ChatGroup, columns ...string) error {
+	result := db.Model(&ChatGroup{}).Where("id = ?", chatGroup.Id).Select(columns).Updates(chatGroup)
 	if result.Error != nil {
 		return result.Error
 	}
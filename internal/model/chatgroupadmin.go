@@ -53,10 +53,10 @@ func ListChatGroupAdminByAdminTgUserId(db *gorm.DB, adminTgUserId int64) ([]*Cha
 	return chatGroupAdmins, nil
 }
 
-func DeleteChatGroupAdminByChatGroupId(db *gorm.DB, chatGroupId string) {
-	db.Where("chat_group_id = ?", chatGroupId).Delete(&ChatGroupAdmin{})
+func DeleteChatGroupAdminByChatGroupId(db *gorm.DB, chatGroupId string) error {
+	return db.Where("chat_group_id = ?", chatGroupId).Delete(&ChatGroupAdmin{}).Error
 }
 
-func (c *ChatGroupAdmin) DeleteByChatGroupIdAndAdminTgUserId(db *gorm.DB) {
-	db.Where("chat_group_id = ? and admin_tg_user_id = ?", c.ChatGroupId, c.AdminTgUserId).Delete(&ChatGroupAdmin{})
+func (c *ChatGroupAdmin) DeleteByChatGroupIdAndAdminTgUserId(db *gorm.DB) error {
+	return db.Where("chat_group_id = ? and admin_tg_user_id = ?", c.ChatGroupId, c.AdminTgUserId).Delete(&ChatGroupAdmin{}).Error
 }
@@ -3,18 +3,19 @@ package model
 import (
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
 	"telegram-dice-bot/internal/utils"
 )
 
 type ChatGroupUser struct {
-	Id          string  `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
-	TgUserId    int64   `json:"tg_user_id" gorm:"type:bigint(20);not null"` // Telegram 用户ID
-	ChatGroupId string  `json:"chat_group_id" gorm:"type:varchar(64);not null;"`
-	IsLeft      int     `json:"is_left" gorm:"type:int(64);not null;"`      // 是否离开群组
-	Username    string  `json:"username" gorm:"type:varchar(500);not null"` // Telegram 用户名
-	Balance     float64 `json:"balance" gorm:"type:decimal(20, 2);not null"`
-	SignInTime  string  `json:"sign_in_time" gorm:"type:varchar(500)"` // 签到时间
-	CreateTime  string  `json:"create_time" gorm:"type:varchar(255);not null"`
+	Id          string          `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	TgUserId    int64           `json:"tg_user_id" gorm:"type:bigint(20);not null"` // Telegram 用户ID
+	ChatGroupId string          `json:"chat_group_id" gorm:"type:varchar(64);not null;"`
+	IsLeft      int             `json:"is_left" gorm:"type:int(64);not null;"`      // 是否离开群组
+	Username    string          `json:"username" gorm:"type:varchar(500);not null"` // Telegram 用户名
+	Balance     decimal.Decimal `json:"balance" gorm:"type:decimal(20, 2);not null"`
+	SignInTime  string          `json:"sign_in_time" gorm:"type:varchar(500)"` // 签到时间
+	CreateTime  string          `json:"create_time" gorm:"type:varchar(255);not null"`
 }
 
 func (c *ChatGroupUser) Create(db *gorm.DB) error {
@@ -87,3 +88,48 @@ func (c *ChatGroupUser) QueryByIdAndChatGroupId(db *gorm.DB) (*ChatGroupUser, er
 	}
 	return chatGroupUser, nil
 }
+
+// AddBalanceById 按增量更新积分余额 多个进程同时结算时不会覆盖彼此的更新
+func (c *ChatGroupUser) AddBalanceById(db *gorm.DB, amount decimal.Decimal) error {
+	result := db.Model(&ChatGroupUser{}).Where("id = ?", c.Id).Update("balance", gorm.Expr("balance + ?", amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// DeductBalanceById 按增量扣除积分余额 余额不足时不扣除并返回false
+func (c *ChatGroupUser) DeductBalanceById(db *gorm.DB, amount decimal.Decimal) (bool, error) {
+	result := db.Model(&ChatGroupUser{}).Where("id = ? AND balance >= ?", c.Id, amount).Update("balance", gorm.Expr("balance - ?", amount))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateUsernameById 只更新用户名 不覆盖积分余额
+func (c *ChatGroupUser) UpdateUsernameById(db *gorm.DB) error {
+	result := db.Model(&ChatGroupUser{}).Where("id = ?", c.Id).Update("username", c.Username)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// UpdateIsLeftById 只更新是否离开群组的状态 不覆盖积分余额
+func (c *ChatGroupUser) UpdateIsLeftById(db *gorm.DB) error {
+	result := db.Model(&ChatGroupUser{}).Where("id = ?", c.Id).Update("is_left", c.IsLeft)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// UpdateSignInTimeById 只更新签到时间 不覆盖积分余额
+func (c *ChatGroupUser) UpdateSignInTimeById(db *gorm.DB) error {
+	result := db.Model(&ChatGroupUser{}).Where("id = ?", c.Id).Update("sign_in_time", c.SignInTime)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
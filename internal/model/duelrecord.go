@@ -0,0 +1,112 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/utils"
+)
+
+// DuelRecord 玩家对决记录
+type DuelRecord struct {
+	Id              string          `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupId     string          `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	ChallengerId    string          `json:"challenger_id" gorm:"type:varchar(64);not null"` // 发起方 ChatGroupUserId
+	OpponentId      string          `json:"opponent_id" gorm:"type:varchar(64);not null"`   // 应战方 ChatGroupUserId
+	BetAmount       decimal.Decimal `json:"bet_amount" gorm:"type:decimal(20, 2);not null"` // 每方赌注
+	FeeRate         decimal.Decimal `json:"fee_rate" gorm:"type:decimal(5, 2);not null"`    // 发起时的抽成比例(%)
+	FeeAmount       decimal.Decimal `json:"fee_amount" gorm:"type:decimal(20, 2);not null"` // 抽成积分
+	Status          string          `json:"status" gorm:"type:varchar(64);not null"`        // enums.DuelStatus
+	ChallengerValue *int            `json:"challenger_value" gorm:"type:int(11);default:null"`
+	OpponentValue   *int            `json:"opponent_value" gorm:"type:int(11);default:null"`
+	WinnerId        string          `json:"winner_id" gorm:"type:varchar(64)"` // 获胜方 ChatGroupUserId
+	TgMessageId     int             `json:"tg_message_id" gorm:"type:int(11)"` // 群内对决邀请消息ID
+	ExpireTime      string          `json:"expire_time" gorm:"type:varchar(255);not null"`
+	UpdateTime      string          `json:"update_time" gorm:"type:varchar(255);not null"`
+	CreateTime      string          `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *DuelRecord) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *DuelRecord) QueryById(db *gorm.DB) (*DuelRecord, error) {
+	var duelRecord *DuelRecord
+	result := db.Where("id = ?", c.Id).First(&duelRecord)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return duelRecord, nil
+}
+
+// UpdateStatusByIdAndStatus 仅当状态为 fromStatus 时更新状态 返回是否更新成功
+func (c *DuelRecord) UpdateStatusByIdAndStatus(db *gorm.DB, fromStatus string) (bool, error) {
+	result := db.Model(&DuelRecord{}).Where("id = ? and status = ?", c.Id, fromStatus).Updates(map[string]interface{}{
+		"status":      c.Status,
+		"update_time": c.UpdateTime,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FinishByIdAndStatus 仅当状态为 fromStatus 时记录对决结果 返回是否更新成功
+func (c *DuelRecord) FinishByIdAndStatus(db *gorm.DB, fromStatus string) (bool, error) {
+	result := db.Model(&DuelRecord{}).Where("id = ? and status = ?", c.Id, fromStatus).Updates(map[string]interface{}{
+		"status":           c.Status,
+		"challenger_value": c.ChallengerValue,
+		"opponent_value":   c.OpponentValue,
+		"winner_id":        c.WinnerId,
+		"fee_amount":       c.FeeAmount,
+		"update_time":      c.UpdateTime,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (c *DuelRecord) UpdateTgMessageIdById(db *gorm.DB) error {
+	result := db.Model(&DuelRecord{}).Where("id = ?", c.Id).Update("tg_message_id", c.TgMessageId)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *DuelRecord) ListByChatGroupUserId(db *gorm.DB, chatGroupUserId string) ([]*DuelRecord, error) {
+	var duelRecords []*DuelRecord
+
+	result := db.Where("challenger_id = ? or opponent_id = ?", chatGroupUserId, chatGroupUserId).Order("create_time desc").Limit(10).Find(&duelRecords)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return duelRecords, nil
+}
+
+func (c *DuelRecord) ListByStatus(db *gorm.DB) ([]*DuelRecord, error) {
+	var duelRecords []*DuelRecord
+
+	result := db.Where("status = ?", c.Status).Find(&duelRecords)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return duelRecords, nil
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/utils"
+)
+
+type EmojiGameBetRecord struct {
+	Id              string           `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupUserId string           `json:"chat_group_user_id" gorm:"type:varchar(64);not null"` // 用户ID
+	ChatGroupId     string           `json:"chat_group_id" gorm:"type:varchar(64);not null;"`
+	GameplayType    string           `json:"gameplay_type" gorm:"type:varchar(255);not null"`
+	IssueNumber     string           `json:"issue_number" gorm:"type:varchar(64);not null"`
+	BetType         string           `json:"bet_type" gorm:"type:varchar(64);not null"`                 // 下注类型
+	BetAmount       decimal.Decimal  `json:"bet_amount" gorm:"type:decimal(20, 2);not null"`            // 下注金额
+	SettleStatus    int              `json:"settle_status" gorm:"type:int(11);not null"`                // 结算状态
+	BetResultType   *int             `json:"bet_result_type" gorm:"type:int(11);default:null"`          // 下注结果输赢
+	BetResultAmount *decimal.Decimal `json:"bet_result_amount" gorm:"type:decimal(20, 2);default:null"` // 下注结果
+	UpdateTime      string           `json:"update_time" gorm:"type:varchar(255);not null"`
+	CreateTime      string           `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *EmojiGameBetRecord) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *EmojiGameBetRecord) ListByChatGroupIdAndIssueNumber(db *gorm.DB) ([]*EmojiGameBetRecord, error) {
+	var emojiGameBetRecord []*EmojiGameBetRecord
+
+	result := db.Where("chat_group_id = ? and issue_number = ?", c.ChatGroupId, c.IssueNumber).Find(&emojiGameBetRecord)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return emojiGameBetRecord, nil
+}
+
+func (c *EmojiGameBetRecord) QueryById(db *gorm.DB) (*EmojiGameBetRecord, error) {
+	var emojiGameBetRecord *EmojiGameBetRecord
+	result := db.First(&emojiGameBetRecord, c.Id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return emojiGameBetRecord, nil
+}
+
+// ListIssuesBySettleStatusAndGameplayType 存在该结算状态记录的期号 仅返回 chat_group_id 和 issue_number
+func (c *EmojiGameBetRecord) ListIssuesBySettleStatusAndGameplayType(db *gorm.DB) ([]*EmojiGameBetRecord, error) {
+	var emojiGameBetRecords []*EmojiGameBetRecord
+
+	result := db.Model(&EmojiGameBetRecord{}).Select("chat_group_id, issue_number").Where("settle_status = ? and gameplay_type = ?", c.SettleStatus, c.GameplayType).Group("chat_group_id, issue_number").Find(&emojiGameBetRecords)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return emojiGameBetRecords, nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/utils"
+)
+
+// EmojiGameConfig 表情骰子玩法(⚽🏀🎯🎳🎰)配置 每个群每种玩法一条
+type EmojiGameConfig struct {
+	Id           string `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupId  string `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	GameplayType string `json:"gameplay_type" gorm:"type:varchar(255);not null"`
+	Odds         string `json:"odds" gorm:"type:varchar(900)"` // 倍率 JSON {"GOAL":1.5,...}
+	CreateTime   string `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *EmojiGameConfig) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *EmojiGameConfig) UpdateOddsByChatGroupIdAndGameplayType(db *gorm.DB) error {
+	result := db.Model(&EmojiGameConfig{}).Where("chat_group_id = ? and gameplay_type = ?", c.ChatGroupId, c.GameplayType).Update("odds", c.Odds)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func QueryEmojiGameConfigByChatGroupIdAndGameplayType(db *gorm.DB, chatGroupId string, gameplayType string) (*EmojiGameConfig, error) {
+	var emojiGameConfig *EmojiGameConfig
+	result := db.Where("chat_group_id = ? and gameplay_type = ?", chatGroupId, gameplayType).First(&emojiGameConfig)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return emojiGameConfig, nil
+}
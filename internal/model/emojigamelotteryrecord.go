@@ -0,0 +1,43 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/utils"
+)
+
+type EmojiGameLotteryRecord struct {
+	Id           string `json:"id" gorm:"type:varchar(64);not null;primaryKey"` // 与开奖主表 LotteryRecord.Id 一致
+	ChatGroupId  string `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	IssueNumber  string `json:"issue_number" gorm:"type:varchar(64);not null"`
+	GameplayType string `json:"gameplay_type" gorm:"type:varchar(255);not null"`
+	Value        int    `json:"value" gorm:"type:int(11);not null"` // Telegram骰子返回值 🎰为1-64 其余为1-5或1-6
+	CreateTime   string `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *EmojiGameLotteryRecord) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *EmojiGameLotteryRecord) QueryById(db *gorm.DB) (*EmojiGameLotteryRecord, error) {
+	var emojiGameLotteryRecord *EmojiGameLotteryRecord
+	result := db.First(&emojiGameLotteryRecord, c.Id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return emojiGameLotteryRecord, nil
+}
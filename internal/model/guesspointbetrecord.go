@@ -0,0 +1,72 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/utils"
+)
+
+type GuessPointBetRecord struct {
+	Id              string           `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupUserId string           `json:"chat_group_user_id" gorm:"type:varchar(64);not null"` // 用户ID
+	ChatGroupId     string           `json:"chat_group_id" gorm:"type:varchar(64);not null;"`
+	IssueNumber     string           `json:"issue_number" gorm:"type:varchar(64);not null"`
+	BetType         string           `json:"bet_type" gorm:"type:varchar(64);not null"`                 // 下注类型
+	BetAmount       decimal.Decimal  `json:"bet_amount" gorm:"type:decimal(20, 2);not null"`            // 下注金额
+	SettleStatus    int              `json:"settle_status" gorm:"type:int(11);not null"`                // 结算状态
+	BetResultType   *int             `json:"bet_result_type" gorm:"type:int(11);default:null"`          // 下注结果输赢
+	BetResultAmount *decimal.Decimal `json:"bet_result_amount" gorm:"type:decimal(20, 2);default:null"` // 下注结果
+	UpdateTime      string           `json:"update_time" gorm:"type:varchar(255);not null"`
+	CreateTime      string           `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *GuessPointBetRecord) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *GuessPointBetRecord) ListByChatGroupIdAndIssueNumber(db *gorm.DB) ([]*GuessPointBetRecord, error) {
+	var guessPointBetRecord []*GuessPointBetRecord
+
+	result := db.Where("chat_group_id = ? and issue_number = ?", c.ChatGroupId, c.IssueNumber).Find(&guessPointBetRecord)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return guessPointBetRecord, nil
+}
+
+func (c *GuessPointBetRecord) QueryById(db *gorm.DB) (*GuessPointBetRecord, error) {
+	var guessPointBetRecord *GuessPointBetRecord
+	result := db.First(&guessPointBetRecord, c.Id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return guessPointBetRecord, nil
+}
+
+// ListIssuesBySettleStatus 存在该结算状态记录的期号 仅返回 chat_group_id 和 issue_number
+func (c *GuessPointBetRecord) ListIssuesBySettleStatus(db *gorm.DB) ([]*GuessPointBetRecord, error) {
+	var guessPointBetRecords []*GuessPointBetRecord
+
+	result := db.Model(&GuessPointBetRecord{}).Select("chat_group_id, issue_number").Where("settle_status = ?", c.SettleStatus).Group("chat_group_id, issue_number").Find(&guessPointBetRecords)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return guessPointBetRecords, nil
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/utils"
+)
+
+type GuessPointConfig struct {
+	Id          string          `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupId string          `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	PointOdds   decimal.Decimal `json:"point_odds" gorm:"type:decimal(5, 2);not null;default:5"`    // 点数倍率(点1-点6)
+	SimpleOdds  decimal.Decimal `json:"simple_odds" gorm:"type:decimal(5, 2);not null;default:1.9"` // 简易倍率(单/双/大/小)
+	CreateTime  string          `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *GuessPointConfig) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *GuessPointConfig) UpdatePointOddsByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&GuessPointConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("point_odds", c.PointOdds)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *GuessPointConfig) UpdateSimpleOddsByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&GuessPointConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("simple_odds", c.SimpleOdds)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func QueryGuessPointConfigByChatGroupId(db *gorm.DB, chatGroupId string) (*GuessPointConfig, error) {
+	var guessPointConfig *GuessPointConfig
+	result := db.Where("chat_group_id = ?", chatGroupId).First(&guessPointConfig)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return guessPointConfig, nil
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/utils"
+)
+
+type GuessPointLotteryRecord struct {
+	Id           string `json:"id" gorm:"type:varchar(64);not null;primaryKey"` // 与开奖主表 LotteryRecord.Id 一致
+	ChatGroupId  string `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	IssueNumber  string `json:"issue_number" gorm:"type:varchar(64);not null"`
+	Value        int    `json:"value" gorm:"type:int(11);not null"`
+	SingleDouble string `json:"single_double" gorm:"type:varchar(255);not null"`
+	BigSmall     string `json:"big_small" gorm:"type:varchar(255);not null"`
+	CreateTime   string `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *GuessPointLotteryRecord) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *GuessPointLotteryRecord) QueryById(db *gorm.DB) (*GuessPointLotteryRecord, error) {
+	var guessPointLotteryRecord *GuessPointLotteryRecord
+	result := db.First(&guessPointLotteryRecord, c.Id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return guessPointLotteryRecord, nil
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/utils"
+)
+
+type HighestRollConfig struct {
+	Id          string          `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupId string          `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	EntryFee    decimal.Decimal `json:"entry_fee" gorm:"type:decimal(20, 2);not null;default:10"` // 每期参与费用
+	CreateTime  string          `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *HighestRollConfig) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *HighestRollConfig) UpdateEntryFeeByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&HighestRollConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("entry_fee", c.EntryFee)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func QueryHighestRollConfigByChatGroupId(db *gorm.DB, chatGroupId string) (*HighestRollConfig, error) {
+	var highestRollConfig *HighestRollConfig
+	result := db.Where("chat_group_id = ?", chatGroupId).First(&highestRollConfig)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return highestRollConfig, nil
+}
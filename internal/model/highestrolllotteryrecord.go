@@ -0,0 +1,57 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/utils"
+)
+
+type HighestRollLotteryRecord struct {
+	Id               string          `json:"id" gorm:"type:varchar(64);not null;primaryKey"` // 与开奖主表 LotteryRecord.Id 一致
+	ChatGroupId      string          `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	IssueNumber      string          `json:"issue_number" gorm:"type:varchar(64);not null"`
+	ParticipantCount int             `json:"participant_count" gorm:"type:int(11);not null"`  // 参与人数
+	PoolAmount       decimal.Decimal `json:"pool_amount" gorm:"type:decimal(20, 2);not null"` // 奖池积分
+	MaxValue         int             `json:"max_value" gorm:"type:int(11);not null"`          // 最高点数 无人参与时为0
+	WinnerCount      int             `json:"winner_count" gorm:"type:int(11);not null"`       // 瓜分奖池人数
+	CreateTime       string          `json:"create_time" gorm:"type:varchar(255);not null"`
+
+	Participants []*HighestRollParticipant `json:"-" gorm:"-"` // 本期参与者及掷骰结果 开奖时保存
+	Winners      []*HighestRollParticipant `json:"-" gorm:"-"` // 瓜分奖池的参与者
+}
+
+func (c *HighestRollLotteryRecord) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// 同一事务中保存参与者的掷骰点数 供重启后恢复结算
+	for _, participant := range c.Participants {
+		result = db.Model(participant).Select("value", "tie_break_values").Updates(participant)
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (c *HighestRollLotteryRecord) QueryById(db *gorm.DB) (*HighestRollLotteryRecord, error) {
+	var highestRollLotteryRecord *HighestRollLotteryRecord
+	result := db.First(&highestRollLotteryRecord, c.Id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return highestRollLotteryRecord, nil
+}
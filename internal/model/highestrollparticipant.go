@@ -0,0 +1,83 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/utils"
+)
+
+// HighestRollParticipant 比大小每期参与记录 Id 与下注主表 BetRecord.Id 一致
+type HighestRollParticipant struct {
+	Id              string           `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupUserId string           `json:"chat_group_user_id" gorm:"type:varchar(64);not null;uniqueIndex:idx_highest_roll_participant"` // 用户ID
+	ChatGroupId     string           `json:"chat_group_id" gorm:"type:varchar(64);not null;"`
+	IssueNumber     string           `json:"issue_number" gorm:"type:varchar(64);not null;uniqueIndex:idx_highest_roll_participant"`
+	EntryFee        decimal.Decimal  `json:"entry_fee" gorm:"type:decimal(20, 2);not null"`             // 参与费用
+	Value           *int             `json:"value" gorm:"type:int(11);default:null"`                    // 掷骰点数
+	TieBreakValues  string           `json:"tie_break_values" gorm:"type:varchar(255);default:null"`    // 加赛点数 逗号分隔
+	SettleStatus    int              `json:"settle_status" gorm:"type:int(11);not null"`                // 结算状态
+	BetResultType   *int             `json:"bet_result_type" gorm:"type:int(11);default:null"`          // 输赢
+	BetResultAmount *decimal.Decimal `json:"bet_result_amount" gorm:"type:decimal(20, 2);default:null"` // 结算结果
+	UpdateTime      string           `json:"update_time" gorm:"type:varchar(255);not null"`
+	CreateTime      string           `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *HighestRollParticipant) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *HighestRollParticipant) ListByChatGroupIdAndIssueNumber(db *gorm.DB) ([]*HighestRollParticipant, error) {
+	var highestRollParticipants []*HighestRollParticipant
+
+	result := db.Where("chat_group_id = ? and issue_number = ?", c.ChatGroupId, c.IssueNumber).Order("create_time").Find(&highestRollParticipants)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return highestRollParticipants, nil
+}
+
+func (c *HighestRollParticipant) QueryByChatGroupUserIdAndIssueNumber(db *gorm.DB) (*HighestRollParticipant, error) {
+	var highestRollParticipant *HighestRollParticipant
+	result := db.Where("chat_group_user_id = ? and issue_number = ?", c.ChatGroupUserId, c.IssueNumber).First(&highestRollParticipant)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return highestRollParticipant, nil
+}
+
+func (c *HighestRollParticipant) QueryById(db *gorm.DB) (*HighestRollParticipant, error) {
+	var highestRollParticipant *HighestRollParticipant
+	result := db.First(&highestRollParticipant, c.Id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return highestRollParticipant, nil
+}
+
+// ListIssuesBySettleStatus 存在该结算状态记录的期号 仅返回 chat_group_id 和 issue_number
+func (c *HighestRollParticipant) ListIssuesBySettleStatus(db *gorm.DB) ([]*HighestRollParticipant, error) {
+	var highestRollParticipants []*HighestRollParticipant
+
+	result := db.Model(&HighestRollParticipant{}).Select("chat_group_id, issue_number").Where("settle_status = ?", c.SettleStatus).Group("chat_group_id, issue_number").Find(&highestRollParticipants)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return highestRollParticipants, nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/utils"
+)
+
+// IssueRecord 开期记录 开期时保存 开奖及结算时使用开期时的配置和公布的内容
+type IssueRecord struct {
+	Id             string          `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupId    string          `json:"chat_group_id" gorm:"type:varchar(64);not null;uniqueIndex:idx_issue_record_issue"`
+	IssueNumber    string          `json:"issue_number" gorm:"type:varchar(64);not null;uniqueIndex:idx_issue_record_issue"`
+	DrawTime       string          `json:"draw_time" gorm:"type:varchar(255);default:null"`             // 开奖时间(RFC3339) 开期时按群时区确定 修改时区后仍按此时间开奖
+	OddsMode       string          `json:"odds_mode" gorm:"type:varchar(64);default:null"`              // 开期时的快三赔付模式 enums.OddsMode
+	PoolRakeRate   decimal.Decimal `json:"pool_rake_rate" gorm:"type:decimal(5, 2);not null;default:0"` // 开期时的快三奖池抽成比例(%)
+	ServerSeed     string          `json:"server_seed" gorm:"type:varchar(64);default:null"`            // 可验证随机 公布种子承诺时生成的服务端种子 开奖时公布
+	SeedCommitment string          `json:"seed_commitment" gorm:"type:varchar(64);default:null"`        // 可验证随机 开期时公布的种子承诺 SHA-256(种子)
+	CloseStatus    int             `json:"close_status" gorm:"type:int(64);not null;default:0"`         // 是否已封盘 封盘后不再接受下注
+	BetCount       int             `json:"bet_count" gorm:"type:int(64);not null;default:0"`            // 封盘前接受的下注笔数
+	CreateTime     string          `json:"create_time" gorm:"type:varchar(255);not null"`
+}
+
+func (c *IssueRecord) Create(db *gorm.DB) error {
+	if c.Id == "" {
+		id, err := utils.NextID()
+		if err != nil {
+			logrus.Error("SnowFlakeId create error")
+			return err
+		}
+		c.Id = id
+	}
+
+	result := db.Create(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (c *IssueRecord) QueryByChatGroupIdAndIssueNumber(db *gorm.DB) (*IssueRecord, error) {
+	var issueRecord *IssueRecord
+	result := db.Where("chat_group_id = ? and issue_number = ?", c.ChatGroupId, c.IssueNumber).First(&issueRecord)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return issueRecord, nil
+}
+
+// UpdateServerSeedById 仅当尚未生成种子时保存种子 返回是否更新成功
+func (c *IssueRecord) UpdateServerSeedById(db *gorm.DB) (bool, error) {
+	result := db.Model(&IssueRecord{}).Where("id = ? and (server_seed is null or server_seed = '')", c.Id).Updates(map[string]interface{}{
+		"server_seed":     c.ServerSeed,
+		"seed_commitment": c.SeedCommitment,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// AddBetCountByChatGroupIdAndIssueNumber 未封盘时下注笔数加1 返回是否未封盘
+// 与封盘更新同一行 在下注事务内执行时封盘会等待该事务提交 封盘后的下注不会被接受
+func (c *IssueRecord) AddBetCountByChatGroupIdAndIssueNumber(db *gorm.DB) (bool, error) {
+	result := db.Model(&IssueRecord{}).Where("chat_group_id = ? and issue_number = ? and close_status = 0", c.ChatGroupId, c.IssueNumber).Update("bet_count", gorm.Expr("bet_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CloseByChatGroupIdAndIssueNumber 封盘 返回时已开始的下注事务均已提交
+func (c *IssueRecord) CloseByChatGroupIdAndIssueNumber(db *gorm.DB) error {
+	result := db.Model(&IssueRecord{}).Where("chat_group_id = ? and issue_number = ?", c.ChatGroupId, c.IssueNumber).Update("close_status", 1)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
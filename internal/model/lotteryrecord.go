@@ -8,9 +8,10 @@ import (
 
 type LotteryRecord struct {
 	Id           string `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
-	ChatGroupId  string `json:"chat_group_id" gorm:"type:varchar(64);not null"`
-	IssueNumber  string `json:"issue_number" gorm:"type:varchar(64);not null"`
+	ChatGroupId  string `json:"chat_group_id" gorm:"type:varchar(64);not null;uniqueIndex:idx_lottery_record_issue"`
+	IssueNumber  string `json:"issue_number" gorm:"type:varchar(64);not null;uniqueIndex:idx_lottery_record_issue"` // 同一群的期号只能开奖或作废一次
 	GameplayType string `json:"gameplay_type" gorm:"type:varchar(255);not null"`
+	Status       int    `json:"status" gorm:"type:int(11);not null;default:0"` // 开奖状态 enums.LotteryStatus
 	CreateTime   string `json:"create_time" gorm:"type:varchar(255);not null"`
 }
 
@@ -42,3 +43,21 @@ func (c *LotteryRecord) ListByChatGroupId(db *gorm.DB) ([]*LotteryRecord, error)
 
 	return lotteryRecords, nil
 }
+
+func (c *LotteryRecord) QueryByChatGroupIdAndIssueNumber(db *gorm.DB) (*LotteryRecord, error) {
+	var lotteryRecord *LotteryRecord
+	result := db.Where("chat_group_id = ? and issue_number = ?", c.ChatGroupId, c.IssueNumber).First(&lotteryRecord)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return lotteryRecord, nil
+}
+
+func (c *LotteryRecord) QueryById(db *gorm.DB) (*LotteryRecord, error) {
+	var lotteryRecord *LotteryRecord
+	result := db.First(&lotteryRecord, c.Id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return lotteryRecord, nil
+}
@@ -3,21 +3,22 @@ package model
 import (
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
 	"telegram-dice-bot/internal/utils"
 )
 
 type QuickThereBetRecord struct {
-	Id              string  `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
-	ChatGroupUserId string  `json:"chat_group_user_id" gorm:"type:varchar(64);not null"` // 用户ID
-	ChatGroupId     string  `json:"chat_group_id" gorm:"type:varchar(64);not null;"`
-	IssueNumber     string  `json:"issue_number" gorm:"type:varchar(64);not null"`
-	BetType         string  `json:"bet_type" gorm:"type:varchar(64);not null"`               // 下注类型
-	BetAmount       float64 `json:"bet_amount" gorm:"type:decimal(20, 2);not null"`          // 下注金额
-	SettleStatus    int     `json:"settle_status" gorm:"type:int(11);not null"`              // 结算状态
-	BetResultType   *int    `json:"bet_result_type" gorm:"type:int(11);default:null"`        // 下注结果输赢
-	BetResultAmount string  `json:"bet_result_amount" gorm:"type:varchar(255);default:null"` // 下注结果
-	UpdateTime      string  `json:"update_time" gorm:"type:varchar(255);not null"`
-	CreateTime      string  `json:"create_time" gorm:"type:varchar(255);not null"`
+	Id              string           `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupUserId string           `json:"chat_group_user_id" gorm:"type:varchar(64);not null"` // 用户ID
+	ChatGroupId     string           `json:"chat_group_id" gorm:"type:varchar(64);not null;"`
+	IssueNumber     string           `json:"issue_number" gorm:"type:varchar(64);not null"`
+	BetType         string           `json:"bet_type" gorm:"type:varchar(64);not null"`                 // 下注类型
+	BetAmount       decimal.Decimal  `json:"bet_amount" gorm:"type:decimal(20, 2);not null"`            // 下注金额
+	SettleStatus    int              `json:"settle_status" gorm:"type:int(11);not null"`                // 结算状态
+	BetResultType   *int             `json:"bet_result_type" gorm:"type:int(11);default:null"`          // 下注结果输赢
+	BetResultAmount *decimal.Decimal `json:"bet_result_amount" gorm:"type:decimal(20, 2);default:null"` // 下注结果
+	UpdateTime      string           `json:"update_time" gorm:"type:varchar(255);not null"`
+	CreateTime      string           `json:"create_time" gorm:"type:varchar(255);not null"`
 }
 
 func (c *QuickThereBetRecord) Create(db *gorm.DB) error {
@@ -49,6 +50,16 @@ func (c *QuickThereBetRecord) ListByChatGroupIdAndIssueNumber(db *gorm.DB) ([]*Q
 	return quickThereBetRecord, nil
 }
 
+// SumBetAmountByChatGroupIdAndIssueNumber 该期下注积分总和
+func (c *QuickThereBetRecord) SumBetAmountByChatGroupIdAndIssueNumber(db *gorm.DB) (decimal.Decimal, error) {
+	var sum decimal.Decimal
+	err := db.Model(&QuickThereBetRecord{}).Select("COALESCE(SUM(bet_amount), 0)").Where("chat_group_id = ? and issue_number = ?", c.ChatGroupId, c.IssueNumber).Row().Scan(&sum)
+	if err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
 func (c *QuickThereBetRecord) QueryById(db *gorm.DB) (*QuickThereBetRecord, error) {
 	var quickThereBetRecord *QuickThereBetRecord
 	result := db.First(&quickThereBetRecord, c.Id)
@@ -57,3 +68,15 @@ func (c *QuickThereBetRecord) QueryById(db *gorm.DB) (*QuickThereBetRecord, erro
 	}
 	return quickThereBetRecord, nil
 }
+
+// ListIssuesBySettleStatus 存在该结算状态记录的期号 仅返回 chat_group_id 和 issue_number
+func (c *QuickThereBetRecord) ListIssuesBySettleStatus(db *gorm.DB) ([]*QuickThereBetRecord, error) {
+	var quickThereBetRecords []*QuickThereBetRecord
+
+	result := db.Model(&QuickThereBetRecord{}).Select("chat_group_id, issue_number").Where("settle_status = ?", c.SettleStatus).Group("chat_group_id, issue_number").Find(&quickThereBetRecords)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return quickThereBetRecords, nil
+}
@@ -3,15 +3,25 @@ package model
 import (
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
 	"telegram-dice-bot/internal/utils"
 )
 
 type QuickThereConfig struct {
-	Id          string  `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
-	ChatGroupId string  `json:"chat_group_id" gorm:"type:varchar(64);not null"`
-	SimpleOdds  float64 `json:"simple_odds" gorm:"decimal(5, 2);not null"`
-	TripletOdds float64 `json:"triplet_odds" gorm:"decimal(5, 2);not null"`
-	CreateTime  string  `json:"create_time" gorm:"type:varchar(255);not null"`
+	Id                  string          `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupId         string          `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	SimpleOdds          decimal.Decimal `json:"simple_odds" gorm:"type:decimal(5, 2);not null"`
+	TripletOdds         decimal.Decimal `json:"triplet_odds" gorm:"type:decimal(5, 2);not null"`
+	ComboOdds           decimal.Decimal `json:"combo_odds" gorm:"type:decimal(5, 2);not null;default:3.5"`            // 组合倍率(大单/大双/小单/小双)
+	SumOdds             string          `json:"sum_odds" gorm:"type:varchar(900)"`                                    // 和值倍率 JSON {"3":240,...}
+	SpecificTripletOdds decimal.Decimal `json:"specific_triplet_odds" gorm:"type:decimal(7, 2);not null;default:180"` // 指定豹子倍率
+	PairOdds            decimal.Decimal `json:"pair_odds" gorm:"type:decimal(5, 2);not null;default:2"`               // 对子倍率
+	SpecificPairOdds    decimal.Decimal `json:"specific_pair_odds" gorm:"type:decimal(5, 2);not null;default:11"`     // 指定对子倍率
+	NumberOdds          string          `json:"number_odds" gorm:"type:varchar(255)"`                                 // 单号倍率 按出现次数 JSON {"1":2,"2":3,"3":4}
+	TripleKill          int             `json:"triple_kill" gorm:"type:int(11);not null;default:0"`                   // 豹子通杀 开出豹子时大/小/单/双及组合不中奖 enums.TripleKillStatus
+	OddsMode            string          `json:"odds_mode" gorm:"type:varchar(64);not null;default:FIXED"`             // 赔付模式 enums.OddsMode
+	PoolRakeRate        decimal.Decimal `json:"pool_rake_rate" gorm:"type:decimal(5, 2);not null;default:0"`          // 奖池模式抽成比例(%)
+	CreateTime          string          `json:"create_time" gorm:"type:varchar(255);not null"`
 }
 
 func (c *QuickThereConfig) Create(db *gorm.DB) error {
@@ -48,6 +58,78 @@ func (c *QuickThereConfig) UpdateTripletOddsByChatGroupId(db *gorm.DB) error {
 	return nil
 }
 
+func (c *QuickThereConfig) UpdateTripleKillByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&QuickThereConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("triple_kill", c.TripleKill)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *QuickThereConfig) UpdateOddsModeByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&QuickThereConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("odds_mode", c.OddsMode)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *QuickThereConfig) UpdatePoolRakeRateByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&QuickThereConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("pool_rake_rate", c.PoolRakeRate)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *QuickThereConfig) UpdateComboOddsByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&QuickThereConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("combo_odds", c.ComboOdds)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *QuickThereConfig) UpdateSpecificTripletOddsByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&QuickThereConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("specific_triplet_odds", c.SpecificTripletOdds)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *QuickThereConfig) UpdatePairOddsByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&QuickThereConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("pair_odds", c.PairOdds)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *QuickThereConfig) UpdateSpecificPairOddsByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&QuickThereConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("specific_pair_odds", c.SpecificPairOdds)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *QuickThereConfig) UpdateNumberOddsByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&QuickThereConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("number_odds", c.NumberOdds)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (c *QuickThereConfig) UpdateSumOddsByChatGroupId(db *gorm.DB) error {
+	result := db.Model(&QuickThereConfig{}).Where("chat_group_id = ?", c.ChatGroupId).Update("sum_odds", c.SumOdds)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
 func QueryQuickThereConfigByChatGroupId(db *gorm.DB, chatGroupId string) (*QuickThereConfig, error) {
 	var QuickThereConfig *QuickThereConfig
 	result := db.Where("chat_group_id = ?", chatGroupId).First(&QuickThereConfig)
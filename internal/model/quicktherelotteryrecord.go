@@ -7,17 +7,19 @@ import (
 )
 
 type QuickThereLotteryRecord struct {
-	Id           string `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
-	ChatGroupId  string `json:"chat_group_id" gorm:"type:varchar(64);not null"`
-	IssueNumber  string `json:"issue_number" gorm:"type:varchar(64);not null"`
-	ValueA       int    `json:"value_a" gorm:"type:int(11);not null"`
-	ValueB       int    `json:"value_b" gorm:"type:int(11);not null"`
-	ValueC       int    `json:"value_c" gorm:"type:int(11);not null"`
-	Total        int    `json:"total" gorm:"type:int(11);not null"`
-	SingleDouble string `json:"single_double" gorm:"type:varchar(255);not null"`
-	BigSmall     string `json:"big_small" gorm:"type:varchar(255);not null"`
-	Triplet      int    `json:"triplet" gorm:"type:int(11);not null"`
-	CreateTime   string `json:"create_time" gorm:"type:varchar(255);not null"`
+	Id             string `json:"id" gorm:"type:varchar(64);not null;primaryKey"`
+	ChatGroupId    string `json:"chat_group_id" gorm:"type:varchar(64);not null"`
+	IssueNumber    string `json:"issue_number" gorm:"type:varchar(64);not null"`
+	ValueA         int    `json:"value_a" gorm:"type:int(11);not null"`
+	ValueB         int    `json:"value_b" gorm:"type:int(11);not null"`
+	ValueC         int    `json:"value_c" gorm:"type:int(11);not null"`
+	Total          int    `json:"total" gorm:"type:int(11);not null"`
+	SingleDouble   string `json:"single_double" gorm:"type:varchar(255);not null"`
+	BigSmall       string `json:"big_small" gorm:"type:varchar(255);not null"`
+	Triplet        int    `json:"triplet" gorm:"type:int(11);not null"`
+	ServerSeed     string `json:"server_seed" gorm:"type:varchar(64);default:null"`     // 可验证随机 开奖时公布的服务端种子
+	SeedCommitment string `json:"seed_commitment" gorm:"type:varchar(64);default:null"` // 可验证随机 开期时公布的种子承诺 SHA-256(种子)
+	CreateTime     string `json:"create_time" gorm:"type:varchar(255);not null"`
 }
 
 func (c *QuickThereLotteryRecord) Create(db *gorm.DB) error {
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/model"
+)
+
+// gormStore 基于 gorm 的存储 MySQL 与 SQLite 共用 方言差异由 beforeMigrate 处理
+type gormStore struct {
+	db            *gorm.DB
+	beforeMigrate func(db *gorm.DB) error
+}
+
+// migrateModels 自动迁移的表 新增表时在此追加
+var migrateModels = []interface{}{
+	&model.ChatGroup{},
+	&model.ChatGroupAdmin{},
+	&model.QuickThereConfig{},
+	&model.QuickThereLotteryRecord{},
+	&model.GuessPointConfig{},
+	&model.GuessPointLotteryRecord{},
+	&model.GuessPointBetRecord{},
+	&model.EmojiGameConfig{},
+	&model.EmojiGameLotteryRecord{},
+	&model.EmojiGameBetRecord{},
+	&model.HighestRollConfig{},
+	&model.HighestRollLotteryRecord{},
+	&model.HighestRollParticipant{},
+	&model.DuelRecord{},
+	&model.ChatGroupUser{},
+	&model.QuickThereBetRecord{},
+	&model.LotteryRecord{},
+	&model.IssueRecord{},
+	&model.BetRecord{},
+	&model.BalanceLedger{},
+}
+
+func (s *gormStore) ChatGroups() ChatGroupRepository {
+	return &chatGroupRepository{db: s.db}
+}
+
+func (s *gormStore) ChatGroupUsers() ChatGroupUserRepository {
+	return &chatGroupUserRepository{db: s.db}
+}
+
+func (s *gormStore) ChatGroupAdmins() ChatGroupAdminRepository {
+	return &chatGroupAdminRepository{db: s.db}
+}
+
+func (s *gormStore) BetRecords() BetRecordRepository {
+	return &betRecordRepository{db: s.db}
+}
+
+func (s *gormStore) LotteryRecords() LotteryRecordRepository {
+	return &lotteryRecordRepository{db: s.db}
+}
+
+func (s *gormStore) Begin() Tx {
+	return &gormTx{gormStore: &gormStore{db: s.db.Begin(), beforeMigrate: s.beforeMigrate}}
+}
+
+func (s *gormStore) DB() *gorm.DB {
+	return s.db
+}
+
+func (s *gormStore) Migrate() error {
+	if s.beforeMigrate != nil {
+		if err := s.beforeMigrate(s.db); err != nil {
+			return err
+		}
+	}
+	for _, value := range migrateModels {
+		if err := s.db.AutoMigrate(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *gormStore) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+type gormTx struct {
+	*gormStore
+	done bool
+}
+
+func (t *gormTx) Commit() error {
+	t.done = true
+	return t.db.Commit().Error
+}
+
+// Rollback 未结束的事务必须回滚 SQLite 下未结束的事务会一直持有写锁
+func (t *gormTx) Rollback() {
+	if t.done {
+		return
+	}
+	t.done = true
+	t.db.Rollback()
+}
+
+type chatGroupRepository struct {
+	db *gorm.DB
+}
+
+func (r *chatGroupRepository) Create(chatGroup *model.ChatGroup) error {
+	return chatGroup.Create(r.db)
+}
+
+func (r *chatGroupRepository) Save(chatGroup *model.ChatGroup) error {
+	return r.db.Save(chatGroup).Error
+}
+
+func (r *chatGroupRepository) QueryById(id string) (*model.ChatGroup, error) {
+	return model.QueryChatGroupById(r.db, id)
+}
+
+func (r *chatGroupRepository) QueryByTgChatId(tgChatId int64) (*model.ChatGroup, error) {
+	return model.QueryChatGroupByTgChatId(r.db, tgChatId)
+}
+
+func (r *chatGroupRepository) ListByIds(ids []string) ([]*model.ChatGroup, error) {
+	return model.ListChatGroupByIds(r.db, ids)
+}
+
+func (r *chatGroupRepository) ListByGameplayStatus(gameplayStatus int) ([]*model.ChatGroup, error) {
+	chatGroup := &model.ChatGroup{GameplayStatus: gameplayStatus}
+	return chatGroup.ListByGameplayStatus(r.db)
+}
+
+func (r *chatGroupRepository) UpdateColumnsById(chatGroup *model.ChatGroup, columns ...string) error {
+	return model.UpdateChatGroupColumnsById(r.db, chatGroup, columns...)
+}
+
+func (r *chatGroupRepository) UpdateChatGroupStatusByTgChatId(chatGroup *model.ChatGroup) error {
+	_, err := model.UpdateChatGroupStatusByTgChatId(r.db, chatGroup)
+	return err
+}
+
+func (r *chatGroupRepository) UpdateGameplayStatusAndChatGroupStatusByTgChatId(chatGroup *model.ChatGroup) error {
+	_, err := chatGroup.UpdateGameplayStatusAndChatGroupStatusByTgChatId(r.db)
+	return err
+}
+
+type chatGroupUserRepository struct {
+	db *gorm.DB
+}
+
+func (r *chatGroupUserRepository) Create(chatGroupUser *model.ChatGroupUser) error {
+	return chatGroupUser.Create(r.db)
+}
+
+func (r *chatGroupUserRepository) Save(chatGroupUser *model.ChatGroupUser) error {
+	return r.db.Save(chatGroupUser).Error
+}
+
+func (r *chatGroupUserRepository) QueryById(id string) (*model.ChatGroupUser, error) {
+	query := &model.ChatGroupUser{Id: id}
+	return query.QueryById(r.db)
+}
+
+func (r *chatGroupUserRepository) QueryByIdAndChatGroupId(id string, chatGroupId string) (*model.ChatGroupUser, error) {
+	query := &model.ChatGroupUser{Id: id, ChatGroupId: chatGroupId}
+	return query.QueryByIdAndChatGroupId(r.db)
+}
+
+func (r *chatGroupUserRepository) QueryByTgUserIdAndChatGroupId(tgUserId int64, chatGroupId string) (*model.ChatGroupUser, error) {
+	query := &model.ChatGroupUser{TgUserId: tgUserId, ChatGroupId: chatGroupId}
+	return query.QueryByTgUserIdAndChatGroupId(r.db)
+}
+
+func (r *chatGroupUserRepository) QueryByUsernameAndChatGroupId(username string, chatGroupId string) (*model.ChatGroupUser, error) {
+	query := &model.ChatGroupUser{Username: username, ChatGroupId: chatGroupId}
+	return query.QueryByUsernameAndChatGroupId(r.db)
+}
+
+func (r *chatGroupUserRepository) ListByTgUserId(tgUserId int64) ([]*model.ChatGroupUser, error) {
+	query := &model.ChatGroupUser{TgUserId: tgUserId}
+	return query.ListByTgUserId(r.db)
+}
+
+func (r *chatGroupUserRepository) ListByTgUserIdAndIsLeft(tgUserId int64, isLeft int) ([]*model.ChatGroupUser, error) {
+	query := &model.ChatGroupUser{TgUserId: tgUserId, IsLeft: isLeft}
+	return query.ListByTgUserIdAndIsLeft(r.db)
+}
+
+type chatGroupAdminRepository struct {
+	db *gorm.DB
+}
+
+func (r *chatGroupAdminRepository) Create(chatGroupAdmin *model.ChatGroupAdmin) error {
+	return model.CreateChatGroupAdmin(r.db, chatGroupAdmin)
+}
+
+func (r *chatGroupAdminRepository) QueryByChatGroupIdAndTgUserId(chatGroupId string, adminTgUserId int64) (*model.ChatGroupAdmin, error) {
+	return model.QueryChatGroupAdminByChatGroupIdAndTgUserId(r.db, chatGroupId, adminTgUserId)
+}
+
+func (r *chatGroupAdminRepository) ListByAdminTgUserId(adminTgUserId int64) ([]*model.ChatGroupAdmin, error) {
+	return model.ListChatGroupAdminByAdminTgUserId(r.db, adminTgUserId)
+}
+
+func (r *chatGroupAdminRepository) DeleteByChatGroupId(chatGroupId string) error {
+	return model.DeleteChatGroupAdminByChatGroupId(r.db, chatGroupId)
+}
+
+func (r *chatGroupAdminRepository) DeleteByChatGroupIdAndAdminTgUserId(chatGroupId string, adminTgUserId int64) error {
+	chatGroupAdmin := &model.ChatGroupAdmin{ChatGroupId: chatGroupId, AdminTgUserId: adminTgUserId}
+	return chatGroupAdmin.DeleteByChatGroupIdAndAdminTgUserId(r.db)
+}
+
+type betRecordRepository struct {
+	db *gorm.DB
+}
+
+func (r *betRecordRepository) Create(betRecord *model.BetRecord) error {
+	return betRecord.Create(r.db)
+}
+
+func (r *betRecordRepository) ListByChatGroupUserId(chatGroupUserId string) ([]*model.BetRecord, error) {
+	query := &model.BetRecord{ChatGroupUserId: chatGroupUserId}
+	return query.ListByChatGroupUserId(r.db)
+}
+
+func (r *betRecordRepository) ListChatGroupUserIdsByChatGroupIdAndIssueNumber(chatGroupId string, issueNumber string) ([]string, error) {
+	query := &model.BetRecord{ChatGroupId: chatGroupId, IssueNumber: issueNumber}
+	return query.ListChatGroupUserIdsByChatGroupIdAndIssueNumber(r.db)
+}
+
+type lotteryRecordRepository struct {
+	db *gorm.DB
+}
+
+func (r *lotteryRecordRepository) Create(lotteryRecord *model.LotteryRecord) error {
+	return lotteryRecord.Create(r.db)
+}
+
+func (r *lotteryRecordRepository) Save(lotteryRecord *model.LotteryRecord) error {
+	return r.db.Save(lotteryRecord).Error
+}
+
+func (r *lotteryRecordRepository) QueryById(id string) (*model.LotteryRecord, error) {
+	query := &model.LotteryRecord{Id: id}
+	return query.QueryById(r.db)
+}
+
+func (r *lotteryRecordRepository) QueryByChatGroupIdAndIssueNumber(chatGroupId string, issueNumber string) (*model.LotteryRecord, error) {
+	query := &model.LotteryRecord{ChatGroupId: chatGroupId, IssueNumber: issueNumber}
+	return query.QueryByChatGroupIdAndIssueNumber(r.db)
+}
+
+func (r *lotteryRecordRepository) ListByChatGroupId(chatGroupId string) ([]*model.LotteryRecord, error) {
+	query := &model.LotteryRecord{ChatGroupId: chatGroupId}
+	return query.ListByChatGroupId(r.db)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"strings"
+	"telegram-dice-bot/internal/model"
+)
+
+// NewMySQLStore MySQL 存储
+func NewMySQLStore(db *gorm.DB) Store {
+	return &gormStore{db: db, beforeMigrate: migrateBetResultAmount}
+}
+
+// migrateBetResultAmount 下注结果字段由文本改为数值前 将未结算记录的空字符串置为NULL 并去掉盈利金额的 + 号 避免修改字段类型失败
+func migrateBetResultAmount(db *gorm.DB) error {
+	for _, value := range []interface{}{&model.QuickThereBetRecord{}, &model.GuessPointBetRecord{}, &model.EmojiGameBetRecord{}, &model.HighestRollParticipant{}} {
+		if !db.Migrator().HasTable(value) {
+			continue
+		}
+		columnTypes, err := db.Migrator().ColumnTypes(value)
+		if err != nil {
+			return err
+		}
+		for _, columnType := range columnTypes {
+			if columnType.Name() != "bet_result_amount" || !strings.EqualFold(columnType.DatabaseTypeName(), "varchar") {
+				continue
+			}
+			err = db.Model(value).Where("bet_result_amount = ?", "").Update("bet_result_amount", nil).Error
+			if err != nil {
+				return err
+			}
+			// 旧版本按 %+.2f 写入的盈利金额带有前导 + 号
+			err = db.Model(value).Where("bet_result_amount LIKE ?", "+%").Update("bet_result_amount", gorm.Expr("SUBSTR(bet_result_amount, 2)")).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
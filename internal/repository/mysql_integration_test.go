@@ -0,0 +1,97 @@
+//go:build mysql
+
+package repository
+
+import (
+	"gorm.io/gorm"
+	"os"
+	"strings"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/model"
+	"testing"
+)
+
+// 在 MySQL 上执行完整迁移 旧的文本下注结果需转换为数值字段
+// go test -tags mysql ./internal/repository -run TestMySQLMigrateBetResultAmount
+// TEST_MYSQL_DSN 指向一个可以随意建表删表的测试库
+func TestMySQLMigrateBetResultAmount(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("未设置 TEST_MYSQL_DSN")
+	}
+	db, err := database.InitDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&model.QuickThereBetRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	table := stmt.Schema.Table
+
+	// 按旧版本建表 其余字段由迁移补齐
+	if err := db.Migrator().DropTable(table); err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("CREATE TABLE " + table + " (id varchar(64) PRIMARY KEY, bet_result_amount varchar(255))").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Migrator().DropTable(table)
+	})
+
+	rows := map[string]string{
+		"unsettled": "",
+		"win":       "19.50",
+		"loss":      "-10",
+		"refund":    "10.0",
+		"signedWin": "+19.50",
+		"signedTie": "+0.00",
+	}
+	for id, amount := range rows {
+		err = db.Exec("INSERT INTO "+table+" (id, bet_result_amount) VALUES (?, ?)", id, amount).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := NewMySQLStore(db).Migrate(); err != nil {
+		t.Fatalf("Migrate err = %v", err)
+	}
+
+	columnTypes, err := db.Migrator().ColumnTypes(&model.QuickThereBetRecord{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, columnType := range columnTypes {
+		if columnType.Name() == "bet_result_amount" && !strings.EqualFold(columnType.DatabaseTypeName(), "decimal") {
+			t.Fatalf("bet_result_amount 字段类型 = %s, want decimal", columnType.DatabaseTypeName())
+		}
+	}
+
+	var betRecords []*model.QuickThereBetRecord
+	err = db.Table(table).Select("id", "bet_result_amount").Find(&betRecords).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*decimal.Decimal{
+		"unsettled": nil,
+		"win":       decimalPtr(decimal.MustParse("19.5")),
+		"loss":      decimalPtr(decimal.MustParse("-10")),
+		"refund":    decimalPtr(decimal.MustParse("10")),
+		"signedWin": decimalPtr(decimal.MustParse("19.5")),
+		"signedTie": decimalPtr(decimal.Zero),
+	}
+	if len(betRecords) != len(want) {
+		t.Fatalf("下注记录数 = %d, want %d", len(betRecords), len(want))
+	}
+	for _, betRecord := range betRecords {
+		wantAmount := want[betRecord.Id]
+		gotAmount := betRecord.BetResultAmount
+		if (gotAmount == nil) != (wantAmount == nil) || (gotAmount != nil && *gotAmount != *wantAmount) {
+			t.Errorf("%s BetResultAmount = %v, want %v", betRecord.Id, gotAmount, wantAmount)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/decimal"
+	"telegram-dice-bot/internal/model"
+	"testing"
+)
+
+// 下注结果字段由文本改为数值前的旧数据 未结算记录为空字符串 盈利金额按 %+.2f 写入带有 + 号
+func TestMigrateBetResultAmount(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&model.QuickThereBetRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	table := stmt.Schema.Table
+
+	err = db.Exec("CREATE TABLE " + table + " (id varchar(64) PRIMARY KEY, bet_result_amount varchar(255))").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := map[string]string{
+		"unsettled": "",
+		"win":       "19.50",
+		"loss":      "-10",
+		"refund":    "10.0",
+		"signedWin": "+19.50",
+		"signedTie": "+0.00",
+	}
+	for id, amount := range rows {
+		err = db.Exec("INSERT INTO "+table+" (id, bet_result_amount) VALUES (?, ?)", id, amount).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := migrateBetResultAmount(db); err != nil {
+		t.Fatalf("migrateBetResultAmount err = %v", err)
+	}
+
+	var betRecords []*model.QuickThereBetRecord
+	err = db.Table(table).Select("id", "bet_result_amount").Find(&betRecords).Error
+	if err != nil {
+		t.Fatalf("读取迁移后的下注记录 err = %v", err)
+	}
+
+	want := map[string]*decimal.Decimal{
+		"unsettled": nil,
+		"win":       decimalPtr(decimal.MustParse("19.5")),
+		"loss":      decimalPtr(decimal.MustParse("-10")),
+		"refund":    decimalPtr(decimal.MustParse("10")),
+		"signedWin": decimalPtr(decimal.MustParse("19.5")),
+		"signedTie": decimalPtr(decimal.Zero),
+	}
+	if len(betRecords) != len(want) {
+		t.Fatalf("下注记录数 = %d, want %d", len(betRecords), len(want))
+	}
+	for _, betRecord := range betRecords {
+		wantAmount := want[betRecord.Id]
+		gotAmount := betRecord.BetResultAmount
+		if (gotAmount == nil) != (wantAmount == nil) || (gotAmount != nil && *gotAmount != *wantAmount) {
+			t.Errorf("%s BetResultAmount = %v, want %v", betRecord.Id, gotAmount, wantAmount)
+		}
+	}
+
+	// 迁移后的文本可以直接转换为数值字段
+	var signedCount int64
+	err = db.Table(table).Where("bet_result_amount LIKE ?", "+%").Count(&signedCount).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signedCount != 0 {
+		t.Fatalf("带有 + 号的下注结果数 = %d, want 0", signedCount)
+	}
+
+	// 已迁移后再次执行不报错
+	if err := migrateBetResultAmount(db); err != nil {
+		t.Fatalf("再次执行 migrateBetResultAmount err = %v", err)
+	}
+}
+
+func decimalPtr(d decimal.Decimal) *decimal.Decimal {
+	return &d
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"telegram-dice-bot/internal/database"
+)
+
+// Open 按 DSN 前缀连接数据库并返回对应的存储 sqlite:// 使用 SQLite 其余使用 MySQL
+func Open(dsn string) (Store, error) {
+	db, err := database.InitDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	dialect, _ := database.ParseDSN(dsn)
+	if dialect == database.DialectSQLite {
+		return NewSQLiteStore(db), nil
+	}
+	return NewMySQLStore(db), nil
+}
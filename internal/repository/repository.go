@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"telegram-dice-bot/internal/model"
+)
+
+// ChatGroupRepository 群配置
+type ChatGroupRepository interface {
+	Create(chatGroup *model.ChatGroup) error
+	Save(chatGroup *model.ChatGroup) error
+	QueryById(id string) (*model.ChatGroup, error)
+	QueryByTgChatId(tgChatId int64) (*model.ChatGroup, error)
+	ListByIds(ids []string) ([]*model.ChatGroup, error)
+	ListByGameplayStatus(gameplayStatus int) ([]*model.ChatGroup, error)
+	// UpdateColumnsById 按 Id 更新 chatGroup 的指定字段(数据库字段名)
+	UpdateColumnsById(chatGroup *model.ChatGroup, columns ...string) error
+	UpdateChatGroupStatusByTgChatId(chatGroup *model.ChatGroup) error
+	UpdateGameplayStatusAndChatGroupStatusByTgChatId(chatGroup *model.ChatGroup) error
+}
+
+// ChatGroupUserRepository 群用户
+type ChatGroupUserRepository interface {
+	Create(chatGroupUser *model.ChatGroupUser) error
+	Save(chatGroupUser *model.ChatGroupUser) error
+	QueryById(id string) (*model.ChatGroupUser, error)
+	QueryByIdAndChatGroupId(id string, chatGroupId string) (*model.ChatGroupUser, error)
+	QueryByTgUserIdAndChatGroupId(tgUserId int64, chatGroupId string) (*model.ChatGroupUser, error)
+	QueryByUsernameAndChatGroupId(username string, chatGroupId string) (*model.ChatGroupUser, error)
+	ListByTgUserId(tgUserId int64) ([]*model.ChatGroupUser, error)
+	ListByTgUserIdAndIsLeft(tgUserId int64, isLeft int) ([]*model.ChatGroupUser, error)
+}
+
+// ChatGroupAdminRepository 群管理员
+type ChatGroupAdminRepository interface {
+	Create(chatGroupAdmin *model.ChatGroupAdmin) error
+	QueryByChatGroupIdAndTgUserId(chatGroupId string, adminTgUserId int64) (*model.ChatGroupAdmin, error)
+	ListByAdminTgUserId(adminTgUserId int64) ([]*model.ChatGroupAdmin, error)
+	DeleteByChatGroupId(chatGroupId string) error
+	DeleteByChatGroupIdAndAdminTgUserId(chatGroupId string, adminTgUserId int64) error
+}
+
+// BetRecordRepository 下注主表 玩法下注明细仍由各玩法通过 Store.DB 读写
+type BetRecordRepository interface {
+	Create(betRecord *model.BetRecord) error
+	ListByChatGroupUserId(chatGroupUserId string) ([]*model.BetRecord, error)
+	// ListChatGroupUserIdsByChatGroupIdAndIssueNumber 该期下注的用户ID 已去重
+	ListChatGroupUserIdsByChatGroupIdAndIssueNumber(chatGroupId string, issueNumber string) ([]string, error)
+}
+
+// LotteryRecordRepository 开奖主表 玩法开奖明细仍由各玩法通过 Store.DB 读写
+type LotteryRecordRepository interface {
+	Create(lotteryRecord *model.LotteryRecord) error
+	Save(lotteryRecord *model.LotteryRecord) error
+	QueryById(id string) (*model.LotteryRecord, error)
+	QueryByChatGroupIdAndIssueNumber(chatGroupId string, issueNumber string) (*model.LotteryRecord, error)
+	ListByChatGroupId(chatGroupId string) ([]*model.LotteryRecord, error)
+}
+
+// Store 数据存储 按 DSN 选择 MySQL 或 SQLite 实现
+type Store interface {
+	ChatGroups() ChatGroupRepository
+	ChatGroupUsers() ChatGroupUserRepository
+	ChatGroupAdmins() ChatGroupAdminRepository
+	BetRecords() BetRecordRepository
+	LotteryRecords() LotteryRecordRepository
+	// Begin 开启事务 通过返回的 Tx 访问的仓储均在该事务内执行
+	Begin() Tx
+	// DB 底层连接 玩法配置及明细、对决、积分流水等尚未抽象为仓储的表直接使用
+	DB() *gorm.DB
+	// Migrate 自动迁移表结构
+	Migrate() error
+	// Close 关闭数据库连接
+	Close() error
+}
+
+// Tx 事务
+type Tx interface {
+	Store
+	Commit() error
+	// Rollback 回滚事务 已提交或已回滚时不做任何操作 开启事务后可直接 defer
+	Rollback()
+}
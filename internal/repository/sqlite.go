@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// NewSQLiteStore SQLite 存储 适合单机部署 无需 MySQL 服务
+// SQLite 从该版本起才支持 无历史表结构需要兼容
+func NewSQLiteStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
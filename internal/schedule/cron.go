@@ -0,0 +1,153 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("cron表达式格式错误")
+
+// 查找下一个开奖时间的最大年数 超过后视为没有可开奖的时间
+const cronSearchYears = 5
+
+// Cron 五段式 cron 表达式 分 时 日 月 周
+// 每段支持 * 、数字、范围 a-b、步长 */n a-b/n a/n 及逗号分隔的列表 周的0和7均表示周日
+type Cron struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	// 日或周为 * 时只按另一段匹配 均不为 * 时满足其一即可(与标准 cron 一致)
+	dayOfMonthStar, dayOfWeekStar bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"分", 0, 59},
+	{"时", 0, 23},
+	{"日", 1, 31},
+	{"月", 1, 12},
+	{"周", 0, 7},
+}
+
+// ParseCron 解析 cron 表达式
+func ParseCron(expr string) (*Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%w: 需要5段(分 时 日 月 周)", ErrInvalidCron)
+	}
+
+	bits := make([]uint64, len(cronFields))
+	for i, field := range cronFields {
+		var err error
+		bits[i], err = parseCronField(parts[i], field)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 周的7与0均表示周日
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &Cron{
+		minute:         bits[0],
+		hour:           bits[1],
+		dayOfMonth:     bits[2],
+		month:          bits[3],
+		dayOfWeek:      bits[4],
+		dayOfMonthStar: parts[2] == "*",
+		dayOfWeekStar:  parts[4] == "*",
+	}, nil
+}
+
+func parseCronField(text string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("%w: %s段步长 %s 不合法", ErrInvalidCron, field.name, item)
+			}
+		}
+
+		start, end := field.min, field.max
+		if rangeText != "*" {
+			startText, endText, hasRange := strings.Cut(rangeText, "-")
+			var err error
+			start, err = strconv.Atoi(startText)
+			if err != nil {
+				return 0, fmt.Errorf("%w: %s段 %s 不合法", ErrInvalidCron, field.name, item)
+			}
+			if hasRange {
+				end, err = strconv.Atoi(endText)
+				if err != nil {
+					return 0, fmt.Errorf("%w: %s段 %s 不合法", ErrInvalidCron, field.name, item)
+				}
+			} else if !hasStep {
+				end = start
+			}
+		}
+		if start < field.min || end > field.max || start > end {
+			return 0, fmt.Errorf("%w: %s段 %s 超出范围[%d-%d]", ErrInvalidCron, field.name, item, field.min, field.max)
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dayOfMonthMatch := c.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeekMatch := c.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if c.dayOfMonthStar || c.dayOfWeekStar {
+		return dayOfMonthMatch && dayOfWeekMatch
+	}
+	return dayOfMonthMatch || dayOfWeekMatch
+}
+
+// Next t 之后(不含)第一个满足表达式的整分钟时间 按 t 的时区计算 没有时返回零值
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	yearLimit := t.Year() + cronSearchYears
+
+	for t.Year() <= yearLimit {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc))
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// advance 夏令时切换时 time.Date 可能回到更早的时间 此时按一分钟推进 保证查找不会停滞
+func advance(t time.Time, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Minute)
+}
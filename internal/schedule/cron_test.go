@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"段数不足", "* * * *"},
+		{"段数过多", "* * * * * *"},
+		{"分超出范围", "60 * * * *"},
+		{"时超出范围", "0 24 * * *"},
+		{"日为0", "0 0 0 * *"},
+		{"月超出范围", "0 0 1 13 *"},
+		{"周超出范围", "0 0 * * 8"},
+		{"范围倒置", "0 10-5 * * *"},
+		{"步长为0", "*/0 * * * *"},
+		{"步长非数字", "*/a * * * *"},
+		{"非数字", "a * * * *"},
+		{"范围非数字", "0 1-b * * *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if !errors.Is(err, ErrInvalidCron) {
+				t.Fatalf("ParseCron(%q) err = %v, want ErrInvalidCron", tt.expr, err)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-01 是周一
+	base := time.Date(2024, 1, 1, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"每分钟", "* * * * *", base, time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"不含当前分钟", "30 10 * * *", time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC), time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)},
+		{"步长", "*/15 * * * *", base, time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"范围加步长", "0 9-17/4 * * *", base, time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"起始加步长", "5/20 * * * *", base, time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"列表", "0 12,20 * * *", base, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"列表跨天", "0 8,9 * * *", base, time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"指定日", "0 0 15 * *", base, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"指定月跨年", "0 0 1 1 *", base, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"指定周", "0 0 * * 5", base, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"周的7为周日", "0 0 * * 7", base, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"周的0为周日", "0 0 * * 0", base, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"日与周满足其一", "0 0 3 * 6", base, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"日为星号时只按周", "0 0 * * 3", base, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"闰日", "0 0 29 2 *", base, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"没有可开奖的时间", "0 0 31 2 *", base, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) err = %v", tt.expr, err)
+			}
+			if got := cron.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextLocation(t *testing.T) {
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cron, err := ParseCron("0 12 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// UTC 05:00 即上海 13:00 下一个开奖时间为上海次日 12:00
+	got := cron.Next(time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC).In(location))
+	want := time.Date(2024, 1, 2, 12, 0, 0, 0, location)
+	if !got.Equal(want) {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+}
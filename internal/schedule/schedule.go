@@ -0,0 +1,195 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// 内置时区数据 运行环境缺少系统时区数据时也能加载群配置的时区
+	_ "time/tzdata"
+)
+
+var (
+	ErrInvalidQuietHours = errors.New("静默时段格式错误")
+	ErrInvalidTimezone   = errors.New("时区不存在")
+	ErrNoDrawTime        = errors.New("开奖计划内没有可开奖的时间")
+)
+
+const (
+	// 跳过静默时段时最多尝试的开奖时间数 超过后视为没有可开奖的时间
+	maxQuietSkips = 100000
+	// 计算最短开奖间隔时查找的时长及最多开奖次数
+	minIntervalSearchDuration = 7 * 24 * time.Hour
+	minIntervalSearchDraws    = 20000
+)
+
+// Schedule 群的开奖计划 按 cron 表达式开奖 未设置时按开奖周期从每天0点起对齐开奖 静默时段内不开奖
+type Schedule struct {
+	cron       *Cron
+	cycle      time.Duration
+	quietHours []quietWindow
+	location   *time.Location
+}
+
+// quietWindow 静默时段 单位为当天的分钟数 start 大于 end 时表示跨越0点
+type quietWindow struct {
+	start, end int
+}
+
+// Parse 解析开奖计划 cronExpr 为空时按 cycle 开奖 quietHours 例: 23:00-09:00,12:00-13:00 timezone 为空时使用服务器时区
+func Parse(cronExpr string, quietHours string, timezone string, cycle time.Duration) (*Schedule, error) {
+	schedule := &Schedule{
+		cycle:    cycle,
+		location: time.Local,
+	}
+
+	if cronExpr != "" {
+		cron, err := ParseCron(cronExpr)
+		if err != nil {
+			return nil, err
+		}
+		schedule.cron = cron
+	}
+
+	if quietHours != "" {
+		for _, item := range strings.Split(quietHours, ",") {
+			window, err := parseQuietWindow(strings.TrimSpace(item))
+			if err != nil {
+				return nil, err
+			}
+			schedule.quietHours = append(schedule.quietHours, window)
+		}
+	}
+
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTimezone, timezone)
+		}
+		schedule.location = location
+	}
+
+	return schedule, nil
+}
+
+func parseQuietWindow(text string) (quietWindow, error) {
+	startText, endText, found := strings.Cut(text, "-")
+	if !found {
+		return quietWindow{}, fmt.Errorf("%w: %s", ErrInvalidQuietHours, text)
+	}
+	start, err := parseClock(startText)
+	if err != nil {
+		return quietWindow{}, fmt.Errorf("%w: %s", ErrInvalidQuietHours, text)
+	}
+	end, err := parseClock(endText)
+	if err != nil || start == end {
+		return quietWindow{}, fmt.Errorf("%w: %s", ErrInvalidQuietHours, text)
+	}
+	return quietWindow{start: start, end: end}, nil
+}
+
+// parseClock 解析 HH:MM 为当天的分钟数 24:00 表示当天结束
+func parseClock(text string) (int, error) {
+	hourText, minuteText, found := strings.Cut(strings.TrimSpace(text), ":")
+	if !found {
+		return 0, ErrInvalidQuietHours
+	}
+	hour, err := strconv.Atoi(hourText)
+	if err != nil {
+		return 0, err
+	}
+	minute, err := strconv.Atoi(minuteText)
+	if err != nil {
+		return 0, err
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, ErrInvalidQuietHours
+	}
+	return hour*60 + minute, nil
+}
+
+// Location 开奖计划的时区
+func (s *Schedule) Location() *time.Location {
+	return s.location
+}
+
+// inQuietHours t 是否处于静默时段
+func (s *Schedule) inQuietHours(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	for _, window := range s.quietHours {
+		if window.start < window.end {
+			if minute >= window.start && minute < window.end {
+				return true
+			}
+		} else if minute >= window.start || minute < window.end {
+			return true
+		}
+	}
+	return false
+}
+
+// next 不考虑静默时段时 t 之后(不含)的下一个开奖时间
+func (s *Schedule) next(t time.Time) time.Time {
+	if s.cron != nil {
+		return s.cron.Next(t)
+	}
+	return alignedDrawTime(t, s.cycle)
+}
+
+// Next t 之后(不含)的下一个开奖时间 使用开奖计划的时区 没有时返回零值
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.location)
+	for i := 0; i < maxQuietSkips; i++ {
+		t = s.next(t)
+		if t.IsZero() || !s.inQuietHours(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Preview t 之后的 count 个开奖时间 用于配置时预览
+func (s *Schedule) Preview(t time.Time, count int) ([]time.Time, error) {
+	var drawTimes []time.Time
+	for i := 0; i < count; i++ {
+		t = s.Next(t)
+		if t.IsZero() {
+			break
+		}
+		drawTimes = append(drawTimes, t)
+	}
+	if len(drawTimes) == 0 {
+		return nil, ErrNoDrawTime
+	}
+	return drawTimes, nil
+}
+
+// MinInterval t 之后一周内相邻两次开奖的最短间隔 用于校验封盘时间 不足两次开奖时返回0
+func (s *Schedule) MinInterval(t time.Time) time.Duration {
+	var minInterval time.Duration
+	searchEnd := t.Add(minIntervalSearchDuration)
+	drawTime := s.Next(t)
+	for i := 0; i < minIntervalSearchDraws && !drawTime.IsZero() && drawTime.Before(searchEnd); i++ {
+		nextTime := s.Next(drawTime)
+		if nextTime.IsZero() {
+			break
+		}
+		if interval := nextTime.Sub(drawTime); minInterval == 0 || interval < minInterval {
+			minInterval = interval
+		}
+		drawTime = nextTime
+	}
+	return minInterval
+}
+
+// alignedDrawTime t 之后按开奖周期对齐的下一个开奖时间 每天0点起对齐 周期不能整除一天时当天最后一期在0点开奖
+func alignedDrawTime(t time.Time, cycle time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	nextMidnight := midnight.AddDate(0, 0, 1)
+	drawTime := midnight.Add((t.Sub(midnight)/cycle + 1) * cycle)
+	if drawTime.After(nextMidnight) {
+		drawTime = nextMidnight
+	}
+	return drawTime
+}
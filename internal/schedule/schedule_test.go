@@ -0,0 +1,155 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseQuietHoursInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		quietHours string
+	}{
+		{"缺少结束时间", "23:00"},
+		{"缺少冒号", "2300-0900"},
+		{"小时超出范围", "25:00-09:00"},
+		{"分钟超出范围", "23:60-09:00"},
+		{"超过24点", "23:00-24:01"},
+		{"开始等于结束", "09:00-09:00"},
+		{"非数字", "ab:00-09:00"},
+		{"列表中有错误项", "23:00-09:00,12:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("", tt.quietHours, "", time.Minute)
+			if !errors.Is(err, ErrInvalidQuietHours) {
+				t.Fatalf("Parse(quietHours=%q) err = %v, want ErrInvalidQuietHours", tt.quietHours, err)
+			}
+		})
+	}
+}
+
+func TestParseInvalidTimezone(t *testing.T) {
+	_, err := Parse("", "", "Mars/Olympus", time.Minute)
+	if !errors.Is(err, ErrInvalidTimezone) {
+		t.Fatalf("Parse err = %v, want ErrInvalidTimezone", err)
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	tests := []struct {
+		name       string
+		quietHours string
+		clock      string
+		want       bool
+	}{
+		{"未设置", "", "03:00", false},
+		{"当天时段内", "12:00-13:00", "12:30", true},
+		{"当天时段开始", "12:00-13:00", "12:00", true},
+		{"当天时段结束不含", "12:00-13:00", "13:00", false},
+		{"当天时段外", "12:00-13:00", "11:59", false},
+		{"跨0点 0点前", "23:00-09:00", "23:30", true},
+		{"跨0点 0点", "23:00-09:00", "00:00", true},
+		{"跨0点 0点后", "23:00-09:00", "08:59", true},
+		{"跨0点 结束不含", "23:00-09:00", "09:00", false},
+		{"跨0点 时段外", "23:00-09:00", "22:59", false},
+		{"到24点", "22:00-24:00", "23:59", true},
+		{"到24点 0点不含", "22:00-24:00", "00:00", false},
+		{"多个时段 第二个", "23:00-09:00,12:00-13:00", "12:15", true},
+		{"多个时段 之间", "23:00-09:00,12:00-13:00", "10:00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse("", tt.quietHours, "UTC", time.Minute)
+			if err != nil {
+				t.Fatalf("Parse(quietHours=%q) err = %v", tt.quietHours, err)
+			}
+			clock, err := time.Parse("15:04", tt.clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := time.Date(2024, 1, 1, clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+			if got := schedule.inQuietHours(at); got != tt.want {
+				t.Fatalf("inQuietHours(%s) = %v, want %v", tt.clock, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		cronExpr   string
+		quietHours string
+		cycle      time.Duration
+		from       time.Time
+		want       time.Time
+	}{
+		{"按周期对齐", "", "", 5 * time.Minute, time.Date(2024, 1, 1, 10, 2, 30, 0, time.UTC), time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)},
+		{"周期不含当前时间", "", "", 5 * time.Minute, time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC), time.Date(2024, 1, 1, 10, 10, 0, 0, time.UTC)},
+		{"周期不能整除一天时0点开奖", "", "", 7 * time.Minute, time.Date(2024, 1, 1, 23, 57, 0, 0, time.UTC), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"跳过静默时段", "", "23:00-09:00", 30 * time.Minute, time.Date(2024, 1, 1, 22, 45, 0, 0, time.UTC), time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"静默时段前正常开奖", "", "23:00-09:00", 30 * time.Minute, time.Date(2024, 1, 1, 22, 15, 0, 0, time.UTC), time.Date(2024, 1, 1, 22, 30, 0, 0, time.UTC)},
+		{"cron 跳过静默时段", "0 * * * *", "12:00-14:00", time.Minute, time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC), time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)},
+		{"cron 全部处于静默时段", "0 3 * * *", "23:00-09:00", time.Minute, time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.cronExpr, tt.quietHours, "UTC", tt.cycle)
+			if err != nil {
+				t.Fatalf("Parse err = %v", err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextTimezone(t *testing.T) {
+	schedule, err := Parse("0 12 * * *", "", "Asia/Shanghai", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按开奖计划的时区计算 UTC 03:00 即上海 11:00
+	got := schedule.Next(time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC))
+	want := time.Date(2024, 1, 1, 4, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+	if got.Location() != schedule.Location() {
+		t.Fatalf("Next location = %v, want %v", got.Location(), schedule.Location())
+	}
+}
+
+func TestScheduleMinInterval(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		cronExpr   string
+		quietHours string
+		cycle      time.Duration
+		want       time.Duration
+	}{
+		{"按周期", "", "", 10 * time.Minute, 10 * time.Minute},
+		{"周期不能整除一天时取0点前的间隔", "", "", 7 * time.Minute, 5 * time.Minute},
+		{"静默时段不缩短间隔", "", "23:00-09:00", 30 * time.Minute, 30 * time.Minute},
+		{"cron 不按开奖周期", "0 12,20 * * *", "", time.Minute, 8 * time.Hour},
+		{"cron 间隔不均匀", "0,5 * * * *", "", time.Hour, 5 * time.Minute},
+		{"cron 每周一次", "0 12 * * 1", "", time.Minute, 7 * 24 * time.Hour},
+		{"没有可开奖的时间", "0 0 31 2 *", "", time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.cronExpr, tt.quietHours, "UTC", tt.cycle)
+			if err != nil {
+				t.Fatalf("Parse err = %v", err)
+			}
+			if got := schedule.MinInterval(from); got != tt.want {
+				t.Fatalf("MinInterval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}